@@ -6,5 +6,7 @@ require (
 	github.com/aws/aws-sdk-go v1.37.11
 	github.com/blend/go-sdk v1.20210216.2
 	github.com/spf13/cobra v1.1.1
+	github.com/yuin/goldmark v1.4.12
+	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 )
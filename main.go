@@ -18,6 +18,7 @@ blogctl
 	build : compile the posts into static pages
 	deploy : push it to aws/gcp/*
 	server : start a local server against the output folder
+	validate : check the config, posts and templates for mistakes
 
 flags:
 --config
@@ -39,6 +40,7 @@ func main() {
 	blogctl.AddCommand(cmd.New(flags))
 	blogctl.AddCommand(cmd.Server(flags))
 	blogctl.AddCommand(cmd.Show(flags))
+	blogctl.AddCommand(cmd.Validate(flags))
 
 	sh.Fatal(blogctl.Execute())
 }
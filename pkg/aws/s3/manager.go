@@ -257,6 +257,31 @@ func (m Manager) List(ctx context.Context, bucket string) ([]File, error) {
 	return files, nil
 }
 
+// ListPrefix lists all files in a bucket with keys that start with a given prefix.
+func (m Manager) ListPrefix(ctx context.Context, bucket, prefix string) ([]File, error) {
+	var files []File
+	err := s3.New(m.Session).ListObjectsPagesWithContext(ctx, &s3.ListObjectsInput{
+		Bucket: &bucket,
+		Prefix: &prefix,
+	}, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, file := range page.Contents {
+			files = append(files, File{
+				Bucket: bucket,
+				Key:    aws.DerefStr(file.Key),
+				ETag:   aws.DerefStr(file.ETag),
+			})
+		}
+		return true
+	})
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	return files, nil
+}
+
 // Get fetches a file at a given key
 func (m Manager) Get(ctx context.Context, bucket, key string) (file File, contents io.ReadCloser, err error) {
 	remoteFile, getErr := s3.New(m.Session).GetObjectWithContext(ctx, &s3.GetObjectInput{
@@ -0,0 +1,124 @@
+package blurhash
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// ErrInvalidComponents is returned if the component counts are out of range.
+const ErrInvalidComponents ex.Class = "blurhash; components must be between 1 and 9"
+
+// characters are the base83 digits.
+const characters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// Encode returns the blurhash of an image with a given number of horizontal and vertical components.
+//
+// The image is sampled at every pixel, so it should be downscaled first.
+// See: https://github.com/woltapp/blurhash/blob/master/Algorithm.md
+func Encode(xComponents, yComponents int, img image.Image) (string, error) {
+	if xComponents < 1 || xComponents > 9 || yComponents < 1 || yComponents > 9 {
+		return "", ex.New(ErrInvalidComponents)
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// convert to linear rgb once up front.
+	linear := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			linear[y*width+x] = [3]float64{
+				sRGBToLinear(int(r >> 8)),
+				sRGBToLinear(int(g >> 8)),
+				sRGBToLinear(int(b >> 8)),
+			}
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalization := 2.0
+			if i == 0 && j == 0 {
+				normalization = 1.0
+			}
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalization *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					pixel := linear[y*width+x]
+					factor[0] += basis * pixel[0]
+					factor[1] += basis * pixel[1]
+					factor[2] += basis * pixel[2]
+				}
+			}
+			scale := 1.0 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	output := new(strings.Builder)
+	encode83(output, (xComponents-1)+(yComponents-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		var actualMaximum float64
+		for _, factor := range ac {
+			for _, value := range factor {
+				actualMaximum = math.Max(actualMaximum, math.Abs(value))
+			}
+		}
+		quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		encode83(output, quantisedMaximum, 1)
+	} else {
+		encode83(output, 0, 1)
+	}
+
+	encode83(output, (linearToSRGB(dc[0])<<16)+(linearToSRGB(dc[1])<<8)+linearToSRGB(dc[2]), 4)
+	for _, factor := range ac {
+		encode83(output, encodeAC(factor, maximumValue), 2)
+	}
+	return output.String(), nil
+}
+
+func encodeAC(factor [3]float64, maximumValue float64) int {
+	quantise := func(value float64) int {
+		return int(math.Max(0, math.Min(18, math.Floor(signPow(value/maximumValue, 0.5)*9+9.5))))
+	}
+	return quantise(factor[0])*19*19 + quantise(factor[1])*19 + quantise(factor[2])
+}
+
+func encode83(output *strings.Builder, value, length int) {
+	for index := 1; index <= length; index++ {
+		digit := (value / int(math.Pow(83, float64(length-index)))) % 83
+		output.WriteByte(characters[digit])
+	}
+}
+
+func sRGBToLinear(value int) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exponent float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exponent), value)
+}
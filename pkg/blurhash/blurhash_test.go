@@ -0,0 +1,47 @@
+package blurhash
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestEncodeSolid(t *testing.T) {
+	assert := assert.New(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: 255, A: 255}}, image.Point{}, draw.Src)
+
+	hash, err := Encode(4, 3, img)
+	assert.Nil(err)
+	// the size flag, the ac maximum, the red dc ("TI:j"), and the 11 ac components;
+	// the basis functions don't sum to zero over the pixels so the ac components aren't flat.
+	assert.Equal("LfTI:j|cfQ|c|csUfQsUfQfQfQfQ", hash)
+	assert.True(strings.HasPrefix(hash[2:], "TI:j"))
+}
+
+func TestEncode(t *testing.T) {
+	assert := assert.New(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, image.Rect(0, 0, 4, 8), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(4, 0, 8, 8), &image.Uniform{C: color.Black}, image.Point{}, draw.Src)
+
+	hash, err := Encode(4, 3, img)
+	assert.Nil(err)
+	assert.Len(hash, 28)
+	assert.NotEqual('0', hash[1])
+
+	hash, err = Encode(1, 1, img)
+	assert.Nil(err)
+	assert.Len(hash, 6)
+
+	_, err = Encode(0, 3, img)
+	assert.NotNil(err)
+	_, err = Encode(4, 10, img)
+	assert.NotNil(err)
+}
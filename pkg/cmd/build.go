@@ -14,7 +14,8 @@ import (
 
 // Build returns the build command.
 func Build(flags config.Flags) *cobra.Command {
-	return &cobra.Command{
+	var rebuild, drafts *bool
+	cmd := &cobra.Command{
 		Use:   "build",
 		Short: "Build the photoblog",
 		Run: func(cmd *cobra.Command, args []string) {
@@ -34,9 +35,14 @@ func Build(flags config.Flags) *cobra.Command {
 				engine.OptConfig(cfg),
 				engine.OptLog(log),
 				engine.OptParallelism(*flags.Parallelism),
+				engine.OptRebuild(*rebuild),
+				engine.OptDrafts(*drafts),
 			).Build(context.Background()); err != nil {
 				Fatal(err)
 			}
 		},
 	}
+	rebuild = cmd.Flags().Bool("rebuild", false, "If we should ignore the build manifest, remove the output path and render every output")
+	drafts = cmd.Flags().Bool("drafts", false, "If we should include draft posts")
+	return cmd
 }
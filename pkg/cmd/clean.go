@@ -14,6 +14,7 @@ import (
 
 // Clean returns the clean command.
 func Clean(flags config.Flags) *cobra.Command {
+	var index, remote *bool
 	cmd := &cobra.Command{
 		Use:   "clean",
 		Short: "Clean caches",
@@ -27,15 +28,28 @@ func Clean(flags config.Flags) *cobra.Command {
 				log.Infof("using config path(s): %s", strings.Join(cfgPaths, ", "))
 			}
 
-			if err := engine.MustNew(
+			options := []engine.Option{
 				engine.OptConfig(cfg),
 				engine.OptLog(log),
 				engine.OptParallelism(*flags.Parallelism),
 				engine.OptDryRun(*flags.DryRun),
-			).CleanThumbnailCache(context.Background()); err != nil {
+			}
+			// the shared thumbnail cache is only purged when asked to, as other machines use it.
+			if !*remote {
+				options = append(options, engine.OptCacheStorage(engine.LocalCacheStorage{Path: cfg.ThumbnailCachePathOrDefault()}))
+			}
+			e := engine.MustNew(options...)
+			if *index {
+				if err := e.CleanSourceIndex(); err != nil {
+					Fatal(err)
+				}
+			}
+			if err := e.CleanThumbnailCache(context.Background()); err != nil {
 				Fatal(err)
 			}
 		},
 	}
+	index = cmd.Flags().Bool("index", false, "If we should also remove the source index, so every image is read again")
+	remote = cmd.Flags().Bool("remote", false, "If we should also purge orphaned thumbnails from the `thumbnailCache` bucket")
 	return cmd
 }
@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/blend/go-sdk/ansi/slant"
 	"github.com/blend/go-sdk/stringutil"
 	"github.com/blend/go-sdk/uuid"
 	"github.com/spf13/cobra"
 	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/engine"
+	"github.com/wcharczuk/blogctl/pkg/gpx"
+	"github.com/wcharczuk/blogctl/pkg/model"
 )
 
 // Fix returns the fix tree of commands.
@@ -61,5 +69,84 @@ func Fix(flags config.Flags) *cobra.Command {
 		},
 	}
 	cmd.AddCommand(slugify)
+
+	var gpxPaths *[]string
+	var clockOffset, maxGap *time.Duration
+	var timeZone *string
+	var overwrite *bool
+	geotag := &cobra.Command{
+		Use:   "geotag",
+		Short: "Write the location of image posts to their meta from gpx tracks, by the image capture dates",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, cfgPaths, err := config.ReadConfig(flags)
+			Fatal(err)
+
+			log := Logger(flags, "geotag")
+			slant.Print(log.Output, "BLOGCTL")
+
+			if len(cfgPaths) > 0 {
+				log.Infof("using config path(s): %s", strings.Join(cfgPaths, ", "))
+			}
+			if len(*gpxPaths) == 0 {
+				Fatal(fmt.Errorf("at least one gpx file is required; set it with --gpx"))
+			}
+
+			var points gpx.Points
+			for _, gpxPath := range *gpxPaths {
+				track, err := gpx.ReadFile(gpxPath)
+				Fatal(err)
+				points = append(points, track.Points()...)
+			}
+			sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+			log.Infof("read %d track points", len(points))
+
+			options := engine.GeotagOptions{
+				ClockOffset: *clockOffset,
+				MaxGap:      *maxGap,
+			}
+			if *timeZone != "" {
+				options.TimeZone, err = time.LoadLocation(*timeZone)
+				Fatal(err)
+			}
+
+			e := engine.MustNew(
+				engine.OptConfig(cfg),
+				engine.OptLog(log),
+				engine.OptDryRun(flags.DryRun != nil && *flags.DryRun),
+			)
+			posts, err := e.DiscoverAllPosts(context.Background())
+			Fatal(err)
+			Fatal(e.SaveSourceIndex())
+
+			var untagged []*model.Post
+			for _, post := range posts {
+				if _, ok := post.GeotaggedImage(); (ok || post.Meta.HasLocation()) && !*overwrite {
+					log.Debugf("%s: skipping post that already has a location", post.OriginalPath)
+					continue
+				}
+				untagged = append(untagged, post)
+			}
+
+			for _, match := range engine.Geotag(untagged, points, options) {
+				if !match.IsMatched(options.MaxGap) {
+					log.Infof("%s: no track point within %v of %v", match.Post.OriginalPath, options.MaxGap, match.CaptureDate.Format(time.RFC3339))
+					continue
+				}
+				metaPath := filepath.Join(match.Post.OriginalPath, constants.FileMeta)
+				if flags.DryRun != nil && *flags.DryRun {
+					log.Infof("(dry run) would geotag %s at %f,%f (captured %v, %v from the nearest track point)", match.Post.OriginalPath, match.Position.Latitude, match.Position.Longitude, match.CaptureDate.Format(time.RFC3339), match.Gap)
+					continue
+				}
+				Fatal(engine.WriteMetaLocation(metaPath, match.Position.Latitude, match.Position.Longitude, match.Position.Elevation))
+				log.Infof("geotagged %s at %f,%f (captured %v, %v from the nearest track point)", match.Post.OriginalPath, match.Position.Latitude, match.Position.Longitude, match.CaptureDate.Format(time.RFC3339), match.Gap)
+			}
+		},
+	}
+	gpxPaths = geotag.Flags().StringSlice("gpx", nil, "The gpx track file(s) to match capture dates against (can be repeated)")
+	clockOffset = geotag.Flags().Duration("clock-offset", 0, "How far ahead of the actual time the camera clock is, e.g. 90s if it is 90 seconds fast (or -90s if it is slow)")
+	timeZone = geotag.Flags().String("timezone", "", "The time zone the camera clock is set to, e.g. America/Los_Angeles (defaults to the time zone in the exif data, or the local time zone)")
+	maxGap = geotag.Flags().Duration("max-gap", 5*time.Minute, "The maximum time between a capture date and the nearest track point")
+	overwrite = geotag.Flags().Bool("overwrite", false, "If posts that already have a location should be geotagged")
+	cmd.AddCommand(geotag)
 	return cmd
 }
@@ -108,6 +108,8 @@ const (
 	<meta name="description" content="{{ .Config.Description }}">
 	<meta name="viewport" content="width=device-width, initial-scale=1">
 	<link rel="stylesheet" href="/css/site.css">
+	<link rel="alternate" type="application/atom+xml" title="{{ .Config.Title }}" href="/feed.xml">
+	<link rel="alternate" type="application/rss+xml" title="{{ .Config.Title }}" href="/rss.xml">
 </head>
 <body>
 	<div class="content">
@@ -120,18 +122,25 @@ const (
 {{ end }}`
 
 	indexHTML = `{{ template "header" . }}
-{{ range $index, $post := .Posts }}
+{{ range $index, $post := .Pagination.Posts }}
 	<div class="post">
-		<img src="{{ $post.ImageSourceSmall }}" />
+		<img src="/{{ $post.ImagePathSmall }}" />
 	</div>
 {{ else }}
 	<h2>No Posts.</h2>
 {{ end }}
+{{ if .Pagination.HasPrevious }}<a class="previous" href="{{ .Pagination.PreviousURL }}">Newer</a>{{ end }}
+{{ if .Pagination.HasNext }}<a class="next" href="{{ .Pagination.NextURL }}">Older</a>{{ end }}
 {{ template "footer" . }}`
 
 	imageHTML = `{{ template "header" . }}
 <div class="image post">
-	<img src="{{ .Post.ImageSourceLarge}}" />
+	{{ range $index, $image := .Post.Images }}
+	<figure>
+		{{ image_tag $image }}
+		{{ if $image.Caption }}<figcaption>{{ $image.Caption }}</figcaption>{{ end }}
+	</figure>
+	{{ end }}
 </div>
 {{ template "footer" . }}`
 
@@ -143,14 +152,16 @@ const (
 
 	tagHTML = `{{ template "header" . }}
 <div class="tag">
-	{{ range $index, $post := .Tag.Posts }}
+	{{ range $index, $post := .Pagination.Posts }}
 	<div class="post">
-		<img src="{{$post.ImageSourceSmall}}" />
+		<img src="/{{ $post.ImagePathSmall }}" />
 	</div>
 	{{ else }}
 	<h2>No Posts For Tag.</h2>
 	{{ end }}
 </div>
+{{ if .Pagination.HasPrevious }}<a class="previous" href="{{ .Pagination.PreviousURL }}">Newer</a>{{ end }}
+{{ if .Pagination.HasNext }}<a class="next" href="{{ .Pagination.NextURL }}">Older</a>{{ end }}
 {{ template "footer" . }}`
 
 	siteCSS = `body { font-family: 'sans-serif'; margin: 0; padding: 0; }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/blend/go-sdk/ansi/slant"
+	"github.com/blend/go-sdk/logger"
 	"github.com/blend/go-sdk/stringutil"
 
 	"github.com/wcharczuk/blogctl/pkg/config"
@@ -21,6 +23,7 @@ import (
 // New returns a new post command.
 func New(flags config.Flags) *cobra.Command {
 	var title, location, posted *string
+	var skipDuplicates *bool
 	var tags *[]string
 	cmd := &cobra.Command{
 		Use:   "new [IMAGE_PATH]",
@@ -52,6 +55,10 @@ func New(flags config.Flags) *cobra.Command {
 				*title = filepath.Base(imagePath)
 			}
 
+			if !*skipDuplicates {
+				warnDuplicates(log, cfg, imagePath)
+			}
+
 			path := fmt.Sprintf("%s/%s-%s", cfg.PostsPathOrDefault(), postedDate.Format("2006-01-02"), stringutil.Slugify(*title))
 			log.Infof("writing new post to %s", path)
 			if _, err := os.Stat(path); err == nil {
@@ -78,5 +85,56 @@ func New(flags config.Flags) *cobra.Command {
 	location = cmd.Flags().String("location", "", "The location (optional)")
 	posted = cmd.Flags().String("posted", "", "The posted effective date (optional)")
 	tags = cmd.Flags().StringArray("tag", nil, "Photo tags (optional)")
+	skipDuplicates = cmd.Flags().Bool("skip-duplicates", false, "If we should skip checking if the image looks like an image that's already posted")
 	return cmd
 }
+
+// warnDuplicates logs a warning for each posted image that looks like the image at a given path.
+// The check is best effort; images that can't be read or hashed are logged and skipped.
+func warnDuplicates(log *logger.Logger, cfg config.Config, imagePath string) {
+	ctx := context.Background()
+	e := engine.MustNew(
+		engine.OptConfig(cfg),
+		engine.OptLog(log),
+	)
+	image, err := engine.ReadImage(imagePath)
+	if err != nil {
+		log.Warningf("%s: skipping the duplicate check: %v", imagePath, err)
+		return
+	}
+	source := e.NewSourceImage(ctx, image)
+	image.PerceptualHash, err = e.PerceptualHash(ctx, source)
+	source.Close()
+	if err != nil {
+		log.Warningf("%s: skipping the duplicate check: %v", imagePath, err)
+		return
+	}
+
+	posts, err := e.DiscoverAllPosts(ctx)
+	if err != nil {
+		log.Warningf("skipping the duplicate check: %v", err)
+		return
+	}
+	if err := e.ProcessSourceImages(ctx, posts, func(postImage *model.Image, source *engine.SourceImage) (err error) {
+		if postImage.PerceptualHash, err = e.PerceptualHash(ctx, source); err != nil {
+			log.Warningf("%s: skipping in the duplicate check: %v", postImage.SourcePath, err)
+		}
+		return nil
+	}); err != nil {
+		log.Warningf("skipping the duplicate check: %v", err)
+		return
+	}
+
+	if err := e.SaveSourceIndex(); err != nil {
+		log.Warningf("saving the source index: %v", err)
+	}
+
+	duplicates, err := engine.FindDuplicates(posts, image, constants.DefaultDuplicateDistance)
+	if err != nil {
+		log.Warningf("skipping the duplicate check: %v", err)
+		return
+	}
+	for _, duplicate := range duplicates {
+		log.Warningf("%s looks like %s from %q (distance %d)", imagePath, duplicate.SourcePath, duplicate.Title, duplicate.Distance)
+	}
+}
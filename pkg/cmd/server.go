@@ -1,21 +1,27 @@
 package cmd
 
 import (
+	"context"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/blend/go-sdk/ansi/slant"
 	"github.com/blend/go-sdk/graceful"
+	"github.com/blend/go-sdk/logger"
 	"github.com/blend/go-sdk/web"
 
 	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/engine"
+	"github.com/wcharczuk/blogctl/pkg/livereload"
 )
 
 // Server returns the server command.
 func Server(flags config.Flags) *cobra.Command {
 	var bindAddr *string
-	var cached *bool
+	var cached, watch, drafts *bool
+	var watchInterval *time.Duration
 	var statics *[]string
 	cmd := &cobra.Command{
 		Use:   "server",
@@ -30,8 +36,9 @@ func Server(flags config.Flags) *cobra.Command {
 			if len(cfgPaths) > 0 {
 				log.Infof("using config path(s): %s", strings.Join(cfgPaths, ", "))
 			}
-			if *cached {
-				log.Infof("using cached static file server")
+			if *cached && *watch {
+				log.Infof("ignoring --cached; files are rebuilt while watching")
+				*cached = false
 			}
 
 			files := cfg.OutputPathOrDefault()
@@ -41,12 +48,21 @@ func Server(flags config.Flags) *cobra.Command {
 			filePaths := append(*statics, files)
 			log.Infof("using static search paths: %s", strings.Join(filePaths, ", "))
 
+			var middleware []web.Middleware
+			if *watch {
+				broker := livereload.New()
+				broker.Log = log
+				middleware = append(middleware, broker.Middleware)
+				log.Infof("watching for changes every %v", *watchInterval)
+				go watchAndRebuild(context.Background(), flags, log, broker, *watchInterval, *drafts)
+			}
+
 			if *cached {
 				log.Infof("using cached static file server")
-				app.ServeStaticCached("/", filePaths)
+				app.ServeStaticCached("/", filePaths, middleware...)
 			} else {
 				log.Infof("using live static file server")
-				app.ServeStatic("/", filePaths)
+				app.ServeStatic("/", filePaths, middleware...)
 			}
 
 			_ = app.SetStaticRewriteRule("/", "/$", func(filePath string, matchedPieces ...string) string {
@@ -61,5 +77,53 @@ func Server(flags config.Flags) *cobra.Command {
 	bindAddr = cmd.Flags().String("bind-addr", ":9000", "The bind address for the static webserver.")
 	statics = cmd.Flags().StringArray("static", nil, "Alternate static directories to serve from.")
 	cached = cmd.Flags().Bool("cached", false, "If we should cache static files in memory.")
+	watch = cmd.Flags().Bool("watch", false, "If we should rebuild when the posts, pages, partials, statics or config change, and reload open browser tabs.")
+	drafts = cmd.Flags().Bool("drafts", false, "If we should include draft posts when watching.")
+	watchInterval = cmd.Flags().Duration("watch-interval", 500*time.Millisecond, "How often to poll for changes when watching.")
 	return cmd
 }
+
+// watchAndRebuild builds the blog, then rebuilds it every time its inputs change.
+// Build errors are logged and sent to open browser tabs rather than stopping the server.
+func watchAndRebuild(ctx context.Context, flags config.Flags, log logger.Log, broker *livereload.Broker, interval time.Duration, drafts bool) {
+	var snapshot engine.Snapshot
+	for {
+		// re-read the config every pass so changes to it, including to the watched paths, are picked up.
+		cfg, cfgPaths, err := config.ReadConfig(flags)
+		e := engine.MustNew(
+			engine.OptConfig(cfg),
+			engine.OptLog(log),
+			engine.OptParallelism(*flags.Parallelism),
+			engine.OptDrafts(drafts),
+		)
+		paths := append(e.WatchPaths(), *flags.ConfigPath)
+		paths = append(paths, cfgPaths...)
+
+		// if the paths can't be snapshotted, changes are compared against the last snapshot.
+		if next, snapshotErr := engine.TakeSnapshot(paths...); snapshotErr != nil {
+			logger.MaybeError(log, snapshotErr)
+		} else {
+			snapshot = next
+		}
+		if err == nil {
+			err = e.Build(ctx)
+		}
+		if err != nil {
+			logger.MaybeError(log, err)
+			broker.Error(err)
+		} else {
+			broker.Reload()
+		}
+
+		// errors polling for changes are retried rather than treated as a change.
+		changed, err := engine.WaitForChanges(ctx, snapshot, interval, paths...)
+		for err != nil && ctx.Err() == nil {
+			logger.MaybeError(log, err)
+			changed, err = engine.WaitForChanges(ctx, snapshot, interval, paths...)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		logger.MaybeInfof(log, "rebuilding; %d file(s) changed: %s", len(changed), strings.Join(changed, ", "))
+	}
+}
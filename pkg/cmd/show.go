@@ -7,6 +7,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/spf13/cobra"
@@ -17,6 +18,7 @@ import (
 	"github.com/blend/go-sdk/sh"
 
 	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
 	"github.com/wcharczuk/blogctl/pkg/engine"
 	"github.com/wcharczuk/blogctl/pkg/model"
 )
@@ -45,39 +47,44 @@ func Show(flags config.Flags) *cobra.Command {
 				engine.OptDryRun(*flags.DryRun),
 			)
 
-			posts, err := e.DiscoverPosts(context.Background())
+			posts, err := e.DiscoverAllPosts(context.Background())
 			Fatal(err)
+			Fatal(e.GenerateCrops(context.Background(), posts))
+			if !cfg.SkipGeneratePalettes {
+				Fatal(e.GeneratePalettes(context.Background(), posts))
+			}
+			Fatal(e.SaveSourceIndex())
 
 			if *postsSelector != "" {
 				sel, err := selector.Parse(*postsSelector)
 				Fatal(err)
-				posts.Posts = model.Posts(posts.Posts).FilterBySelector(sel)
+				posts = model.Posts(posts).FilterBySelector(sel)
 			}
 
 			switch strings.ToLower(*postsOrderBy) {
 			case "location":
 				if *postsOrderDesc {
-					sort.Slice(posts.Posts, func(i, j int) bool { return posts.Posts[i].Meta.Location > posts.Posts[j].Meta.Location })
+					sort.Slice(posts, func(i, j int) bool { return posts[i].Meta.Location > posts[j].Meta.Location })
 				} else {
-					sort.Slice(posts.Posts, func(i, j int) bool { return posts.Posts[i].Meta.Location < posts.Posts[j].Meta.Location })
+					sort.Slice(posts, func(i, j int) bool { return posts[i].Meta.Location < posts[j].Meta.Location })
 				}
 			case "posted":
 				if *postsOrderDesc {
-					sort.Slice(posts.Posts, func(i, j int) bool { return posts.Posts[i].Meta.Posted.Before(posts.Posts[j].Meta.Posted) })
+					sort.Slice(posts, func(i, j int) bool { return posts[i].Meta.Posted.Before(posts[j].Meta.Posted) })
 				} else {
-					sort.Slice(posts.Posts, func(i, j int) bool { return posts.Posts[i].Meta.Posted.After(posts.Posts[j].Meta.Posted) })
+					sort.Slice(posts, func(i, j int) bool { return posts[i].Meta.Posted.After(posts[j].Meta.Posted) })
 				}
 			case "slug":
 				if *postsOrderDesc {
-					sort.Slice(posts.Posts, func(i, j int) bool { return posts.Posts[i].Slug > posts.Posts[j].Slug })
+					sort.Slice(posts, func(i, j int) bool { return posts[i].Slug > posts[j].Slug })
 				} else {
-					sort.Slice(posts.Posts, func(i, j int) bool { return posts.Posts[i].Slug < posts.Posts[j].Slug })
+					sort.Slice(posts, func(i, j int) bool { return posts[i].Slug < posts[j].Slug })
 				}
 			case "title":
 				if *postsOrderDesc {
-					sort.Slice(posts.Posts, func(i, j int) bool { return posts.Posts[i].TitleOrDefault() > posts.Posts[j].TitleOrDefault() })
+					sort.Slice(posts, func(i, j int) bool { return posts[i].TitleOrDefault() > posts[j].TitleOrDefault() })
 				} else {
-					sort.Slice(posts.Posts, func(i, j int) bool { return posts.Posts[i].TitleOrDefault() < posts.Posts[j].TitleOrDefault() })
+					sort.Slice(posts, func(i, j int) bool { return posts[i].TitleOrDefault() < posts[j].TitleOrDefault() })
 				}
 			default:
 				sh.Fatal(fmt.Errorf("invalid post order by: %s", *postsOrderBy))
@@ -85,15 +92,20 @@ func Show(flags config.Flags) *cobra.Command {
 
 			switch strings.ToLower(*outputFormat) {
 			case "name":
-				for _, post := range posts.Posts {
-					fmt.Fprintf(os.Stdout, "%s\n", post.TitleOrDefault())
+				now := time.Now().UTC()
+				for _, post := range posts {
+					if state := post.PublicationState(now); state != constants.PublicationStatePublished {
+						fmt.Fprintf(os.Stdout, "%s (%s)\n", post.TitleOrDefault(), state)
+					} else {
+						fmt.Fprintf(os.Stdout, "%s\n", post.TitleOrDefault())
+					}
 				}
 			case "json":
-				sh.Fatal(json.NewEncoder(os.Stdout).Encode(posts.Posts))
+				sh.Fatal(json.NewEncoder(os.Stdout).Encode(posts))
 			case "yaml":
-				sh.Fatal(yaml.NewEncoder(os.Stdout).Encode(posts.Posts))
+				sh.Fatal(yaml.NewEncoder(os.Stdout).Encode(posts))
 			case "table":
-				sh.Fatal(ansi.TableForSlice(os.Stdout, model.Posts(posts.Posts).TableRows()))
+				sh.Fatal(ansi.TableForSlice(os.Stdout, model.Posts(posts).TableRows()))
 			default:
 				sh.Fatal(fmt.Errorf("invalid output format: %s", *outputFormat))
 			}
@@ -120,6 +132,7 @@ func Show(flags config.Flags) *cobra.Command {
 
 			posts, err := e.DiscoverPosts(context.Background())
 			Fatal(err)
+			Fatal(e.SaveSourceIndex())
 			switch strings.ToLower(*tagsOrderBy) {
 			case "tag":
 				if *tagsOrderDesc {
@@ -162,8 +175,52 @@ func Show(flags config.Flags) *cobra.Command {
 	tagsOrderBy = tags.Flags().String("order-by", "tag", "Which field to order the tags by; one of `tag`, or `posts`")
 	tagsOrderDesc = tags.Flags().Bool("desc", false, "The tags sort order (true will sort descending)")
 
+	var duplicatesMaxDistance *int
+	duplicates := &cobra.Command{
+		Use:   "duplicates",
+		Short: "Show sets of near identical images posted more than once",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, _, err := config.ReadConfig(flags)
+			Fatal(err)
+			e := engine.MustNew(
+				engine.OptConfig(cfg),
+				engine.OptParallelism(*flags.Parallelism),
+				engine.OptDryRun(*flags.DryRun),
+			)
+
+			posts, err := e.DiscoverAllPosts(context.Background())
+			Fatal(err)
+			Fatal(e.GeneratePerceptualHashes(context.Background(), posts))
+			Fatal(e.SaveSourceIndex())
+			duplicates, err := engine.Duplicates(posts, *duplicatesMaxDistance)
+			Fatal(err)
+
+			switch strings.ToLower(*outputFormat) {
+			case "name":
+				for index, duplicate := range duplicates {
+					if index > 0 {
+						fmt.Fprintln(os.Stdout)
+					}
+					for _, image := range duplicate.Images {
+						fmt.Fprintf(os.Stdout, "%s (%s) %d\n", image.Title, image.SourcePath, image.Distance)
+					}
+				}
+			case "json":
+				sh.Fatal(json.NewEncoder(os.Stdout).Encode(duplicates))
+			case "yaml":
+				sh.Fatal(yaml.NewEncoder(os.Stdout).Encode(duplicates))
+			case "table":
+				sh.Fatal(ansi.TableForSlice(os.Stdout, duplicates.TableRows()))
+			default:
+				sh.Fatal(fmt.Errorf("invalid output format: %s", *outputFormat))
+			}
+		},
+	}
+	duplicatesMaxDistance = duplicates.Flags().Int("max-distance", constants.DefaultDuplicateDistance, "The maximum hamming distance between the perceptual hashes of duplicate images (out of 64)")
+
 	cmd.AddCommand(posts)
 	cmd.AddCommand(tags)
+	cmd.AddCommand(duplicates)
 	return cmd
 }
 
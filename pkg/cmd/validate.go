@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/engine"
+)
+
+// Validate returns the validate command.
+func Validate(flags config.Flags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config, posts and templates for mistakes without building",
+		Run: func(cmd *cobra.Command, args []string) {
+			// mistakes in the config are reported along with the other problems, where the config files can be found.
+			cfg, cfgPaths, err := config.ReadConfigUnchecked(flags)
+			if len(cfgPaths) == 0 {
+				Fatal(err)
+			}
+
+			e := engine.MustNew(
+				engine.OptConfig(cfg),
+				engine.OptParallelism(*flags.Parallelism),
+			)
+			problems := e.Validate(context.Background(), cfgPaths...)
+			for _, problem := range problems {
+				fmt.Fprintln(os.Stdout, problem)
+			}
+			if len(problems) > 0 {
+				Fatal(fmt.Errorf("found %d problem(s)", len(problems)))
+			}
+		},
+	}
+}
@@ -42,6 +42,9 @@ type Config struct {
 	StaticsPath string `json:"staticsPath,omitempty" yaml:"staticsPath,omitempty"`
 	// ThumbnailCachePath is the path to the thumbnail cache.
 	ThumbnailCachePath string `json:"thumbnailCachePath,omitempty" yaml:"thumbnailCachePath,omitempty"`
+	// BuildManifestPath is the path to the build manifest.
+	// It records the inputs for each output so unchanged outputs are skipped on subsequent builds.
+	BuildManifestPath string `json:"buildManifestPath,omitempty" yaml:"buildManifestPath,omitempty"`
 
 	// SlugTemplate is the template for post slugs.
 	// It defaults to "/{{ .Meta.Posted.Year }}/{{ .Meta.Posted.Month }}/{{ .Meta.Posted.Day }}/{{ .Meta.Title | slugify }}/"
@@ -58,13 +61,41 @@ type Config struct {
 	// ImageSizes lets you set what size thumbnails to create from post files.
 	// This defaults to 2048px, 1024px, and 512px.
 	ImageSizes []int `json:"imageSizes,omitempty" yaml:"imageSizes,omitempty"`
+	// ImageVariants are named thumbnails with their own dimensions and crop modes,
+	// e.g. square crops or social cards, on top of the image sizes.
+	ImageVariants []ImageVariant `json:"imageVariants,omitempty" yaml:"imageVariants,omitempty"`
+	// ImageEncoding are the options for resizing and encoding the thumbnails and image variants,
+	// i.e. the interpolation filter, the jpeg quality and sharpening.
+	ImageEncoding ImageEncoding `json:"imageEncoding,omitempty" yaml:"imageEncoding,omitempty"`
+	// ImageSizeEncodings override the image encoding options for specific image sizes,
+	// e.g. a higher quality for the largest size.
+	ImageSizeEncodings map[int]ImageEncoding `json:"imageSizeEncodings,omitempty" yaml:"imageSizeEncodings,omitempty"`
+	// ImageMemoryBudget is roughly how much memory in megabytes the images being processed can take at once.
+	// Images wait to be decoded until there's room, so large images are processed with less parallelism.
+	// It defaults to 1024.
+	ImageMemoryBudget int `json:"imageMemoryBudget,omitempty" yaml:"imageMemoryBudget,omitempty"`
 	// Extra is optional and allows you to provide variables for templates.
 	Extra map[string]string `json:"extra,omitempty" yaml:"extra,omitempty"`
 
+	// ThumbnailCache governs where the thumbnail cache is shared, besides the thumbnail cache path.
+	ThumbnailCache ThumbnailCache `json:"thumbnailCache,omitempty" yaml:"thumbnailCache,omitempty"`
+
 	// S3 governs how the blog is deployed.
 	S3 S3 `json:"s3,omitempty" yaml:"s3,omitempty"`
 	// Cloudfront governs options for how the s3 files are cached.
 	Cloudfront Cloudfront `json:"cloudfront,omitempty" yaml:"cloudfront,omitempty"`
+	// Feed governs the atom and rss feeds.
+	Feed Feed `json:"feed,omitempty" yaml:"feed,omitempty"`
+	// Metadata governs the metadata of published original images.
+	Metadata Metadata `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	// Pagination governs how the index page and the tag pages are split into pages.
+	Pagination Pagination `json:"pagination,omitempty" yaml:"pagination,omitempty"`
+	// Locations governs the geojson map data layer of geotagged posts.
+	Locations Locations `json:"locations,omitempty" yaml:"locations,omitempty"`
+	// Sitemap governs the sitemap.
+	Sitemap Sitemap `json:"sitemap,omitempty" yaml:"sitemap,omitempty"`
+	// Robots governs the robots.txt file.
+	Robots Robots `json:"robots,omitempty" yaml:"robots,omitempty"`
 	// Web is the config for the web server.
 	Web web.Config `json:"web,omitempty" yaml:"web,omitempty"`
 
@@ -74,12 +105,24 @@ type Config struct {
 	PostSortKey string `json:"postSortKey,omitempty" yaml:"postSortKey,omitempty"`
 	// PostSortAscending determines if we should sort ascending or descending.
 	PostSortAscending *bool `json:"postSortAscending,omitempty" yaml:"postSortAscending,omitempty"`
+	// SkipGIFAnimation instructs the engine to only use the first frame of animated gifs for their thumbnails.
+	SkipGIFAnimation bool `json:"skipGIFAnimation,omitempty" yaml:"skipGIFAnimation,omitempty"`
 	// SkipImageOriginal instructs the engine to not copy the original image.
 	SkipCopyOriginalImage bool `json:"skipImageOriginal,omitempty" yaml:"skipImageOriginal,omitempty"`
 	// SkipTags instructs the engine to not create tag summary pages.
 	SkipGenerateTags bool `json:"skipGenerateTags,omitempty" yaml:"skipGenerateTags,omitempty"`
 	// SkipGenerateJSONData instructs the engine not to create a data.json file.
 	SkipGenerateJSONData bool `json:"skipGenerateJSONData,omitempty" yaml:"skipGenerateJSONData,omitempty"`
+	// SkipGenerateFeeds instructs the engine not to create atom and rss feeds.
+	SkipGenerateFeeds bool `json:"skipGenerateFeeds,omitempty" yaml:"skipGenerateFeeds,omitempty"`
+	// SkipGeneratePlaceholders instructs the engine not to create blurhash and inline preview placeholders for images.
+	SkipGeneratePlaceholders bool `json:"skipGeneratePlaceholders,omitempty" yaml:"skipGeneratePlaceholders,omitempty"`
+	// SkipGeneratePalettes instructs the engine not to extract the dominant colors of images.
+	SkipGeneratePalettes bool `json:"skipGeneratePalettes,omitempty" yaml:"skipGeneratePalettes,omitempty"`
+	// SkipGenerateSitemap instructs the engine not to create a sitemap.xml file.
+	SkipGenerateSitemap bool `json:"skipGenerateSitemap,omitempty" yaml:"skipGenerateSitemap,omitempty"`
+	// SkipGenerateRobots instructs the engine not to create a robots.txt file.
+	SkipGenerateRobots bool `json:"skipGenerateRobots,omitempty" yaml:"skipGenerateRobots,omitempty"`
 }
 
 // Fields returns fields to prompt for when creating a new config.
@@ -197,6 +240,14 @@ func (c Config) ThumbnailCachePathOrDefault() string {
 	return constants.DefaultThumbnailCachePath
 }
 
+// BuildManifestPathOrDefault returns the build manifest path or a default.
+func (c Config) BuildManifestPathOrDefault() string {
+	if c.BuildManifestPath != "" {
+		return c.BuildManifestPath
+	}
+	return constants.DefaultBuildManifestPath
+}
+
 // ImageSizesOrDefault returns the image sizes or a default set.
 func (c Config) ImageSizesOrDefault() []int {
 	if c.ImageSizes != nil {
@@ -205,6 +256,19 @@ func (c Config) ImageSizesOrDefault() []int {
 	return constants.DefaultImageSizes
 }
 
+// ImageMemoryBudgetOrDefault returns the image memory budget in megabytes or a default.
+func (c Config) ImageMemoryBudgetOrDefault() int {
+	if c.ImageMemoryBudget > 0 {
+		return c.ImageMemoryBudget
+	}
+	return constants.DefaultImageMemoryBudget
+}
+
+// ImageEncodingForSize returns the image encoding options for an image size.
+func (c Config) ImageEncodingForSize(size int) ImageEncoding {
+	return c.ImageEncoding.Merge(c.ImageSizeEncodings[size])
+}
+
 // PostSortKeyOrDefault returns the post sort key or a default.
 func (c Config) PostSortKeyOrDefault() string {
 	if c.PostSortKey != "" {
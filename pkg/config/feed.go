@@ -0,0 +1,69 @@
+package config
+
+import (
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+// ErrInvalidFeed is returned when the feed config is invalid.
+const ErrInvalidFeed ex.Class = "invalid feed"
+
+// Feed governs the atom and rss feeds.
+type Feed struct {
+	// EntryCount is the maximum number of entries in each feed.
+	// It defaults to 20.
+	EntryCount int `json:"entryCount,omitempty" yaml:"entryCount,omitempty"`
+	// PostTypes are the post types to include in the feeds; `image`, `text` or both.
+	// It defaults to both.
+	PostTypes []string `json:"postTypes,omitempty" yaml:"postTypes,omitempty"`
+	// ImageSize is the size of the image to attach to image post entries.
+	// It must be one of the image sizes, and defaults to 2048px.
+	ImageSize int `json:"imageSize,omitempty" yaml:"imageSize,omitempty"`
+}
+
+// EntryCountOrDefault returns the entry count or a default.
+func (f Feed) EntryCountOrDefault() int {
+	if f.EntryCount > 0 {
+		return f.EntryCount
+	}
+	return constants.DefaultFeedEntryCount
+}
+
+// PostTypesOrDefault returns the post types or a default.
+func (f Feed) PostTypesOrDefault() []string {
+	if len(f.PostTypes) > 0 {
+		return f.PostTypes
+	}
+	return constants.DefaultFeedPostTypes
+}
+
+// ImageSizeOrDefault returns the image size or a default.
+func (f Feed) ImageSizeOrDefault() int {
+	if f.ImageSize > 0 {
+		return f.ImageSize
+	}
+	return constants.SizeLarge
+}
+
+// Validate returns an error if the image size isn't one of the image sizes thumbnails
+// are generated at, as image post entries would otherwise link to images that don't exist.
+func (f Feed) Validate(imageSizes []int) error {
+	imageSize := f.ImageSizeOrDefault()
+	for _, size := range imageSizes {
+		if size == imageSize {
+			return nil
+		}
+	}
+	return ex.New(ErrInvalidFeed, ex.OptMessagef("imageSize must be one of the image sizes %v; got %d", imageSizes, imageSize))
+}
+
+// IncludesPostType returns if a given post type should be included in the feeds.
+func (f Feed) IncludesPostType(postType string) bool {
+	for _, included := range f.PostTypesOrDefault() {
+		if included == postType {
+			return true
+		}
+	}
+	return false
+}
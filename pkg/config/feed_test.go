@@ -0,0 +1,20 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+func TestFeedValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(Feed{}.Validate(constants.DefaultImageSizes))
+	assert.Nil(Feed{ImageSize: 1024}.Validate([]int{1024, 512}))
+
+	// the image size, or the default, must be generated.
+	assert.NotNil(Feed{ImageSize: 100}.Validate(constants.DefaultImageSizes))
+	assert.NotNil(Feed{}.Validate([]int{1024, 512}))
+}
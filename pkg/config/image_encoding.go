@@ -0,0 +1,113 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+// ImageEncoding are the options for resizing and encoding thumbnails.
+type ImageEncoding struct {
+	// Filter is the interpolation filter images are resized with; one of `nearest`, `bilinear`,
+	// `bicubic` (the default), `mitchell-netravali`, `lanczos2` or `lanczos3`.
+	Filter string `json:"filter,omitempty" yaml:"filter,omitempty"`
+	// Quality is the jpeg quality between 1 and 100.
+	// It defaults to 75.
+	Quality int `json:"quality,omitempty" yaml:"quality,omitempty"`
+	// Sharpen is an unsharp mask applied to images after they're resized.
+	Sharpen UnsharpMask `json:"sharpen,omitempty" yaml:"sharpen,omitempty"`
+	// ColorProfile is what is done with the icc color profile of jpegs; `keep` (the default)
+	// embeds it in the thumbnails, and `srgb` converts the thumbnails to srgb, which browsers
+	// assume for images without a profile.
+	ColorProfile string `json:"colorProfile,omitempty" yaml:"colorProfile,omitempty"`
+	// SkipAttribution skips writing the artist and copyright of jpegs to the exif data of their thumbnails.
+	SkipAttribution bool `json:"skipAttribution,omitempty" yaml:"skipAttribution,omitempty"`
+}
+
+// FilterOrDefault returns the filter or a default.
+func (ie ImageEncoding) FilterOrDefault() string {
+	if ie.Filter != "" {
+		return strings.ToLower(ie.Filter)
+	}
+	return constants.DefaultImageFilter
+}
+
+// QualityOrDefault returns the quality or a default.
+func (ie ImageEncoding) QualityOrDefault() int {
+	if ie.Quality > 0 {
+		return ie.Quality
+	}
+	return constants.DefaultImageQuality
+}
+
+// ColorProfileOrDefault returns the color profile or a default.
+func (ie ImageEncoding) ColorProfileOrDefault() string {
+	if ie.ColorProfile != "" {
+		return strings.ToLower(ie.ColorProfile)
+	}
+	return constants.ColorProfileKeep
+}
+
+// Merge returns the options with the set options of another set of options taking precedence.
+func (ie ImageEncoding) Merge(other ImageEncoding) ImageEncoding {
+	if other.Filter != "" {
+		ie.Filter = other.Filter
+	}
+	if other.Quality > 0 {
+		ie.Quality = other.Quality
+	}
+	if !other.Sharpen.IsZero() {
+		ie.Sharpen = other.Sharpen
+	}
+	if other.ColorProfile != "" {
+		ie.ColorProfile = other.ColorProfile
+	}
+	if other.SkipAttribution {
+		ie.SkipAttribution = true
+	}
+	return ie
+}
+
+// Normalize returns the options with the defaults filled in, so equivalent options are equal.
+func (ie ImageEncoding) Normalize() ImageEncoding {
+	ie.Filter = ie.FilterOrDefault()
+	ie.Quality = ie.QualityOrDefault()
+	ie.ColorProfile = ie.ColorProfileOrDefault()
+	if ie.Sharpen.IsZero() {
+		ie.Sharpen = UnsharpMask{}
+	} else {
+		ie.Sharpen.Radius = ie.Sharpen.RadiusOrDefault()
+	}
+	return ie
+}
+
+// IsDefault returns if the options are equivalent to the defaults.
+func (ie ImageEncoding) IsDefault() bool {
+	return ie.Normalize() == ImageEncoding{}.Normalize()
+}
+
+// UnsharpMask are the options for sharpening images with an unsharp mask.
+type UnsharpMask struct {
+	// Amount is how much the edges are sharpened, e.g. `0.5` for 50%.
+	// Images aren't sharpened if it's unset.
+	Amount float64 `json:"amount,omitempty" yaml:"amount,omitempty"`
+	// Radius is the radius of the edges in pixels.
+	// It defaults to 1.
+	Radius float64 `json:"radius,omitempty" yaml:"radius,omitempty"`
+	// Threshold is how different neighboring pixels have to be, between 0 and 255,
+	// to be sharpened, so flat areas and noise aren't sharpened.
+	Threshold int `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+}
+
+// IsZero returns if images aren't sharpened.
+func (um UnsharpMask) IsZero() bool {
+	return um.Amount <= 0
+}
+
+// RadiusOrDefault returns the radius or a default.
+func (um UnsharpMask) RadiusOrDefault() float64 {
+	if um.Radius > 0 {
+		return um.Radius
+	}
+	return constants.DefaultUnsharpRadius
+}
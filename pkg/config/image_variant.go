@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+// ImageVariant is a named thumbnail with its own dimensions and crop mode, e.g.
+// a square crop for a grid, or a 1200x630 social card.
+type ImageVariant struct {
+	// Name is the name of the variant, which is also its file name (e.g. `square.jpg`).
+	Name string `json:"name" yaml:"name"`
+	// Width is the width of the variant in pixels.
+	// For the `fit` mode it can be unset to only constrain the height.
+	Width int `json:"width,omitempty" yaml:"width,omitempty"`
+	// Height is the height of the variant in pixels.
+	// For the `fit` mode it can be unset to only constrain the width.
+	Height int `json:"height,omitempty" yaml:"height,omitempty"`
+	// Mode is how the image is made to fit the dimensions; one of:
+	// - `fit` scales the image down to fit within the dimensions (the default)
+	// - `fill` scales the image to cover the dimensions and crops the rest
+	// - `crop` crops the dimensions from the image without scaling it
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Anchor is which part of the image is kept when it's cropped; one of `center` (the default),
+	// `smart`, which picks the part with the most interesting content, `top`, `bottom`, `left`,
+	// `right`, `top-left`, `top-right`, `bottom-left` or `bottom-right`.
+	Anchor string `json:"anchor,omitempty" yaml:"anchor,omitempty"`
+	// Focus is the point of the image that is kept at the center of crops if possible,
+	// as the fractions of the width and height from the top left, e.g. `[0.5, 0.3]`.
+	// It takes precedence over the anchor, and the focus of an image in the post meta takes precedence over it.
+	Focus []float64 `json:"focus,omitempty" yaml:"focus,omitempty"`
+	// Format is the format of the variant; one of `jpg`, `png` or `gif`.
+	// It defaults to the format of the image's thumbnails.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Quality is the jpeg quality of the variant between 1 and 100.
+	// It defaults to the quality of the image encoding.
+	Quality int `json:"quality,omitempty" yaml:"quality,omitempty"`
+}
+
+// ModeOrDefault returns the mode or a default.
+func (iv ImageVariant) ModeOrDefault() string {
+	if iv.Mode != "" {
+		return strings.ToLower(iv.Mode)
+	}
+	return constants.ImageVariantModeFit
+}
+
+// AnchorOrDefault returns the anchor or a default.
+func (iv ImageVariant) AnchorOrDefault() string {
+	if iv.Anchor != "" {
+		return strings.ToLower(iv.Anchor)
+	}
+	return constants.AnchorCenter
+}
+
+// ExtensionOrDefault returns the file extension for the format, or a given default extension.
+func (iv ImageVariant) ExtensionOrDefault(defaultExtension string) string {
+	switch strings.ToLower(strings.TrimPrefix(iv.Format, ".")) {
+	case "jpg", "jpeg":
+		return constants.ExtensionJPG
+	case "png":
+		return constants.ExtensionPNG
+	case "gif":
+		return constants.ExtensionGIF
+	}
+	return defaultExtension
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+// ErrInvalidLocations is returned when the locations config is invalid.
+const ErrInvalidLocations ex.Class = "invalid locations"
+
+// Locations governs the geojson map data layer of geotagged posts.
+type Locations struct {
+	// Enabled instructs the engine to write the `locations.geojson` file.
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// ImageSize is the size of the thumbnail linked from each location.
+	// It must be one of the image sizes, and defaults to 512px.
+	ImageSize int `json:"imageSize,omitempty" yaml:"imageSize,omitempty"`
+}
+
+// ImageSizeOrDefault returns the image size or a default.
+func (l Locations) ImageSizeOrDefault() int {
+	if l.ImageSize > 0 {
+		return l.ImageSize
+	}
+	return constants.SizeSmall
+}
+
+// Validate returns an error if the image size isn't one of the image sizes thumbnails
+// are generated at, as each location would otherwise link to a thumbnail that doesn't exist.
+func (l Locations) Validate(imageSizes []int) error {
+	imageSize := l.ImageSizeOrDefault()
+	for _, size := range imageSizes {
+		if size == imageSize {
+			return nil
+		}
+	}
+	return ex.New(ErrInvalidLocations, ex.OptMessagef("imageSize must be one of the image sizes %v; got %d", imageSizes, imageSize))
+}
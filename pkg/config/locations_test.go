@@ -0,0 +1,20 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+func TestLocationsValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(Locations{}.Validate(constants.DefaultImageSizes))
+	assert.Nil(Locations{ImageSize: 1024}.Validate([]int{1024, 512}))
+
+	// the image size, or the default, must be generated.
+	assert.NotNil(Locations{ImageSize: 200}.Validate(constants.DefaultImageSizes))
+	assert.NotNil(Locations{}.Validate([]int{2048, 1024}))
+}
@@ -0,0 +1,81 @@
+package config
+
+import "github.com/wcharczuk/blogctl/pkg/constants"
+
+// Metadata governs the metadata of published original images.
+type Metadata struct {
+	// Policy is what metadata to keep; `keep` keeps everything, `strip` removes everything
+	// and `allowlist` keeps only the exif fields in `allow`.
+	// It defaults to `keep`. The orientation is always kept so images display upright.
+	// It applies to jpeg, png, webp and gif originals alike.
+	Policy string `json:"policy,omitempty" yaml:"policy,omitempty"`
+	// Allow are the exif field names kept by the `allowlist` policy, e.g. `Copyright`.
+	// It defaults to the artist, copyright, camera, lens, capture date and exposure settings.
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	// PrivacyZones are areas where the gps location of images is removed or rounded.
+	PrivacyZones []PrivacyZone `json:"privacyZones,omitempty" yaml:"privacyZones,omitempty"`
+}
+
+// PolicyOrDefault returns the policy or a default.
+func (m Metadata) PolicyOrDefault() string {
+	if m.Policy != "" {
+		return m.Policy
+	}
+	return constants.MetadataPolicyKeep
+}
+
+// AllowOrDefault returns the allowed exif fields or a default.
+func (m Metadata) AllowOrDefault() []string {
+	if len(m.Allow) > 0 {
+		return m.Allow
+	}
+	return constants.DefaultMetadataAllow
+}
+
+// IsKeepAll returns if the original images are published with all their metadata.
+func (m Metadata) IsKeepAll() bool {
+	return m.PolicyOrDefault() == constants.MetadataPolicyKeep && len(m.PrivacyZones) == 0
+}
+
+// PrivacyZone is a circle where the gps location of images is removed or rounded.
+type PrivacyZone struct {
+	// Name is a name for the zone, for your reference.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Latitude is the latitude of the center of the zone in decimal degrees.
+	Latitude float64 `json:"latitude" yaml:"latitude"`
+	// Longitude is the longitude of the center of the zone in decimal degrees.
+	Longitude float64 `json:"longitude" yaml:"longitude"`
+	// Radius is the radius of the zone in meters.
+	// It defaults to 1000m.
+	Radius float64 `json:"radius,omitempty" yaml:"radius,omitempty"`
+	// Action is what to do with the location of images in the zone; `strip` or `round`.
+	// It defaults to `strip`.
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	// Precision is the number of decimal places locations are rounded to with the `round` action.
+	// It defaults to 2, i.e. roughly a kilometer.
+	Precision int `json:"precision,omitempty" yaml:"precision,omitempty"`
+}
+
+// RadiusOrDefault returns the radius or a default.
+func (pz PrivacyZone) RadiusOrDefault() float64 {
+	if pz.Radius > 0 {
+		return pz.Radius
+	}
+	return constants.DefaultPrivacyZoneRadius
+}
+
+// ActionOrDefault returns the action or a default.
+func (pz PrivacyZone) ActionOrDefault() string {
+	if pz.Action != "" {
+		return pz.Action
+	}
+	return constants.PrivacyZoneActionStrip
+}
+
+// PrecisionOrDefault returns the precision or a default.
+func (pz PrivacyZone) PrecisionOrDefault() int {
+	if pz.Precision > 0 {
+		return pz.Precision
+	}
+	return constants.DefaultPrivacyZonePrecision
+}
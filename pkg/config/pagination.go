@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+// ErrInvalidPagination is returned when the pagination config is invalid.
+const ErrInvalidPagination ex.Class = "invalid pagination"
+
+// Pagination governs how the index page and the tag pages are split into pages.
+type Pagination struct {
+	// PageSize is the number of posts on each page.
+	// If it is unset, pagination is disabled and every post is on the first page.
+	PageSize int `json:"pageSize,omitempty" yaml:"pageSize,omitempty"`
+	// PathFormat is the format of the path of each page after the first, relative to
+	// the path of the first page, given the page number.
+	// It defaults to `page/%d`, i.e. `/page/2/`, `/tags/foo/page/2/` etc.
+	PathFormat string `json:"pathFormat,omitempty" yaml:"pathFormat,omitempty"`
+}
+
+// IsEnabled returns if pagination is enabled.
+func (p Pagination) IsEnabled() bool {
+	return p.PageSize > 0
+}
+
+// PathFormatOrDefault returns the path format or a default.
+func (p Pagination) PathFormatOrDefault() string {
+	if p.PathFormat != "" {
+		return p.PathFormat
+	}
+	return constants.DefaultPaginationPathFormat
+}
+
+// Validate returns an error if the path format doesn't take the page number as its only `%d`,
+// as every page would otherwise be written to the same path.
+func (p Pagination) Validate() error {
+	format := p.PathFormatOrDefault()
+	if second := fmt.Sprintf(format, 2); strings.Contains(second, "%!") || second == fmt.Sprintf(format, 3) {
+		return ex.New(ErrInvalidPagination, ex.OptMessagef("pathFormat must include the page number as %%d; got %s", format))
+	}
+	return nil
+}
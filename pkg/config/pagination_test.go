@@ -0,0 +1,19 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestPaginationValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(Pagination{}.Validate())
+	assert.Nil(Pagination{PathFormat: "p/%d"}.Validate())
+	assert.Nil(Pagination{PathFormat: "%d"}.Validate())
+
+	assert.NotNil(Pagination{PathFormat: "page"}.Validate())
+	assert.NotNil(Pagination{PathFormat: "page/%s"}.Validate())
+	assert.NotNil(Pagination{PathFormat: "page/%d/%d"}.Validate())
+}
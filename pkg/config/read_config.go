@@ -1,16 +1,62 @@
 package config
 
 import (
+	"os"
+	"strings"
+
 	"github.com/blend/go-sdk/configutil"
 )
 
-// ReadConfig reads a config at a given path as yaml.
+// ReadConfig reads a config at a given path as yaml, and checks the pagination path format,
+// the thumbnail cache bucket and the feed and locations image sizes.
 func ReadConfig(flags Flags) (cfg Config, configPaths []string, err error) {
+	cfg, configPaths, err = ReadConfigUnchecked(flags)
+	if err == nil {
+		err = cfg.Pagination.Validate()
+	}
+	if err == nil {
+		err = cfg.ThumbnailCache.Validate(cfg.S3)
+	}
+	if err == nil && !cfg.SkipGenerateFeeds {
+		err = cfg.Feed.Validate(cfg.ImageSizesOrDefault())
+	}
+	if err == nil && cfg.Locations.Enabled {
+		err = cfg.Locations.Validate(cfg.ImageSizesOrDefault())
+	}
+	return
+}
+
+// ReadConfigUnchecked reads a config at a given path as yaml without checking it.
+//
+// If a config file can't be read, the config is read up to that file, and the config paths
+// are every config file that exists, so the file that can't be read can be checked too.
+func ReadConfigUnchecked(flags Flags) (cfg Config, configPaths []string, err error) {
 	configPaths, err = configutil.Read(&cfg,
 		configutil.OptAddPreferredPaths(*flags.ConfigPath),
 	)
 	if configutil.IsIgnored(err) {
 		err = nil
 	}
+	if err != nil {
+		configPaths = existingConfigPaths(*flags.ConfigPath)
+	}
+	return
+}
+
+// existingConfigPaths returns the paths a config is read from that exist, in the order they're read.
+func existingConfigPaths(configPath string) (configPaths []string) {
+	paths := []string{configPath}
+	for _, path := range strings.Split(os.Getenv(configutil.EnvVarConfigPath), ",") {
+		paths = append(paths, strings.TrimSpace(path))
+	}
+	paths = append(paths, configutil.DefaultPaths...)
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			configPaths = append(configPaths, path)
+		}
+	}
 	return
 }
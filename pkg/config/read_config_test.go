@@ -0,0 +1,36 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ref"
+)
+
+func TestReadConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "blogctl")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	configPath := filepath.Join(tempDir, "config.yml")
+	assert.Nil(ioutil.WriteFile(configPath, []byte("title: test\npagination:\n  pathFormat: page\n"), 0644))
+
+	// the config is checked when it's read, unless it's read unchecked.
+	_, _, err = ReadConfig(Flags{ConfigPath: ref.String(configPath)})
+	assert.NotNil(err)
+	cfg, configPaths, err := ReadConfigUnchecked(Flags{ConfigPath: ref.String(configPath)})
+	assert.Nil(err)
+	assert.Equal("test", cfg.Title)
+	assert.Equal([]string{configPath}, configPaths)
+
+	// config files that can't be read are still returned.
+	assert.Nil(ioutil.WriteFile(configPath, []byte("title: test\npagination:\n  pageSize: abc\n"), 0644))
+	_, configPaths, err = ReadConfigUnchecked(Flags{ConfigPath: ref.String(configPath)})
+	assert.NotNil(err)
+	assert.Equal([]string{configPath}, configPaths)
+}
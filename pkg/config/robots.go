@@ -0,0 +1,33 @@
+package config
+
+// Robots governs the robots.txt file.
+type Robots struct {
+	// Rules are the robots.txt rules, one group per user agent.
+	// It defaults to allowing every user agent everything.
+	Rules []RobotsRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+	// SkipSitemap instructs the engine not to reference the sitemap from the robots.txt.
+	SkipSitemap bool `json:"skipSitemap,omitempty" yaml:"skipSitemap,omitempty"`
+}
+
+// RobotsRule is a group of rules for a user agent.
+type RobotsRule struct {
+	UserAgent string   `json:"userAgent,omitempty" yaml:"userAgent,omitempty"`
+	Allow     []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Disallow  []string `json:"disallow,omitempty" yaml:"disallow,omitempty"`
+}
+
+// UserAgentOrDefault returns the user agent or a default.
+func (rr RobotsRule) UserAgentOrDefault() string {
+	if rr.UserAgent != "" {
+		return rr.UserAgent
+	}
+	return "*"
+}
+
+// RulesOrDefault returns the rules or a default.
+func (r Robots) RulesOrDefault() []RobotsRule {
+	if len(r.Rules) > 0 {
+		return r.Rules
+	}
+	return []RobotsRule{{}}
+}
@@ -0,0 +1,21 @@
+package config
+
+import "github.com/wcharczuk/blogctl/pkg/constants"
+
+// Sitemap governs the sitemap.
+type Sitemap struct {
+	// SkipImages instructs the engine not to add google image sitemap entries for image posts.
+	SkipImages bool `json:"skipImages,omitempty" yaml:"skipImages,omitempty"`
+	// MaxURLs is the maximum number of urls in each sitemap file.
+	// Above this, the sitemap is split into multiple files listed by a sitemap index.
+	// It defaults to (and cannot exceed) 50,000.
+	MaxURLs int `json:"maxURLs,omitempty" yaml:"maxURLs,omitempty"`
+}
+
+// MaxURLsOrDefault returns the max urls per sitemap file or a default.
+func (s Sitemap) MaxURLsOrDefault() int {
+	if s.MaxURLs > 0 && s.MaxURLs < constants.DefaultSitemapMaxURLs {
+		return s.MaxURLs
+	}
+	return constants.DefaultSitemapMaxURLs
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// ErrInvalidThumbnailCache is returned when the thumbnail cache config is invalid.
+const ErrInvalidThumbnailCache ex.Class = "invalid thumbnail cache"
+
+// ThumbnailCache are the options for sharing the thumbnail cache between machines.
+type ThumbnailCache struct {
+	// S3 is the bucket the thumbnail cache is stored in, so thumbnails generated on one machine
+	// are downloaded by others instead of being generated again.
+	// The thumbnail cache is only kept locally if it's unset.
+	S3 S3 `json:"s3,omitempty" yaml:"s3,omitempty"`
+	// Prefix is the prefix of the keys of the thumbnail cache in the bucket, e.g. `thumbnails/`.
+	// It is required if the bucket is set.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+}
+
+// IsZero returns if the thumbnail cache is only kept locally.
+func (tc ThumbnailCache) IsZero() bool {
+	return tc.S3.IsZero()
+}
+
+// BucketName returns the name of the bucket without an `s3://` scheme.
+func (tc ThumbnailCache) BucketName() string {
+	return strings.TrimSuffix(strings.TrimPrefix(tc.S3.Bucket, "s3://"), "/")
+}
+
+// KeyPrefix returns the prefix of the keys in the bucket as a directory, i.e. ending with a slash.
+func (tc ThumbnailCache) KeyPrefix() string {
+	return strings.Trim(tc.Prefix, "/") + "/"
+}
+
+// Validate returns an error if the thumbnail cache is in a bucket without a prefix, or in the
+// bucket the site is deployed to, as cleaning the thumbnail cache removes keys under the prefix.
+func (tc ThumbnailCache) Validate(site S3) error {
+	if tc.IsZero() {
+		return nil
+	}
+	if strings.Trim(tc.Prefix, "/") == "" {
+		return ex.New(ErrInvalidThumbnailCache, ex.OptMessage("prefix must be set, e.g. thumbnails/"))
+	}
+	if tc.BucketName() == (ThumbnailCache{S3: site}).BucketName() {
+		return ex.New(ErrInvalidThumbnailCache, ex.OptMessagef("bucket must differ from the bucket the site is deployed to; got %s", tc.S3.Bucket))
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestThumbnailCacheValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	site := S3{Bucket: "s3://my-blog"}
+	assert.Nil(ThumbnailCache{}.Validate(site))
+	assert.Nil(ThumbnailCache{S3: S3{Bucket: "my-thumbnails"}, Prefix: "thumbnails/"}.Validate(site))
+
+	// the prefix is required, and the bucket can't be the one the site is deployed to.
+	assert.NotNil(ThumbnailCache{S3: S3{Bucket: "my-thumbnails"}}.Validate(site))
+	assert.NotNil(ThumbnailCache{S3: S3{Bucket: "my-thumbnails"}, Prefix: "/"}.Validate(site))
+	assert.NotNil(ThumbnailCache{S3: S3{Bucket: "my-blog"}, Prefix: "thumbnails/"}.Validate(site))
+
+	assert.Equal("thumbnails/", ThumbnailCache{Prefix: "thumbnails"}.KeyPrefix())
+	assert.Equal("thumbnails/", ThumbnailCache{Prefix: "/thumbnails/"}.KeyPrefix())
+}
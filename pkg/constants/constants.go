@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 const (
 	// DefaultConfigPath is the default config file name.
 	DefaultConfigPath = "./config.yml"
@@ -23,8 +25,25 @@ const (
 	DefaultTextPostTemplatePath = "./layout/text.html"
 	// DefaultTagTemplatePath is the default tag template path.
 	DefaultTagTemplatePath = "./layout/tag.html"
+	// DefaultBuildManifestPath is the default build manifest path.
+	DefaultBuildManifestPath = "./manifest.json"
+)
+
+// BuildManifestVersion is the version of the build manifest format.
+// Manifests written with a different version are ignored and trigger a full build.
+const (
+	BuildManifestVersion = "v1"
 )
 
+// SourceIndexVersion is the version of the source index format.
+// Indexes written with a different version are ignored and rebuilt.
+const (
+	SourceIndexVersion = "v2"
+)
+
+// FileSourceIndex is the name of the source index file in the thumbnail cache.
+const FileSourceIndex = "index.json"
+
 // DefaultSlugTemplate is the default slug format.
 const (
 	DefaultSlugTemplate = `{{ .Meta.Posted | time_format "2006/01/02" }}/{{ .Meta.Title | slugify }}`
@@ -32,10 +51,33 @@ const (
 
 // OutputFiles are known output file names.
 const (
-	FileIndex         = "index.html"
-	FileMeta          = "meta.yml"
-	FileData          = "data.json"
-	FileImageOriginal = "original.jpg"
+	FileIndex     = "index.html"
+	FileMeta      = "meta.yml"
+	FileData      = "data.json"
+	FileAtom      = "feed.xml"
+	FileRSS       = "rss.xml"
+	FileSitemap   = "sitemap.xml"
+	FileRobots    = "robots.txt"
+	FileLocations = "locations.geojson"
+)
+
+// CacheFiles are the names of the files of data computed from an image, kept in the thumbnail cache.
+const (
+	FilePlaceholder    = "placeholder.json"
+	FilePalette        = "palette.json"
+	FilePerceptualHash = "phash.json"
+	// FileSmartCropFormat is the format of the smart crop file names, by the width and height of the crop.
+	FileSmartCropFormat = "smartcrop-%dx%d.json"
+)
+
+// SitemapPartFormat is the format for sitemap file names when the sitemap is split.
+const (
+	SitemapPartFormat = "sitemap-%d.xml"
+)
+
+// DefaultSitemapMaxURLs is the default (and maximum) number of urls in a sitemap file.
+const (
+	DefaultSitemapMaxURLs = 50000
 )
 
 // Sizes are the default sizes for the resized images.
@@ -57,16 +99,107 @@ var (
 	}
 )
 
-// ImageSizeFormat is the format for the thumbnail images..
+// ImageSizeFormat is the format for the thumbnail images, given the size and the extension.
+// ImageSizeEncodingFormat is the format for cached thumbnail images with image encoding options, given the size, a hash of the options and the extension.
+// ImageOriginalFormat is the format for the original image, given the extension.
+// ImageVariantFormat is the format for the named image variants, given the name and the extension.
+const (
+	ImageSizeFormat         = "%d%s"
+	ImageSizeEncodingFormat = "%d-%s%s"
+	ImageOriginalFormat     = "original%s"
+	ImageVariantFormat      = "%s%s"
+)
+
+// ImageOriginal is the name of the original image in the image sizes.
+const ImageOriginal = "original"
+
+// ImageVariantModes are how images are made to fit the dimensions of a variant.
+const (
+	ImageVariantModeFit  = "fit"
+	ImageVariantModeFill = "fill"
+	ImageVariantModeCrop = "crop"
+)
+
+// Anchors are which part of an image is kept when it's cropped.
+const (
+	AnchorSmart       = "smart"
+	AnchorCenter      = "center"
+	AnchorTop         = "top"
+	AnchorBottom      = "bottom"
+	AnchorLeft        = "left"
+	AnchorRight       = "right"
+	AnchorTopLeft     = "top-left"
+	AnchorTopRight    = "top-right"
+	AnchorBottomLeft  = "bottom-left"
+	AnchorBottomRight = "bottom-right"
+)
+
+// SmartCropSampleSize is the long dimension images are downscaled to before finding the smart crop.
+const SmartCropSampleSize = 128
+
+// DefaultImageMemoryBudget is the default memory budget in megabytes for decoded images.
+const DefaultImageMemoryBudget = 1024
+
+// BytesPerPixel is the memory a pixel of a decoded image is assumed to take.
+const BytesPerPixel = 4
+
+// MemorySampleInterval is how often the memory use of a build is sampled for its stats.
+const MemorySampleInterval = 100 * time.Millisecond
+
+// DefaultImageQuality is the default jpeg quality of thumbnails.
+const DefaultImageQuality = 75
+
+// DefaultImageFilter is the default interpolation filter thumbnails are resized with.
+const DefaultImageFilter = "bicubic"
+
+// ColorProfiles are what is done with the icc color profiles of jpegs in their thumbnails.
+const (
+	ColorProfileKeep = "keep"
+	ColorProfileSRGB = "srgb"
+)
+
+// AttributionFields are the exif fields written to the thumbnails of jpegs.
+var (
+	AttributionFields = []string{
+		"Artist",
+		"Copyright",
+	}
+)
+
+// DefaultUnsharpRadius is the default radius of the unsharp mask applied to thumbnails, in pixels.
+const DefaultUnsharpRadius = 1.0
+
+// ImageFormats are the names of the image formats as reported by the image decoders.
 const (
-	ImageSizeFormat = "%d.jpg"
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+	ImageFormatGIF  = "gif"
+	ImageFormatWebP = "webp"
+)
+
+// Orientations are the exif orientation values, i.e. how the stored image
+// must be transformed to be displayed upright.
+const (
+	OrientationNormal         = 1
+	OrientationFlipHorizontal = 2
+	OrientationRotate180      = 3
+	OrientationFlipVertical   = 4
+	OrientationTranspose      = 5
+	OrientationRotate90       = 6
+	OrientationTransverse     = 7
+	OrientationRotate270      = 8
 )
 
 // Extensions are file suffixes that indicate file type.
 const (
-	ExtensionJPG  = ".jpg"
-	ExtensionJPEG = ".jpeg"
-	ExtensionHTML = ".html"
+	ExtensionJPG      = ".jpg"
+	ExtensionJPEG     = ".jpeg"
+	ExtensionPNG      = ".png"
+	ExtensionGIF      = ".gif"
+	ExtensionWebP     = ".webp"
+	ExtensionHTML     = ".html"
+	ExtensionMD       = ".md"
+	ExtensionMarkdown = ".markdown"
 )
 
 // Known Extensions
@@ -74,10 +207,49 @@ var (
 	ImageExtensions = []string{
 		ExtensionJPG,
 		ExtensionJPEG,
+		ExtensionPNG,
+		ExtensionGIF,
+		ExtensionWebP,
 	}
 	TemplateExtensions = []string{
 		ExtensionHTML,
 	}
+	MarkdownExtensions = []string{
+		ExtensionMD,
+		ExtensionMarkdown,
+	}
+)
+
+// PostTypes are the types of posts.
+const (
+	PostTypeImage = "image"
+	PostTypeText  = "text"
+)
+
+// PublicationStates are the publication states of posts.
+const (
+	PublicationStatePublished = "published"
+	PublicationStateDraft     = "draft"
+	PublicationStateScheduled = "scheduled"
+	PublicationStateUnlisted  = "unlisted"
+)
+
+// DefaultPaginationPathFormat is the default format of the path of each page after the first.
+const (
+	DefaultPaginationPathFormat = "page/%d"
+)
+
+// DefaultFeedEntryCount is the default maximum number of entries in a feed.
+const (
+	DefaultFeedEntryCount = 20
+)
+
+// DefaultFeedPostTypes are the post types included in feeds by default.
+var (
+	DefaultFeedPostTypes = []string{
+		PostTypeImage,
+		PostTypeText,
+	}
 )
 
 // PostSortKeys
@@ -87,3 +259,67 @@ var (
 	PostSortKeyIndex   = "index"
 	PostSortKeyTitle   = "title"
 )
+
+// MetadataPolicies are the policies for the metadata of published original images.
+const (
+	MetadataPolicyKeep      = "keep"
+	MetadataPolicyStrip     = "strip"
+	MetadataPolicyAllowlist = "allowlist"
+)
+
+// PrivacyZoneActions are what is done with the gps location of images taken in a privacy zone.
+const (
+	PrivacyZoneActionStrip = "strip"
+	PrivacyZoneActionRound = "round"
+)
+
+// Privacy zone defaults.
+const (
+	DefaultPrivacyZoneRadius    = 1000.0
+	DefaultPrivacyZonePrecision = 2
+)
+
+// DefaultMetadataAllow are the exif fields kept by the allowlist metadata policy by default.
+var (
+	DefaultMetadataAllow = []string{
+		"Artist",
+		"Copyright",
+		"Make",
+		"Model",
+		"LensModel",
+		"DateTime",
+		"DateTimeOriginal",
+		"ExposureTime",
+		"FNumber",
+		"ISOSpeedRatings",
+		"FocalLength",
+	}
+)
+
+// Placeholder settings.
+const (
+	// PlaceholderSize is the long dimension of the inline preview image in pixels.
+	PlaceholderSize = 16
+	// BlurHashSampleSize is the long dimension images are downscaled to before computing the blurhash.
+	BlurHashSampleSize = 32
+	// BlurHashComponentsX and BlurHashComponentsY are the number of blurhash components.
+	BlurHashComponentsX = 4
+	BlurHashComponentsY = 3
+)
+
+// Palette settings.
+const (
+	// PaletteSize is the number of colors in the palette of an image.
+	PaletteSize = 5
+	// PaletteSampleSize is the long dimension images are downscaled to before extracting the palette.
+	PaletteSampleSize = 64
+)
+
+// Duplicate settings.
+const (
+	// PerceptualHashSampleSize is the long dimension images are downscaled to before computing the perceptual hash.
+	PerceptualHashSampleSize = 64
+	// DefaultDuplicateDistance is the default maximum hamming distance between the
+	// perceptual hashes of images that are considered duplicates.
+	DefaultDuplicateDistance = 10
+)
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// NewBuildStats returns a new build stats tracker.
+func NewBuildStats() *BuildStats {
+	return new(BuildStats)
+}
+
+// BuildStats tracks how long the phases of a build take, and how much memory it takes.
+// It is safe to use from multiple goroutines, and a nil tracker tracks nothing.
+type BuildStats struct {
+	sync.Mutex
+	Stats model.BuildStats
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Phase starts timing a phase of the build, returning a function that records
+// how long it took when it's done.
+func (bs *BuildStats) Phase(name string) func() {
+	started := time.Now()
+	return func() {
+		if bs == nil {
+			return
+		}
+		bs.Lock()
+		defer bs.Unlock()
+		bs.Stats.Phases = append(bs.Stats.Phases, model.Timing{Name: name, Elapsed: time.Since(started)})
+	}
+}
+
+// Work adds the time since a given start to the time spent on a step of processing images.
+func (bs *BuildStats) Work(name string, started time.Time) {
+	if bs == nil {
+		return
+	}
+	elapsed := time.Since(started)
+	bs.Lock()
+	defer bs.Unlock()
+	for index := range bs.Stats.Work {
+		if bs.Stats.Work[index].Name == name {
+			bs.Stats.Work[index].Elapsed += elapsed
+			return
+		}
+	}
+	bs.Stats.Work = append(bs.Stats.Work, model.Timing{Name: name, Elapsed: elapsed})
+}
+
+// SampleMemory records the memory the heap takes if it's the most it's taken.
+func (bs *BuildStats) SampleMemory() {
+	if bs == nil {
+		return
+	}
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	bs.Lock()
+	defer bs.Unlock()
+	if memStats.HeapAlloc > bs.Stats.PeakHeapBytes {
+		bs.Stats.PeakHeapBytes = memStats.HeapAlloc
+	}
+}
+
+// Start samples the memory the heap takes in the background until `Stop` is called.
+func (bs *BuildStats) Start() {
+	if bs == nil {
+		return
+	}
+	bs.stop = make(chan struct{})
+	bs.done = make(chan struct{})
+	go func() {
+		defer close(bs.done)
+		ticker := time.NewTicker(constants.MemorySampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				bs.SampleMemory()
+			case <-bs.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops sampling the memory the heap takes, taking a last sample.
+func (bs *BuildStats) Stop() {
+	if bs == nil || bs.stop == nil {
+		return
+	}
+	close(bs.stop)
+	<-bs.done
+	bs.stop = nil
+	bs.SampleMemory()
+}
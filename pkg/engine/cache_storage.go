@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/blogctl/pkg/aws"
+	"github.com/wcharczuk/blogctl/pkg/aws/s3"
+)
+
+// CacheStorage is where the thumbnail cache is stored between builds.
+//
+// Cached files are generated in, and copied to the output from, the thumbnail cache path;
+// storages that share the cache read through to their copy when a file isn't in the thumbnail
+// cache path, and write back to it when a file is generated.
+//
+// Keys are the slash separated paths of the files relative to the thumbnail cache path, which
+// start with the etag of the image, e.g. `<etag>/1024.jpg`, and include a hash of the settings
+// the file was generated with if they aren't the defaults, e.g. `<etag>/1024-<hash>.jpg`.
+type CacheStorage interface {
+	// Fetch makes sure the file for a key is in the thumbnail cache path, returning if it's cached.
+	Fetch(ctx context.Context, key string) (bool, error)
+	// Store stores the file for a key from the thumbnail cache path.
+	Store(ctx context.Context, key string) error
+	// Keys returns the keys of every cached file.
+	Keys(ctx context.Context) ([]string, error)
+	// Remove removes the file for a key.
+	Remove(ctx context.Context, key string) error
+}
+
+// NewCacheStorage returns the cache storage per the config; an s3 storage
+// if the thumbnail cache has a bucket, and otherwise a local storage.
+func (e Engine) NewCacheStorage() CacheStorage {
+	path := e.Config.ThumbnailCachePathOrDefault()
+	if e.Config.ThumbnailCache.IsZero() {
+		return LocalCacheStorage{Path: path}
+	}
+	region := e.Config.ThumbnailCache.S3.Region
+	if region == "" {
+		region = e.Config.S3.Region
+	}
+	manager := s3.New(aws.Config{Region: region})
+	manager.Log = e.Log
+	return S3CacheStorage{
+		Path:    path,
+		Bucket:  e.Config.ThumbnailCache.BucketName(),
+		Prefix:  e.Config.ThumbnailCache.KeyPrefix(),
+		Manager: manager,
+	}
+}
+
+// LocalCacheStorage is a cache storage that keeps the thumbnail cache in the thumbnail cache path only.
+type LocalCacheStorage struct {
+	Path string
+}
+
+// Fetch returns if the file for a key is in the thumbnail cache path.
+func (lcs LocalCacheStorage) Fetch(ctx context.Context, key string) (bool, error) {
+	return Exists(filepath.Join(lcs.Path, filepath.FromSlash(key))), nil
+}
+
+// Store does nothing, as the file is already in the thumbnail cache path.
+func (lcs LocalCacheStorage) Store(ctx context.Context, key string) error {
+	return nil
+}
+
+// Keys returns the keys of the files in the thumbnail cache path.
+func (lcs LocalCacheStorage) Keys(ctx context.Context) ([]string, error) {
+	if !Exists(lcs.Path) {
+		return nil, nil
+	}
+	var keys []string
+	err := filepath.Walk(lcs.Path, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		key, err := filepath.Rel(lcs.Path, currentPath)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(key))
+		return nil
+	})
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	return keys, nil
+}
+
+// Remove removes the file for a key from the thumbnail cache path, and its directory if it's left empty.
+func (lcs LocalCacheStorage) Remove(ctx context.Context, key string) error {
+	cachePath := filepath.Join(lcs.Path, filepath.FromSlash(key))
+	if err := os.Remove(cachePath); err != nil && !os.IsNotExist(err) {
+		return ex.New(err)
+	}
+	if dir := filepath.Dir(cachePath); dir != filepath.Clean(lcs.Path) {
+		if files, err := ioutil.ReadDir(dir); err == nil && len(files) == 0 {
+			os.Remove(dir)
+		}
+	}
+	return nil
+}
+
+// S3CacheStorage is a cache storage that shares the thumbnail cache in an s3 bucket.
+//
+// Files that aren't in the thumbnail cache path are downloaded from the bucket,
+// and files that are generated are uploaded to it.
+type S3CacheStorage struct {
+	Path    string
+	Bucket  string
+	Prefix  string
+	Manager *s3.Manager
+}
+
+// Fetch makes sure the file for a key is in the thumbnail cache path,
+// downloading it from the bucket if it isn't.
+func (scs S3CacheStorage) Fetch(ctx context.Context, key string) (bool, error) {
+	local := LocalCacheStorage{Path: scs.Path}
+	if cached, _ := local.Fetch(ctx, key); cached {
+		return true, nil
+	}
+	_, contents, err := scs.Manager.Get(ctx, scs.Bucket, scs.Prefix+key)
+	if err != nil {
+		return false, err
+	}
+	if contents == nil {
+		return false, nil
+	}
+	defer contents.Close()
+
+	// the file is downloaded next to where it goes so it's never partially written.
+	cachePath := filepath.Join(scs.Path, filepath.FromSlash(key))
+	if err := MakeDir(filepath.Dir(cachePath)); err != nil {
+		return false, err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(cachePath), ".download-")
+	if err != nil {
+		return false, ex.New(err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := io.Copy(temp, contents); err != nil {
+		temp.Close()
+		return false, ex.New(err)
+	}
+	if err := temp.Close(); err != nil {
+		return false, ex.New(err)
+	}
+	if err := os.Rename(temp.Name(), cachePath); err != nil {
+		return false, ex.New(err)
+	}
+	logger.MaybeDebugf(scs.Manager.Log, "%s: downloaded cached file", key)
+	return true, nil
+}
+
+// Store uploads the file for a key from the thumbnail cache path to the bucket.
+func (scs S3CacheStorage) Store(ctx context.Context, key string) error {
+	return scs.Manager.Put(ctx, s3.File{
+		FilePath: filepath.Join(scs.Path, filepath.FromSlash(key)),
+		Bucket:   scs.Bucket,
+		Key:      scs.Prefix + key,
+		ACL:      s3.ACLPrivate,
+	})
+}
+
+// Keys returns the keys of the files in the bucket and the thumbnail cache path.
+func (scs S3CacheStorage) Keys(ctx context.Context) ([]string, error) {
+	files, err := scs.Manager.ListPrefix(ctx, scs.Bucket, scs.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := LocalCacheStorage{Path: scs.Path}.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		seen[key] = true
+	}
+	for _, file := range files {
+		if key := strings.TrimPrefix(file.Key, scs.Prefix); !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Remove removes the file for a key from the bucket and the thumbnail cache path.
+func (scs S3CacheStorage) Remove(ctx context.Context, key string) error {
+	if err := scs.Manager.Delete(ctx, scs.Bucket, scs.Prefix+key); err != nil {
+		return err
+	}
+	return LocalCacheStorage{Path: scs.Path}.Remove(ctx, key)
+}
+
+// CacheKey returns the cache storage key for a path in the thumbnail cache path.
+func (e Engine) CacheKey(cachePath string) string {
+	key, err := filepath.Rel(e.Config.ThumbnailCachePathOrDefault(), cachePath)
+	if err != nil {
+		return filepath.ToSlash(cachePath)
+	}
+	return filepath.ToSlash(key)
+}
+
+// IsCached returns if a path in the thumbnail cache path is cached, fetching it from the cache storage if it's shared.
+func (e Engine) IsCached(ctx context.Context, cachePath string) (bool, error) {
+	if e.CacheStorage == nil {
+		return Exists(cachePath), nil
+	}
+	return e.CacheStorage.Fetch(ctx, e.CacheKey(cachePath))
+}
+
+// StoreCached stores a file that was generated in the thumbnail cache path in the cache storage.
+func (e Engine) StoreCached(ctx context.Context, cachePath string) error {
+	if e.CacheStorage == nil {
+		return nil
+	}
+	return e.CacheStorage.Store(ctx, e.CacheKey(cachePath))
+}
+
+// cacheKeyDirectoryName matches the thumbnail cache key of an image; its etag, and its orientation if it has one.
+var cacheKeyDirectoryName = regexp.MustCompile(`^[0-9a-f]{32}(-o[1-8])?$`)
+
+// cacheKeyDirectory returns the directory of a key, i.e. the thumbnail cache key of the image it's for.
+// It returns empty if the key isn't in the directory of an image, so only cached files of images are cleaned.
+func cacheKeyDirectory(key string) string {
+	if dir := path.Dir(key); dir != "." {
+		if name := strings.SplitN(dir, "/", 2)[0]; cacheKeyDirectoryName.MatchString(name) {
+			return name
+		}
+	}
+	return ""
+}
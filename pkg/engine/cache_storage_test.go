@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ref"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+)
+
+// directoryCacheStorage is a shared cache storage backed by another directory, like a bucket.
+type directoryCacheStorage struct {
+	LocalCacheStorage
+	Remote string
+	Misses *fetchMisses
+}
+
+// fetchMisses counts the fetches of each key that isn't in the shared cache.
+type fetchMisses struct {
+	sync.Mutex
+	Keys map[string]int
+}
+
+func (dcs directoryCacheStorage) Fetch(ctx context.Context, key string) (bool, error) {
+	if cached, _ := dcs.LocalCacheStorage.Fetch(ctx, key); cached {
+		return true, nil
+	}
+	remotePath := filepath.Join(dcs.Remote, filepath.FromSlash(key))
+	if !Exists(remotePath) {
+		dcs.Misses.Lock()
+		dcs.Misses.Keys[key]++
+		dcs.Misses.Unlock()
+		return false, nil
+	}
+	localPath := filepath.Join(dcs.Path, filepath.FromSlash(key))
+	if err := MakeDir(filepath.Dir(localPath)); err != nil {
+		return false, err
+	}
+	return true, Copy(remotePath, localPath)
+}
+
+func (dcs directoryCacheStorage) Store(ctx context.Context, key string) error {
+	remotePath := filepath.Join(dcs.Remote, filepath.FromSlash(key))
+	if err := MakeDir(filepath.Dir(remotePath)); err != nil {
+		return err
+	}
+	return Copy(filepath.Join(dcs.Path, filepath.FromSlash(key)), remotePath)
+}
+
+func (dcs directoryCacheStorage) Keys(ctx context.Context) ([]string, error) {
+	return LocalCacheStorage{Path: dcs.Remote}.Keys(ctx)
+}
+
+func (dcs directoryCacheStorage) Remove(ctx context.Context, key string) error {
+	if err := (LocalCacheStorage{Path: dcs.Remote}).Remove(ctx, key); err != nil {
+		return err
+	}
+	return dcs.LocalCacheStorage.Remove(ctx, key)
+}
+
+func TestEngineBuildSharedCache(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(os.Chdir("testdata"))
+
+	defer func() {
+		os.RemoveAll("thumbnails")
+		os.RemoveAll("remote")
+		os.RemoveAll("dist")
+		os.Remove("manifest.json")
+		os.Chdir("..")
+	}()
+
+	cfg, _, err := config.ReadConfig(config.Flags{
+		ConfigPath:  ref.String("./config.yml"),
+		Parallelism: ref.Int(4),
+	})
+	assert.Nil(err)
+	cfg.ImageSizes = []int{64}
+	storage := directoryCacheStorage{
+		LocalCacheStorage: LocalCacheStorage{Path: "thumbnails"},
+		Remote:            "remote",
+		Misses:            &fetchMisses{Keys: make(map[string]int)},
+	}
+	assert.Nil(MustNew(OptConfig(cfg), OptCacheStorage(storage)).Build(context.TODO()))
+
+	// files that aren't in the shared cache are only looked up once before they're generated.
+	assert.NotEmpty(storage.Misses.Keys)
+	for key, misses := range storage.Misses.Keys {
+		assert.Equal(1, misses, key)
+	}
+
+	// generated files are written back to the shared cache.
+	keys, err := storage.Keys(context.TODO())
+	assert.Nil(err)
+	var thumbnailKeys []string
+	for _, key := range keys {
+		if strings.Contains(key, "/64.") {
+			thumbnailKeys = append(thumbnailKeys, key)
+		}
+	}
+	assert.NotEmpty(thumbnailKeys)
+
+	// a fresh checkout reads the thumbnails through from the shared cache rather than generating them.
+	assert.Nil(os.RemoveAll("thumbnails"))
+	assert.Nil(os.RemoveAll("dist"))
+	assert.Nil(os.Remove("manifest.json"))
+	for _, key := range thumbnailKeys {
+		assert.Nil(WriteFile(filepath.Join("remote", key), []byte("shared")))
+	}
+	assert.Nil(MustNew(OptConfig(cfg), OptCacheStorage(storage)).Build(context.TODO()))
+	contents, err := ioutil.ReadFile("dist/2019/02/11/image-post/64.jpg")
+	assert.Nil(err)
+	assert.Equal("shared", string(contents))
+
+	// cleaning purges orphaned files of images from the shared cache, and leaves other files alone.
+	orphan := "remote/0123456789abcdef0123456789abcdef-o6/64.jpg"
+	assert.Nil(MakeDir(filepath.Dir(orphan)))
+	assert.Nil(WriteFile(orphan, []byte("orphan")))
+	assert.Nil(MakeDir("remote/other"))
+	assert.Nil(WriteFile("remote/other/64.jpg", []byte("other")))
+	assert.Nil(MustNew(OptConfig(cfg), OptCacheStorage(storage)).CleanThumbnailCache(context.TODO()))
+	_, err = os.Stat(orphan)
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat("remote/other/64.jpg")
+	assert.Nil(err)
+	_, err = os.Stat(filepath.Join("remote", thumbnailKeys[0]))
+	assert.Nil(err)
+}
@@ -22,3 +22,37 @@ func GetRenderContext(ctx context.Context) *model.RenderContext {
 	}
 	return nil
 }
+
+type manifestKey struct{}
+
+// WithManifest returns a context with a build manifest set.
+func WithManifest(ctx context.Context, m *Manifest) context.Context {
+	return context.WithValue(ctx, manifestKey{}, m)
+}
+
+// GetManifest returns the build manifest off a context.
+func GetManifest(ctx context.Context) *Manifest {
+	if raw := ctx.Value(manifestKey{}); raw != nil {
+		if typed, ok := raw.(*Manifest); ok {
+			return typed
+		}
+	}
+	return nil
+}
+
+type buildStatsKey struct{}
+
+// WithBuildStats returns a context with a build stats tracker set.
+func WithBuildStats(ctx context.Context, bs *BuildStats) context.Context {
+	return context.WithValue(ctx, buildStatsKey{}, bs)
+}
+
+// GetBuildStats returns the build stats tracker off a context.
+func GetBuildStats(ctx context.Context) *BuildStats {
+	if raw := ctx.Value(buildStatsKey{}); raw != nil {
+		if typed, ok := raw.(*BuildStats); ok {
+			return typed
+		}
+	}
+	return nil
+}
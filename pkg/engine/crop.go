@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// GenerateCrops sets the crops of every image of the posts for the
+// image variants that are cropped, i.e. with the `fill` or `crop` modes.
+//
+// Only smart crops are cached, as the other crops are cheap to compute.
+func (e Engine) GenerateCrops(ctx context.Context, posts []*model.Post) error {
+	return e.ProcessSourceImages(ctx, posts, func(image *model.Image, source *SourceImage) (err error) {
+		image.Crops, err = e.ImageCrops(ctx, source)
+		return
+	})
+}
+
+// ImageCrops returns the crops of an image for the image variants that are cropped, by variant name.
+func (e Engine) ImageCrops(ctx context.Context, source *SourceImage) (map[string]model.Crop, error) {
+	crops := make(map[string]model.Crop)
+	for _, variant := range e.Config.ImageVariants {
+		if variant.ModeOrDefault() == constants.ImageVariantModeFit {
+			continue
+		}
+		crop, err := e.ImageCrop(ctx, source, variant)
+		if err != nil {
+			return nil, err
+		}
+		crops[variant.Name] = crop
+	}
+	if len(crops) == 0 {
+		return nil, nil
+	}
+	return crops, nil
+}
+
+// ImageCrop returns the region of an image, as it's displayed, kept for a variant.
+//
+// The region is centered on the focus of the image or the variant, or the anchor of
+// the variant, and otherwise is the region with the most interesting content.
+func (e Engine) ImageCrop(ctx context.Context, source *SourceImage, variant config.ImageVariant) (model.Crop, error) {
+	original := source.Original
+	width, height := CropDimensions(original.Width, original.Height, variant)
+	if focusX, focusY, ok := ImageVariantFocus(original, variant); ok {
+		return model.Crop{
+			X:      cropOffset(original.Width, width, focusX),
+			Y:      cropOffset(original.Height, height, focusY),
+			Width:  width,
+			Height: height,
+		}, nil
+	}
+
+	var crop model.Crop
+	name := fmt.Sprintf(constants.FileSmartCropFormat, width, height)
+	if err := e.CachedImageData(ctx, original, name, &crop, func() error {
+		logger.MaybeDebugf(e.Log, "%s: generating cached smart crop %dx%d", original.SourcePath, width, height)
+		decoded, err := source.Decode()
+		if err != nil {
+			return err
+		}
+		crop = GenerateSmartCrop(decoded, original, width, height)
+		return nil
+	}); err != nil {
+		return model.Crop{}, err
+	}
+	return crop, nil
+}
+
+// CropDimensions returns the size of the region of an image, as it's displayed, kept for a variant.
+//
+// For the `fill` mode it's the largest region with the aspect ratio of the variant,
+// and for the `crop` mode it's the dimensions of the variant; neither is larger than the image.
+func CropDimensions(width, height int, variant config.ImageVariant) (int, int) {
+	if variant.ModeOrDefault() == constants.ImageVariantModeCrop {
+		return minInt(width, variant.Width), minInt(height, variant.Height)
+	}
+	scale := math.Min(float64(width)/float64(variant.Width), float64(height)/float64(variant.Height))
+	scaledWidth, scaledHeight := scaleDimensions(variant.Width, variant.Height, scale)
+	return minInt(width, scaledWidth), minInt(height, scaledHeight)
+}
+
+// GenerateSmartCrop finds the region of a given size of an image, as it's displayed,
+// with the most interesting content from a downscaled copy of it.
+//
+// For gifs the region is found from the first frame.
+func GenerateSmartCrop(decoded image.Image, original model.Image, width, height int) model.Crop {
+	sample := Orient(resize.Thumbnail(constants.SmartCropSampleSize, constants.SmartCropSampleSize, decoded, resize.Bilinear), original.Exif.Orientation)
+	bounds := sample.Bounds()
+	scaleX := float64(bounds.Dx()) / float64(original.Width)
+	scaleY := float64(bounds.Dy()) / float64(original.Height)
+
+	region := resize.SmartCrop(sample, int(math.Round(float64(width)*scaleX)), int(math.Round(float64(height)*scaleY))).Sub(bounds.Min)
+	focusX := (float64(region.Min.X) + float64(region.Dx())/2) / float64(bounds.Dx())
+	focusY := (float64(region.Min.Y) + float64(region.Dy())/2) / float64(bounds.Dy())
+	return model.Crop{
+		X:      cropOffset(original.Width, width, focusX),
+		Y:      cropOffset(original.Height, height, focusY),
+		Width:  width,
+		Height: height,
+	}
+}
+
+// UnorientRectangle returns the region of an image as it's stored for a region of the image
+// as it's displayed, i.e. after it's rotated or flipped per its exif orientation.
+// The width and height are of the image as it's stored.
+func UnorientRectangle(region image.Rectangle, orientation, width, height int) image.Rectangle {
+	x, y, w, h := region.Min.X, region.Min.Y, region.Dx(), region.Dy()
+	switch orientation {
+	case constants.OrientationFlipHorizontal:
+		return image.Rect(width-(x+w), y, width-x, y+h)
+	case constants.OrientationRotate180:
+		return image.Rect(width-(x+w), height-(y+h), width-x, height-y)
+	case constants.OrientationFlipVertical:
+		return image.Rect(x, height-(y+h), x+w, height-y)
+	case constants.OrientationTranspose:
+		return image.Rect(y, x, y+h, x+w)
+	case constants.OrientationRotate90:
+		return image.Rect(y, height-(x+w), y+h, height-x)
+	case constants.OrientationTransverse:
+		return image.Rect(width-(y+h), height-(x+w), width-y, height-x)
+	case constants.OrientationRotate270:
+		return image.Rect(width-(y+h), x, width-y, x+w)
+	}
+	return region
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"image"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/phash"
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// PerceptualHash is the perceptual hash of an image as cached.
+type PerceptualHash struct {
+	// DHash is the difference hash of the image as hex.
+	DHash string `json:"dHash"`
+}
+
+// GeneratePerceptualHashes sets the perceptual hash of every image of the posts.
+func (e Engine) GeneratePerceptualHashes(ctx context.Context, posts []*model.Post) error {
+	return e.ProcessSourceImages(ctx, posts, func(image *model.Image, source *SourceImage) (err error) {
+		image.PerceptualHash, err = e.PerceptualHash(ctx, source)
+		return
+	})
+}
+
+// PerceptualHash returns the perceptual hash of an image from the thumbnail cache,
+// generating and caching it if it isn't cached.
+func (e Engine) PerceptualHash(ctx context.Context, source *SourceImage) (string, error) {
+	var hash PerceptualHash
+	if err := e.CachedImageData(ctx, source.Original, constants.FilePerceptualHash, &hash, func() error {
+		logger.MaybeDebugf(e.Log, "%s: generating cached perceptual hash", source.Original.SourcePath)
+		decoded, err := source.Decode()
+		if err != nil {
+			return err
+		}
+		hash = GeneratePerceptualHash(decoded, source.Original)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return hash.DHash, nil
+}
+
+// GeneratePerceptualHash computes the difference hash of an image as it's displayed,
+// that is after it's rotated or flipped per its exif orientation.
+func GeneratePerceptualHash(decoded image.Image, original model.Image) (output PerceptualHash) {
+	sample := Orient(resize.Thumbnail(constants.PerceptualHashSampleSize, constants.PerceptualHashSampleSize, decoded, resize.Bilinear), original.Exif.Orientation)
+	output.DHash = phash.DHash(sample).String()
+	return
+}
+
+// Duplicates returns the sets of near identical images of different posts,
+// that is images whose perceptual hashes are within a given hamming distance,
+// in the order of the posts.
+//
+// The perceptual hashes of the images must be set first with `GeneratePerceptualHashes`.
+// Near identical images of the same post, like in a gallery, aren't duplicates.
+func Duplicates(posts []*model.Post, maxDistance int) (model.Duplicates, error) {
+	type hashedImage struct {
+		post  *model.Post
+		image model.Image
+		hash  phash.Hash
+	}
+	var images []hashedImage
+	for _, post := range posts {
+		for _, image := range post.Images {
+			if image.PerceptualHash == "" {
+				continue
+			}
+			hash, err := phash.Parse(image.PerceptualHash)
+			if err != nil {
+				return nil, ex.New(err).WithMessagef("image path: %s", image.SourcePath)
+			}
+			images = append(images, hashedImage{post: post, image: image, hash: hash})
+		}
+	}
+
+	// sets are joined with union find, so images within the distance of
+	// any image of a set are part of it.
+	parents := make([]int, len(images))
+	for index := range parents {
+		parents[index] = index
+	}
+	var find func(int) int
+	find = func(index int) int {
+		if parents[index] != index {
+			parents[index] = find(parents[index])
+		}
+		return parents[index]
+	}
+	for i := range images {
+		for j := i + 1; j < len(images); j++ {
+			if images[i].post == images[j].post {
+				continue
+			}
+			if images[i].hash.Distance(images[j].hash) <= maxDistance {
+				if rootI, rootJ := find(i), find(j); rootI != rootJ {
+					// keep the earliest image as the root so it is listed first.
+					if rootI < rootJ {
+						parents[rootJ] = rootI
+					} else {
+						parents[rootI] = rootJ
+					}
+				}
+			}
+		}
+	}
+
+	var output model.Duplicates
+	sets := make(map[int]int)
+	for index, image := range images {
+		root := find(index)
+		if root == index {
+			continue
+		}
+		setIndex, ok := sets[root]
+		if !ok {
+			setIndex = len(output)
+			sets[root] = setIndex
+			output = append(output, model.Duplicate{
+				Images: []model.DuplicateImage{duplicateImage(images[root].post, images[root].image, 0)},
+			})
+		}
+		output[setIndex].Images = append(output[setIndex].Images, duplicateImage(image.post, image.image, images[root].hash.Distance(image.hash)))
+	}
+	return output, nil
+}
+
+// FindDuplicates returns the images of the posts that are within a given hamming
+// distance of an image, e.g. one that is about to be posted.
+//
+// The perceptual hashes of the images, and of the posts' images, must be set first.
+func FindDuplicates(posts []*model.Post, image model.Image, maxDistance int) ([]model.DuplicateImage, error) {
+	hash, err := phash.Parse(image.PerceptualHash)
+	if err != nil {
+		return nil, ex.New(err).WithMessagef("image path: %s", image.SourcePath)
+	}
+	var output []model.DuplicateImage
+	for _, post := range posts {
+		for _, postImage := range post.Images {
+			if postImage.PerceptualHash == "" {
+				continue
+			}
+			postHash, err := phash.Parse(postImage.PerceptualHash)
+			if err != nil {
+				return nil, ex.New(err).WithMessagef("image path: %s", postImage.SourcePath)
+			}
+			if distance := hash.Distance(postHash); distance <= maxDistance {
+				output = append(output, duplicateImage(post, postImage, distance))
+			}
+		}
+	}
+	return output, nil
+}
+
+func duplicateImage(post *model.Post, image model.Image, distance int) model.DuplicateImage {
+	return model.DuplicateImage{
+		Title:          post.TitleOrDefault(),
+		Slug:           post.Slug,
+		SourcePath:     image.SourcePath,
+		PerceptualHash: image.PerceptualHash,
+		Distance:       distance,
+	}
+}
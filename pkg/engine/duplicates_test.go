@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestDuplicates(t *testing.T) {
+	assert := assert.New(t)
+
+	posts := []*model.Post{
+		{Slug: "first", Meta: model.Meta{Title: "First"}, Images: []model.Image{
+			{SourcePath: "first/a.jpg", PerceptualHash: "f0f0f0f0f0f0f0f0"},
+			{SourcePath: "first/b.jpg", PerceptualHash: "f0f0f0f0f0f0f0f1"},
+		}},
+		{Slug: "second", Meta: model.Meta{Title: "Second"}, Images: []model.Image{
+			{SourcePath: "second/a.jpg", PerceptualHash: "0f0f0f0f0f0f0f0f"},
+			{SourcePath: "second/gradient.jpg", PerceptualHash: "0000000000000000"},
+		}},
+		{Slug: "third", Meta: model.Meta{Title: "Third"}, Images: []model.Image{
+			{SourcePath: "third/a.jpg", PerceptualHash: "f0f0f0f0f0f0f0f3"},
+			{SourcePath: "third/gradient.jpg", PerceptualHash: "0000000000000000"},
+		}},
+	}
+
+	duplicates, err := Duplicates(posts, 2)
+	assert.Nil(err)
+	assert.Len(duplicates, 2)
+	assert.Len(duplicates[0].Images, 3)
+	assert.Equal("first/a.jpg", duplicates[0].Images[0].SourcePath)
+	assert.Zero(duplicates[0].Images[0].Distance)
+	assert.Equal("first/b.jpg", duplicates[0].Images[1].SourcePath)
+	assert.Equal(1, duplicates[0].Images[1].Distance)
+	assert.Equal("third/a.jpg", duplicates[0].Images[2].SourcePath)
+	assert.Equal(2, duplicates[0].Images[2].Distance)
+	// images without much detail, like smooth gradients, hash to zero and are compared like any other.
+	assert.Len(duplicates[1].Images, 2)
+	assert.Equal("second/gradient.jpg", duplicates[1].Images[0].SourcePath)
+	assert.Equal("third/gradient.jpg", duplicates[1].Images[1].SourcePath)
+	assert.Len(duplicates.TableRows(), 5)
+
+	// near identical images of the same post aren't duplicates on their own.
+	duplicates, err = Duplicates(posts[:1], 2)
+	assert.Nil(err)
+	assert.Empty(duplicates)
+
+	found, err := FindDuplicates(posts, model.Image{SourcePath: "new.jpg", PerceptualHash: "0f0f0f0f0f0f0f0e"}, 2)
+	assert.Nil(err)
+	assert.Len(found, 1)
+	assert.Equal("Second", found[0].Title)
+	assert.Equal(1, found[0].Distance)
+
+	found, err = FindDuplicates(posts, model.Image{SourcePath: "gradient.jpg", PerceptualHash: "0000000000000001"}, 2)
+	assert.Nil(err)
+	assert.Len(found, 2)
+}
@@ -7,7 +7,8 @@ import (
 	"fmt"
 	"html/template"
 	"image"
-	"image/jpeg"
+	"image/gif"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -15,11 +16,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blend/go-sdk/async"
 	"github.com/blend/go-sdk/ex"
-	"github.com/blend/go-sdk/fileutil"
 	"github.com/blend/go-sdk/logger"
 	"github.com/blend/go-sdk/stringutil"
 
@@ -37,6 +38,16 @@ func New(options ...Option) (*Engine, error) {
 			return nil, err
 		}
 	}
+	if e.SourceIndex == nil {
+		e.SourceIndex = NewSourceIndex(filepath.Join(e.Config.ThumbnailCachePathOrDefault(), constants.FileSourceIndex))
+		e.SourceIndex.Log = e.Log
+	}
+	if e.CacheStorage == nil {
+		e.CacheStorage = e.NewCacheStorage()
+	}
+	if e.MemoryBudget == nil {
+		e.MemoryBudget = NewMemoryBudget(int64(e.Config.ImageMemoryBudgetOrDefault()) << 20)
+	}
 	return &e, nil
 }
 
@@ -76,6 +87,14 @@ func OptParallelism(paralellism int) Option {
 	}
 }
 
+// OptCacheStorage sets the cache storage of the thumbnail cache.
+func OptCacheStorage(storage CacheStorage) Option {
+	return func(e *Engine) error {
+		e.CacheStorage = storage
+		return nil
+	}
+}
+
 // OptDryRun sets DryRun on the engine.
 func OptDryRun(dryRun bool) Option {
 	return func(e *Engine) error {
@@ -84,12 +103,33 @@ func OptDryRun(dryRun bool) Option {
 	}
 }
 
+// OptRebuild sets Rebuild on the engine.
+func OptRebuild(rebuild bool) Option {
+	return func(e *Engine) error {
+		e.Rebuild = rebuild
+		return nil
+	}
+}
+
+// OptDrafts sets Drafts on the engine.
+func OptDrafts(drafts bool) Option {
+	return func(e *Engine) error {
+		e.Drafts = drafts
+		return nil
+	}
+}
+
 // Engine returns a
 type Engine struct {
-	Config      config.Config
-	Parallelism int
-	DryRun      bool
-	Log         logger.Log
+	Config       config.Config
+	Parallelism  int
+	DryRun       bool
+	Rebuild      bool
+	Drafts       bool
+	Log          logger.Log
+	SourceIndex  *SourceIndex
+	MemoryBudget *MemoryBudget
+	CacheStorage CacheStorage
 }
 
 // ParallelismOrDefault is the parallelism or a default.
@@ -102,12 +142,24 @@ func (e Engine) ParallelismOrDefault() int {
 
 // Build generates the blog to the given output directory.
 func (e Engine) Build(ctx context.Context) error {
+	stats := NewBuildStats()
+	stats.Start()
+	defer stats.Stop()
+	ctx = WithBuildStats(ctx, stats)
+
+	endPhase := stats.Phase("discover")
 	renderContext, err := e.BuildRenderContext(ctx)
 	if err != nil {
 		return err
 	}
+	endPhase()
+
+	previousManifest, err := e.ReadBuildManifest()
+	if err != nil {
+		return err
+	}
 
-	if err := e.InitializeOutputPath(); err != nil {
+	if err := e.InitializeOutputPath(previousManifest); err != nil {
 		return err
 	}
 
@@ -115,27 +167,60 @@ func (e Engine) Build(ctx context.Context) error {
 		return err
 	}
 
+	manifest := NewManifest(previousManifest)
+
 	ctx = WithRenderContext(ctx, renderContext)
+	ctx = WithManifest(ctx, manifest)
 	if err := e.Render(ctx); err != nil {
 		return err
 	}
+	if err := e.PruneOutputs(ctx, manifest); err != nil {
+		return err
+	}
+	// dry runs don't prune stale outputs, so the manifest is kept to prune them later.
+	if !e.DryRun {
+		if err := e.WriteBuildManifest(manifest.Current); err != nil {
+			return err
+		}
+	}
+	if err := e.SaveSourceIndex(); err != nil {
+		return err
+	}
+	logger.MaybeInfof(e.Log, "rendered %d outputs, skipped %d unchanged outputs", manifest.Rendered, manifest.Skipped)
 
 	columns, rows := renderContext.Stats.TableData()
 	for index, column := range columns {
 		logger.MaybeInfof(e.Log, "%s: %s", column, rows[0][index])
 	}
 
+	stats.Stop()
+	stats.Stats.PeakImageBytes = e.MemoryBudget.Peak()
+	columns, rows = stats.Stats.TableData()
+	for index, column := range columns {
+		logger.MaybeInfof(e.Log, "%s: %s", column, rows[0][index])
+	}
+
 	return nil
 }
 
 // InitializeOutputPath creates the output path if it doesn't exist.
-func (e Engine) InitializeOutputPath() error {
-	if Exists(e.Config.OutputPathOrDefault()) {
-		if err := ex.New(os.RemoveAll(e.Config.OutputPathOrDefault())); err != nil {
-			return err
+//
+// Any existing output is removed first if there is no manifest from a previous build,
+// as there is nothing to prune stale outputs against; this includes when the engine
+// is set to rebuild, or the manifest was written by a different version of the format.
+func (e Engine) InitializeOutputPath(previous model.Manifest) error {
+	outputPath := e.Config.OutputPathOrDefault()
+	if previous.Version != constants.BuildManifestVersion && Exists(outputPath) {
+		if e.DryRun {
+			logger.MaybeInfof(e.Log, "%s: (dry-run) would remove existing output", outputPath)
+		} else {
+			logger.MaybeInfof(e.Log, "%s: removing existing output", outputPath)
+			if err := ex.New(os.RemoveAll(outputPath)); err != nil {
+				return err
+			}
 		}
 	}
-	return MakeDir(e.Config.OutputPathOrDefault())
+	return MakeDir(outputPath)
 }
 
 // InitializeThumbnailCache creates the output path if it doesn't exist.
@@ -147,8 +232,12 @@ func (e Engine) InitializeThumbnailCache() error {
 }
 
 // DiscoverPosts generates the blog data.
+//
+// Draft posts are skipped unless the engine includes drafts, and scheduled posts
+// are skipped until their publish time. Unlisted posts are kept separate from the
+// listed posts so they're rendered but left out of the listings.
 func (e Engine) DiscoverPosts(ctx context.Context) (*model.Data, error) {
-	slugTemplate, err := e.ParseSlugTemplate()
+	posts, err := e.DiscoverAllPosts(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -159,48 +248,35 @@ func (e Engine) DiscoverPosts(ctx context.Context) (*model.Data, error) {
 		BaseURL: e.Config.BaseURLOrDefault(),
 	}
 	tags := make(map[string]*model.Tag)
-	postsPath := e.Config.PostsPathOrDefault()
 
-	var postIndex int
-	logger.MaybeInfof(e.Log, "searching `%s` for posts", postsPath)
-	err = filepath.Walk(postsPath, func(currentPath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	now := time.Now().UTC()
+	for _, post := range posts {
+		if post.Meta.Draft && !e.Drafts {
+			logger.MaybeDebugf(e.Log, "%s: skipping draft post", post.OriginalPath)
+			continue
 		}
-		if currentPath == postsPath {
-			return nil
+		if post.IsScheduled(now) {
+			logger.MaybeDebugf(e.Log, "%s: skipping post scheduled for %v", post.OriginalPath, post.Meta.PublishAt)
+			continue
 		}
-		if info.IsDir() {
-			defer func() {
-				postIndex++
-			}()
-
-			logger.MaybeDebugf(e.Log, "%s: reading post (%d)", currentPath, postIndex)
-
-			// check if we have an image
-			post, err := e.GeneratePost(ctx, slugTemplate, currentPath, postIndex)
-			if err != nil {
-				return err
-			}
-			output.Posts = append([]*model.Post{post}, output.Posts...)
-
-			if !e.Config.SkipGenerateTags {
-				for _, tag := range post.Meta.Tags {
-					if tagPosts, ok := tags[tag]; ok {
-						tagPosts.Posts = append(tagPosts.Posts, post)
-					} else {
-						tags[tag] = &model.Tag{
-							Tag:   tag,
-							Posts: []*model.Post{post},
-						}
+		if post.Meta.Unlisted {
+			output.Unlisted = append(output.Unlisted, post)
+			continue
+		}
+		output.Posts = append(output.Posts, post)
+
+		if !e.Config.SkipGenerateTags {
+			for _, tag := range post.Meta.Tags {
+				if tagPosts, ok := tags[tag]; ok {
+					tagPosts.Posts = append(tagPosts.Posts, post)
+				} else {
+					tags[tag] = &model.Tag{
+						Tag:   tag,
+						Posts: []*model.Post{post},
 					}
 				}
 			}
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
 	// sort by metadata posted date
@@ -229,8 +305,53 @@ func (e Engine) DiscoverPosts(ctx context.Context) (*model.Data, error) {
 	return &output, nil
 }
 
+// DiscoverAllPosts reads every post in the posts path, regardless of its publication state.
+func (e Engine) DiscoverAllPosts(ctx context.Context) (output []*model.Post, err error) {
+	slugTemplate, err := e.ParseSlugTemplate()
+	if err != nil {
+		return nil, err
+	}
+	postsPath := e.Config.PostsPathOrDefault()
+
+	var postIndex int
+	logger.MaybeInfof(e.Log, "searching `%s` for posts", postsPath)
+	err = filepath.Walk(postsPath, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if currentPath == postsPath {
+			return nil
+		}
+		if info.IsDir() {
+			defer func() {
+				postIndex++
+			}()
+
+			logger.MaybeDebugf(e.Log, "%s: reading post (%d)", currentPath, postIndex)
+
+			// check if we have an image
+			post, err := e.GeneratePost(ctx, slugTemplate, currentPath, postIndex)
+			if err != nil {
+				return err
+			}
+			output = append([]*model.Post{post}, output...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
 // BuildRenderContext builds the render context used by the render function.
 func (e Engine) BuildRenderContext(ctx context.Context) (*model.RenderContext, error) {
+	if err := e.ValidateImageVariants(); err != nil {
+		return nil, err
+	}
+	if err := e.ValidateImageEncodings(); err != nil {
+		return nil, err
+	}
 	partials, err := e.DiscoverPartials(ctx)
 	if err != nil {
 		return nil, err
@@ -254,14 +375,41 @@ func (e Engine) BuildRenderContext(ctx context.Context) (*model.RenderContext, e
 }
 
 // Render writes the templates out for each of the posts.
+//
+// Outputs whose inputs are unchanged since the last build (as recorded in the
+// build manifest on the context) are skipped.
 func (e Engine) Render(ctx context.Context) error {
 	renderContext := GetRenderContext(ctx)
+	manifest := GetManifest(ctx)
+	if manifest == nil {
+		manifest = NewManifest(model.Manifest{})
+	}
 
 	logger.MaybeInfof(e.Log, "rendering site with parallelism %d", e.ParallelismOrDefault())
 	var err error
 
 	outputPath := e.Config.OutputPathOrDefault()
 
+	// unlisted posts are rendered like any other post, they're just not listed.
+	allPosts := append(append([]*model.Post{}, renderContext.Data.Posts...), renderContext.Data.Unlisted...)
+
+	stats := GetBuildStats(ctx)
+
+	// crops, placeholders and palettes are generated first as they're part of the image details the templates use;
+	// the thumbnails are generated along with them so each image is decoded once.
+	endPhase := stats.Phase("images")
+	if err := e.GenerateImages(ctx, allPosts); err != nil {
+		return err
+	}
+	endPhase()
+
+	// siteHash covers the inputs that every rendered template shares;
+	// the config, the partials, and the listing of all the posts.
+	siteHash, err := e.SiteHash(renderContext)
+	if err != nil {
+		return err
+	}
+
 	var defaultImagePostTemplate *template.Template
 	imagePostTemplatePath := e.Config.ImagePostTemplateOrDefault()
 	if imagePostTemplatePath != "" {
@@ -280,16 +428,23 @@ func (e Engine) Render(ctx context.Context) error {
 		}
 	}
 
-	posts := make(chan interface{}, len(renderContext.Data.Posts))
-	batchErrors := make(chan error, len(renderContext.Data.Posts))
-	for _, post := range renderContext.Data.Posts {
+	endPhase = stats.Phase("posts")
+	posts := make(chan interface{}, len(allPosts))
+	batchErrors := make(chan error, len(allPosts))
+	for _, post := range allPosts {
 		posts <- post
 	}
 	async.NewBatch(posts, func(ctx context.Context, workItem interface{}) error {
 		post := workItem.(*model.Post)
 
+		var err error
 		var postTemplate *template.Template
-		if post.Text.SourcePath != "" {
+		var postTemplatePath string
+		if post.Text.IsMarkdown() {
+			if post.Text.Template, post.Template, err = e.CompileMarkdown(post.Text.SourcePath); err != nil {
+				return ex.New(err)
+			}
+		} else if post.Text.SourcePath != "" {
 			if post.Text.Template, post.Template, err = e.CompileTemplate(post.Text.SourcePath, renderContext.Partials); err != nil {
 				return ex.New(err)
 			}
@@ -297,8 +452,10 @@ func (e Engine) Render(ctx context.Context) error {
 
 		if post.IsText() {
 			postTemplate = defaultTextPostTemplate
+			postTemplatePath = textPostTemplatePath
 		} else {
 			postTemplate = defaultImagePostTemplate
+			postTemplatePath = imagePostTemplatePath
 		}
 
 		slugPath := filepath.Join(outputPath, post.Slug)
@@ -307,29 +464,91 @@ func (e Engine) Render(ctx context.Context) error {
 		}
 
 		outputIndexPath := filepath.Join(slugPath, constants.FileIndex)
-		logger.MaybeDebugf(e.Log, "%s: processing page", outputIndexPath)
-		var postTextOutput string
-		if postTextOutput, err = e.RenderTemplateToFile(postTemplate, outputIndexPath, &model.ViewModel{
-			Config: e.Config,
-			Posts:  renderContext.Data.Posts,
-			Tags:   renderContext.Data.Tags,
-			Post:   *post,
-		}); err != nil {
+		pageHash := NewInputHash()
+		pageHash.AddString(siteHash)
+		if err := pageHash.AddFile(postTemplatePath); err != nil {
 			return err
 		}
-		if post.IsText() {
-			post.Text.Output = postTextOutput
+		if metaPath := filepath.Join(post.OriginalPath, constants.FileMeta); Exists(metaPath) {
+			if err := pageHash.AddFile(metaPath); err != nil {
+				return err
+			}
+		}
+		if post.Text.SourcePath != "" {
+			if err := pageHash.AddFile(post.Text.SourcePath); err != nil {
+				return err
+			}
+		}
+
+		if manifest.IsCurrent(outputPath, pageHash.Sum(), e.OutputKey(outputIndexPath)) {
+			logger.MaybeDebugf(e.Log, "%s: skipping unchanged page", outputIndexPath)
+			if post.IsText() {
+				contents, err := ioutil.ReadFile(outputIndexPath)
+				if err != nil {
+					return ex.New(err)
+				}
+				post.Text.Output = string(contents)
+			}
+		} else {
+			logger.MaybeDebugf(e.Log, "%s: processing page", outputIndexPath)
+			var postTextOutput string
+			if postTextOutput, err = e.RenderTemplateToFile(postTemplate, outputIndexPath, &model.ViewModel{
+				Config: e.Config,
+				Posts:  renderContext.Data.Posts,
+				Tags:   renderContext.Data.Tags,
+				Post:   *post,
+			}); err != nil {
+				return err
+			}
+			if post.IsText() {
+				post.Text.Output = postTextOutput
+			}
+			manifest.Record(pageHash.Sum(), pageHash.Inputs, e.OutputKey(outputIndexPath))
 		}
 
-		if post.Image.SourcePath != "" {
+		for _, image := range post.Images {
+			imageHash := NewInputHash()
+			etag, err := e.ImageETag(image.SourcePath)
+			if err != nil {
+				return err
+			}
+			imageHash.AddIndexedFile(image.SourcePath, etag)
+			if err := imageHash.AddValue(e.Config.ImageSizesOrDefault()); err != nil {
+				return err
+			}
+			if err := imageHash.AddValue(e.Config.ImageVariants); err != nil {
+				return err
+			}
+			if err := imageHash.AddValue(e.Config.ImageEncoding); err != nil {
+				return err
+			}
+			if err := imageHash.AddValue(e.Config.ImageSizeEncodings); err != nil {
+				return err
+			}
+			if err := imageHash.AddValue(e.Config.Metadata); err != nil {
+				return err
+			}
+			if err := imageHash.AddValue(image.Crops); err != nil {
+				return err
+			}
+			imagePath := filepath.Join(outputPath, image.OutputPath)
+			imageOutputs := e.ImageOutputKeys(image)
+			if manifest.IsCurrent(outputPath, imageHash.Sum(), imageOutputs...) {
+				logger.MaybeDebugf(e.Log, "%s: skipping unchanged images", imagePath)
+				continue
+			}
+			if err := MakeDir(imagePath); err != nil {
+				return ex.New(err)
+			}
 			if !e.Config.SkipCopyOriginalImage {
-				if err := e.CopyImageOriginal(ctx, post.Image.SourcePath, slugPath); err != nil {
+				if err := e.CopyImageOriginal(ctx, image, imagePath); err != nil {
 					return err
 				}
 			}
-			if err := e.ProcessThumbnails(ctx, post.Image.SourcePath, slugPath); err != nil {
+			if err := e.ProcessThumbnails(ctx, image, imagePath); err != nil {
 				return err
 			}
+			manifest.Record(imageHash.Sum(), imageHash.Inputs, imageOutputs...)
 		}
 		return nil
 	}, async.OptBatchParallelism(e.ParallelismOrDefault()), async.OptBatchErrors(batchErrors)).Process(ctx)
@@ -337,7 +556,9 @@ func (e Engine) Render(ctx context.Context) error {
 	if len(batchErrors) > 0 {
 		return <-batchErrors
 	}
+	endPhase()
 
+	endPhase = stats.Phase("site")
 	pagesPath := e.Config.PagesPathOrDefault()
 	pages, err := ListDirectory(pagesPath)
 	if err != nil {
@@ -347,19 +568,52 @@ func (e Engine) Render(ctx context.Context) error {
 		pageSourcePath := filepath.Join(pagesPath, page.Name())
 		pageOutputPath := filepath.Join(outputPath, page.Name())
 
+		pageHash := NewInputHash()
+		pageHash.AddString(siteHash)
+		if err := pageHash.AddFile(pageSourcePath); err != nil {
+			return err
+		}
+
+		// the index page is paginated, other pages are rendered once.
+		paginations := []model.Pagination{{}}
+		pageOutputPaths := []string{pageOutputPath}
+		if page.Name() == constants.FileIndex {
+			var paginationPaths []string
+			paginations, paginationPaths = e.Paginate("", renderContext.Data.Posts)
+			pageOutputPaths = pageOutputPaths[:0]
+			for _, paginationPath := range paginationPaths {
+				pageOutputPaths = append(pageOutputPaths, filepath.Join(outputPath, paginationPath, constants.FileIndex))
+			}
+		}
+		pageOutputKeys := make([]string, 0, len(pageOutputPaths))
+		for _, path := range pageOutputPaths {
+			pageOutputKeys = append(pageOutputKeys, e.OutputKey(path))
+		}
+		if manifest.IsCurrent(outputPath, pageHash.Sum(), pageOutputKeys...) {
+			logger.MaybeDebugf(e.Log, "%s: skipping unchanged page", pageOutputPath)
+			continue
+		}
+
 		logger.MaybeDebugf(e.Log, "%s: rendering page", pageOutputPath)
 		_, pageTemplate, err := e.CompileTemplate(pageSourcePath, renderContext.Partials)
 		if err != nil {
 			return err
 		}
-		if _, err := e.RenderTemplateToFile(pageTemplate, pageOutputPath, &model.ViewModel{
-			Config: e.Config,
-			Post:   model.Posts(renderContext.Data.Posts).First(),
-			Posts:  renderContext.Data.Posts,
-			Tags:   renderContext.Data.Tags,
-		}); err != nil {
-			return err
+		for index, pagination := range paginations {
+			if err := MakeDir(filepath.Dir(pageOutputPaths[index])); err != nil {
+				return ex.New(err)
+			}
+			if _, err := e.RenderTemplateToFile(pageTemplate, pageOutputPaths[index], &model.ViewModel{
+				Config:     e.Config,
+				Post:       model.Posts(renderContext.Data.Posts).First(),
+				Posts:      renderContext.Data.Posts,
+				Tags:       renderContext.Data.Tags,
+				Pagination: pagination,
+			}); err != nil {
+				return err
+			}
 		}
+		manifest.Record(pageHash.Sum(), pageHash.Inputs, pageOutputKeys...)
 	}
 
 	if !e.Config.SkipGenerateTags {
@@ -369,25 +623,66 @@ func (e Engine) Render(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
+			tagHash := NewInputHash()
+			tagHash.AddString(siteHash)
+			if err := tagHash.AddFile(tagTemplatePath); err != nil {
+				return err
+			}
 			for _, tag := range renderContext.Data.Tags {
-				tagPath := filepath.Join(outputPath, "tags", stringutil.Slugify(tag.Tag))
-				if err := MakeDir(tagPath); err != nil {
-					return ex.New(err)
+				paginations, paginationPaths := e.Paginate(filepath.Join("tags", stringutil.Slugify(tag.Tag)), tag.Posts)
+				var tagOutputKeys []string
+				for _, paginationPath := range paginationPaths {
+					tagOutputKeys = append(tagOutputKeys, e.OutputKey(filepath.Join(outputPath, paginationPath, constants.FileIndex)))
 				}
-				if _, err := e.RenderTemplateToFile(tagTemplate, filepath.Join(tagPath, constants.FileIndex), &model.ViewModel{
-					Config: e.Config,
-					Posts:  renderContext.Data.Posts,
-					Tags:   renderContext.Data.Tags,
-					Tag:    tag,
-				}); err != nil {
-					return err
+				if manifest.IsCurrent(outputPath, tagHash.Sum(), tagOutputKeys...) {
+					logger.MaybeDebugf(e.Log, "%s: skipping unchanged tag", tagOutputKeys[0])
+					continue
 				}
+				for index, pagination := range paginations {
+					tagPath := filepath.Join(outputPath, paginationPaths[index])
+					if err := MakeDir(tagPath); err != nil {
+						return ex.New(err)
+					}
+					if _, err := e.RenderTemplateToFile(tagTemplate, filepath.Join(tagPath, constants.FileIndex), &model.ViewModel{
+						Config:     e.Config,
+						Posts:      renderContext.Data.Posts,
+						Tags:       renderContext.Data.Tags,
+						Tag:        tag,
+						Pagination: pagination,
+					}); err != nil {
+						return err
+					}
+				}
+				manifest.Record(tagHash.Sum(), tagHash.Inputs, tagOutputKeys...)
 			}
 		}
 	}
 
-	staticPath := e.Config.StaticsPathOrDefault()
-	if err := Copy(staticPath, outputPath); err != nil {
+	if !e.Config.SkipGenerateFeeds {
+		if err := e.RenderFeeds(ctx, manifest); err != nil {
+			return err
+		}
+	}
+
+	if e.Config.Locations.Enabled {
+		if err := e.RenderLocations(ctx, manifest); err != nil {
+			return err
+		}
+	}
+
+	if !e.Config.SkipGenerateSitemap {
+		if err := e.RenderSitemap(ctx, manifest); err != nil {
+			return err
+		}
+	}
+
+	if !e.Config.SkipGenerateRobots {
+		if err := e.RenderRobots(manifest); err != nil {
+			return err
+		}
+	}
+
+	if err := e.CopyStatics(ctx, manifest); err != nil {
 		return err
 	}
 
@@ -397,16 +692,176 @@ func (e Engine) Render(ctx context.Context) error {
 		if err := e.WriteDataJSON(renderContext.Data, dataOutputPath); err != nil {
 			return err
 		}
+		manifest.Record(siteHash, nil, e.OutputKey(dataOutputPath))
 	}
+	endPhase()
 
 	return nil
 }
 
+// SiteHash returns the hash of the inputs shared by every rendered template.
+//
+// It includes the config, the partials, and the listing of the posts, that is
+// the slugs, the order, the metadata and the image details of every post.
+// It does not include the text post bodies, so editing the body of a text post
+// only re-renders that post.
+func (e Engine) SiteHash(renderContext *model.RenderContext) (string, error) {
+	siteHash := NewInputHash()
+	if err := siteHash.AddValue(e.Config); err != nil {
+		return "", err
+	}
+	for _, partial := range renderContext.Partials {
+		siteHash.AddString(partial)
+	}
+	for _, post := range renderContext.Data.Posts {
+		if err := siteHash.AddValue(struct {
+			Slug   string
+			Meta   model.Meta
+			Images []model.Image
+			Text   string
+		}{
+			Slug:   post.Slug,
+			Meta:   post.Meta,
+			Images: post.Images,
+			Text:   post.Text.SourcePath,
+		}); err != nil {
+			return "", err
+		}
+	}
+	return siteHash.Sum(), nil
+}
+
+// CopyStatics copies the static files to the output path.
+// Files that are unchanged since the last build are skipped.
+func (e Engine) CopyStatics(ctx context.Context, manifest *Manifest) error {
+	staticPath := e.Config.StaticsPathOrDefault()
+	outputPath := e.Config.OutputPathOrDefault()
+	if !Exists(staticPath) {
+		return nil
+	}
+	return ex.New(filepath.Walk(staticPath, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(staticPath, currentPath)
+		if err != nil {
+			return err
+		}
+		staticOutputPath := filepath.Join(outputPath, relativePath)
+
+		staticHash := NewInputHash()
+		if err := staticHash.AddFile(currentPath); err != nil {
+			return err
+		}
+		if manifest.IsCurrent(outputPath, staticHash.Sum(), e.OutputKey(staticOutputPath)) {
+			return nil
+		}
+		logger.MaybeDebugf(e.Log, "%s: copying static file", staticOutputPath)
+		if err := Copy(currentPath, staticOutputPath); err != nil {
+			return err
+		}
+		manifest.Record(staticHash.Sum(), staticHash.Inputs, e.OutputKey(staticOutputPath))
+		return nil
+	}))
+}
+
+// OutputKey returns the key for an output file in the build manifest,
+// that is the path relative to the output path.
+func (e Engine) OutputKey(path string) string {
+	relativePath, err := filepath.Rel(e.Config.OutputPathOrDefault(), path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(relativePath)
+}
+
+// ImageOutputKeys returns the build manifest keys for the outputs of an image.
+func (e Engine) ImageOutputKeys(image model.Image) (output []string) {
+	if !e.Config.SkipCopyOriginalImage {
+		output = append(output, filepath.ToSlash(image.PathOriginal()))
+	}
+	for _, size := range e.Config.ImageSizesOrDefault() {
+		output = append(output, filepath.ToSlash(image.PathForSize(size)))
+	}
+	for _, variant := range e.Config.ImageVariants {
+		output = append(output, filepath.ToSlash(ImageVariantPath(image, variant)))
+	}
+	return
+}
+
+// ReadBuildManifest reads the build manifest from the previous build.
+// It returns an empty manifest if there is no manifest, if the manifest was written
+// by a different version of the manifest format, or if the engine is set to rebuild.
+func (e Engine) ReadBuildManifest() (output model.Manifest, err error) {
+	manifestPath := e.Config.BuildManifestPathOrDefault()
+	if e.Rebuild || !Exists(manifestPath) {
+		return
+	}
+	var f *os.File
+	f, err = os.Open(manifestPath)
+	if err != nil {
+		err = ex.New(err)
+		return
+	}
+	defer f.Close()
+	if err = json.NewDecoder(f).Decode(&output); err != nil {
+		err = ex.New(err).WithMessagef("build manifest path: %s", manifestPath)
+		return
+	}
+	if output.Version != constants.BuildManifestVersion {
+		logger.MaybeInfof(e.Log, "%s: ignoring build manifest with version %q", manifestPath, output.Version)
+		output = model.Manifest{}
+	}
+	return
+}
+
+// WriteBuildManifest writes the build manifest to disk.
+func (e Engine) WriteBuildManifest(manifest model.Manifest) error {
+	manifest.Version = constants.BuildManifestVersion
+	f, err := os.Create(e.Config.BuildManifestPathOrDefault())
+	if err != nil {
+		return ex.New(err)
+	}
+	defer f.Close()
+	return ex.New(json.NewEncoder(f).Encode(manifest))
+}
+
+// PruneOutputs removes outputs from the previous build that the current build did not produce,
+// along with any directories left empty as a result.
+func (e Engine) PruneOutputs(ctx context.Context, manifest *Manifest) error {
+	outputPath := filepath.Clean(e.Config.OutputPathOrDefault())
+	for _, stale := range manifest.Stale() {
+		stalePath := filepath.Join(outputPath, filepath.FromSlash(stale))
+		if e.DryRun {
+			logger.MaybeInfof(e.Log, "%s: (dry-run) would prune stale output", stalePath)
+			continue
+		}
+		logger.MaybeDebugf(e.Log, "%s: pruning stale output", stalePath)
+		if err := os.Remove(stalePath); err != nil && !os.IsNotExist(err) {
+			return ex.New(err)
+		}
+		for dir := filepath.Dir(stalePath); dir != outputPath && strings.HasPrefix(dir, outputPath); dir = filepath.Dir(dir) {
+			if files, err := ioutil.ReadDir(dir); err != nil || len(files) > 0 {
+				break
+			}
+			if err := os.Remove(dir); err != nil {
+				return ex.New(err)
+			}
+		}
+	}
+	return nil
+}
+
 // CleanThumbnailCache cleans the thumbnail cache by purging cached thumbnails for posts that may have been deleted.
+// Images that are no longer in any post are also removed from the source index.
 func (e Engine) CleanThumbnailCache(ctx context.Context) error {
 	postsPath := e.Config.PostsPathOrDefault()
 	logger.MaybeInfof(e.Log, "%s: searching for posts", postsPath)
 	postSums := map[string]bool{}
+	imagePaths := map[string]bool{}
 	err := filepath.Walk(postsPath, func(currentPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -422,16 +877,15 @@ func (e Engine) CleanThumbnailCache(ctx context.Context) error {
 			for _, fi := range files {
 				name := fi.Name()
 				if HasExtension(name, constants.ImageExtensions...) {
-					contents, err := ioutil.ReadFile(filepath.Join(currentPath, name))
-					if err != nil {
-						return ex.New(err)
-					}
-					etag, err := fileutil.ETag(contents)
+					imagePath := filepath.Join(currentPath, name)
+					entry, err := e.IndexedImage(imagePath)
 					if err != nil {
 						return err
 					}
-
-					postSums[etag] = true
+					postSums[entry.ETag] = true
+					// oriented images are cached by their etag and orientation.
+					postSums[ThumbnailCacheKey(entry.Image(imagePath), entry.ETag)] = true
+					imagePaths[filepath.Clean(imagePath)] = true
 				}
 			}
 		}
@@ -441,37 +895,47 @@ func (e Engine) CleanThumbnailCache(ctx context.Context) error {
 		return ex.New(err)
 	}
 
+	e.SourceIndex.Prune(imagePaths)
+	if err := e.SaveSourceIndex(); err != nil {
+		return err
+	}
+
 	thumbnailCachePath := e.Config.ThumbnailCachePathOrDefault()
 	logger.MaybeInfof(e.Log, "%s: comparing as thumbnail cache", thumbnailCachePath)
 
-	// for each thumbnail cache folder
+	// the keys of the cache storage are compared rather than the thumbnail cache path,
+	// so shared caches are cleaned along with it.
+	storage := e.CacheStorage
+	if storage == nil {
+		storage = LocalCacheStorage{Path: thumbnailCachePath}
+	}
+	keys, err := storage.Keys(ctx)
+	if err != nil {
+		return err
+	}
+	orphanedKeys := make(map[string][]string)
 	var orphanedCachedPosts []string
-	err = filepath.Walk(thumbnailCachePath, func(currentPath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	for _, key := range keys {
+		name := cacheKeyDirectory(key)
+		// see if there is a matching sha'd image
+		if name == "" || postSums[name] {
+			continue
 		}
-		if currentPath == thumbnailCachePath {
-			return nil
-		}
-		if info.IsDir() {
-			name := info.Name()
-			// see if there is a matching sha'd image
-			if _, ok := postSums[name]; !ok {
-				orphanedCachedPosts = append(orphanedCachedPosts, name)
-			}
+		if _, ok := orphanedKeys[name]; !ok {
+			orphanedCachedPosts = append(orphanedCachedPosts, name)
 		}
-		return nil
-	})
-	if err != nil {
-		return ex.New(err)
+		orphanedKeys[name] = append(orphanedKeys[name], key)
 	}
+	sort.Strings(orphanedCachedPosts)
 
 	// purge folders
 	if len(orphanedCachedPosts) > 0 {
 		for _, path := range orphanedCachedPosts {
 			if !e.DryRun {
-				if err := os.RemoveAll(filepath.Join(thumbnailCachePath, path)); err != nil {
-					return ex.New(err)
+				for _, key := range orphanedKeys[path] {
+					if err := storage.Remove(ctx, key); err != nil {
+						return err
+					}
 				}
 				logger.MaybeInfof(e.Log, "%s: purging orphaned cached directory", path)
 			} else {
@@ -522,10 +986,12 @@ func (e Engine) GeneratePost(ctx context.Context, slugTemplate *template.Templat
 	}
 
 	post := model.Post{
-		Index: postIndex,
+		OriginalPath: path,
+		Index:        postIndex,
 	}
 
 	var postModTime time.Time
+	var imageFiles []string
 	for _, fi := range files {
 		name := fi.Name()
 		if strings.ToLower(name) == constants.FileMeta {
@@ -533,17 +999,11 @@ func (e Engine) GeneratePost(ctx context.Context, slugTemplate *template.Templat
 				return nil, err
 			}
 		} else if HasExtension(name, constants.ImageExtensions...) {
-			if post.Image.SourcePath != "" {
-				return nil, ex.New("multiple image files found in post directory", ex.OptMessage(path))
-			}
-			post.Image.SourcePath = filepath.Join(path, name)
+			imageFiles = append(imageFiles, name)
 			if postModTime.Before(fi.ModTime()) {
 				postModTime = fi.ModTime()
 			}
-			if post.Image, err = ReadImage(post.Image.SourcePath); err != nil {
-				return nil, err
-			}
-		} else if HasExtension(name, constants.TemplateExtensions...) {
+		} else if HasExtension(name, constants.TemplateExtensions...) || HasExtension(strings.ToLower(name), constants.MarkdownExtensions...) {
 			if post.Text.SourcePath != "" {
 				return nil, ex.New("multiple text files found in post directory", ex.OptMessage(path))
 			}
@@ -556,13 +1016,45 @@ func (e Engine) GeneratePost(ctx context.Context, slugTemplate *template.Templat
 		}
 	}
 
+	// read the front matter after any `meta.yml` so the front matter takes precedence.
+	if post.Text.IsMarkdown() {
+		if err := ReadFrontMatter(post.Text.SourcePath, &post.Meta); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(imageFiles) > 0 {
+		if post.Images, err = e.ReadImages(path, imageFiles, post.Meta.Images); err != nil {
+			return nil, err
+		}
+		for index := range post.Images {
+			post.Images[index].Exif = e.LocateImage(post.Meta, post.Images[index].Exif)
+			if post.Images[index].Focus == nil {
+				post.Images[index].Focus = post.Meta.Focus
+			}
+			if focus := post.Images[index].Focus; focus != nil && !focus.IsValid() {
+				return nil, ex.New("image focus must be two fractions between 0 and 1", ex.OptMessagef("image path: %s", post.Images[index].SourcePath))
+			}
+		}
+		post.Image = post.Images[0]
+	}
+
+	// scheduled posts are posted when they're published unless they say otherwise.
+	if post.Meta.Posted.IsZero() {
+		post.Meta.Posted = post.Meta.PublishAt
+	}
+
 	post.Slug = e.CreateSlug(slugTemplate, post)
 	post.ModTime = postModTime
 	if post.Meta.Posted.IsZero() {
 		post.Meta.Posted = postModTime
 	}
-	if post.IsImage() {
-		post.Image.Sizes = e.GetImageSizePaths(post)
+	for index := range post.Images {
+		post.Images[index].OutputPath = ImageOutputPath(post.Slug, index)
+		post.Images[index].Sizes = e.GetImageSizePaths(post.Images[index])
+	}
+	if len(post.Images) > 0 {
+		post.Image = post.Images[0]
 	}
 	if post.Image.SourcePath == "" && post.Text.SourcePath == "" {
 		return nil, ex.New("no image or text post data found", ex.OptMessage(path))
@@ -570,26 +1062,82 @@ func (e Engine) GeneratePost(ctx context.Context, slugTemplate *template.Templat
 	return &post, nil
 }
 
+// ReadImages reads the images of a post, ordered by the image meta,
+// with any images that aren't in the image meta following in filename order.
+func (e Engine) ReadImages(postPath string, imageFiles []string, imageMeta []model.ImageMeta) ([]model.Image, error) {
+	sort.Strings(imageFiles)
+	found := make(map[string]bool)
+	for _, file := range imageFiles {
+		found[file] = true
+	}
+
+	var ordered []model.ImageMeta
+	listed := make(map[string]bool)
+	for _, meta := range imageMeta {
+		if !found[meta.File] {
+			return nil, ex.New("image in meta not found in post directory", ex.OptMessagef("%s: %s", postPath, meta.File))
+		}
+		if listed[meta.File] {
+			return nil, ex.New("image listed more than once in meta", ex.OptMessagef("%s: %s", postPath, meta.File))
+		}
+		listed[meta.File] = true
+		ordered = append(ordered, meta)
+	}
+	for _, file := range imageFiles {
+		if !listed[file] {
+			ordered = append(ordered, model.ImageMeta{File: file})
+		}
+	}
+
+	output := make([]model.Image, 0, len(ordered))
+	for _, meta := range ordered {
+		image, err := e.ReadIndexedImage(filepath.Join(postPath, meta.File))
+		if err != nil {
+			return nil, ex.New(err).WithMessagef("image path: %s", filepath.Join(postPath, meta.File))
+		}
+		image.Caption = meta.Caption
+		image.Alt = meta.Alt
+		image.Focus = meta.Focus
+		output = append(output, image)
+	}
+	return output, nil
+}
+
+// ImageOutputPath returns the directory an image of a post is written to, relative to the output path.
+//
+// The first image is written to the post's slug, so single image posts and the cover image
+// of galleries share the same paths; later images are written to numbered directories
+// under the slug starting at 2, e.g. `<slug>/2/1024.jpg`.
+func ImageOutputPath(slug string, index int) string {
+	if index == 0 {
+		return slug
+	}
+	return filepath.Join(slug, strconv.Itoa(index+1))
+}
+
 // ProcessThumbnails processes thumbnails.
-func (e Engine) ProcessThumbnails(ctx context.Context, originalFilePath, destinationPath string) error {
-	originalContents, err := ioutil.ReadFile(originalFilePath)
+//
+// The image is only read if any of its thumbnails aren't cached.
+func (e Engine) ProcessThumbnails(ctx context.Context, original model.Image, destinationPath string) error {
+	etag, err := e.ImageETag(original.SourcePath)
 	if err != nil {
-		return ex.New(err)
+		return err
 	}
 
-	etag, err := fileutil.ETag(originalContents)
+	missing, err := e.MissingThumbnails(ctx, original, etag)
 	if err != nil {
 		return err
 	}
-
-	if e.ShouldGenerateThumbnails(etag) {
-		logger.MaybeInfof(e.Log, "%s: generating thumbnails", originalFilePath)
-		if err := e.GenerateThumbnails(originalContents, originalFilePath, etag); err != nil {
-			return nil
+	if !missing.IsZero() {
+		logger.MaybeInfof(e.Log, "%s: generating thumbnails", original.SourcePath)
+		source := e.NewSourceImage(ctx, original)
+		defer source.Close()
+		if err := e.GenerateThumbnails(ctx, source, etag, missing); err != nil {
+			return err
 		}
 	}
 
-	if err := e.CopyThumbnails(etag, destinationPath); err != nil {
+	if err := e.CopyThumbnails(original, etag, destinationPath); err != nil {
 		return err
 	}
 
@@ -631,81 +1179,367 @@ func (e Engine) WriteDataJSON(data *model.Data, path string) error {
 	return ex.New(json.NewEncoder(f).Encode(data))
 }
 
-// GenerateThumbnails generates and copies our main thumbnails for the post image.
-// - originalContents should be the bytes of the original image file
+// GenerateThumbnails generates the thumbnails and image variants of a post image that are missing.
+// - source is the source of the original image, which is decoded once for every thumbnail
 // - etag should be the sha sum as an etag, it is used as a path component in the file cache
-func (e Engine) GenerateThumbnails(originalContents []byte, originalImagePath, etag string) error {
-	// decode jpeg into image.Image
-	original, err := jpeg.Decode(bytes.NewBuffer(originalContents))
-	if err != nil {
-		ex.New(err)
-	}
-
-	for _, size := range e.Config.ImageSizesOrDefault() {
-		if err := e.GenerateThumbnail(original, size, originalImagePath, etag); err != nil {
+// - missing are the thumbnails and image variants that aren't cached, from `MissingThumbnails`
+//
+// Each size is downscaled from the next larger size rather than the original, e.g. 512px from
+// 1024px from 2048px, and is sharpened, encoded and written in parallel with the other sizes.
+// The image variants are generated along with the thumbnails; variants of animated gifs are of the first frame.
+func (e Engine) GenerateThumbnails(ctx context.Context, source *SourceImage, etag string, missing MissingThumbnails) error {
+	original := source.Original
+
+	// animated gifs keep their animation unless the config says otherwise.
+	var animated bool
+	if original.Format == constants.ImageFormatGIF && !e.Config.SkipGIFAnimation && len(missing.Sizes) > 0 {
+		animation, err := source.DecodeAnimation()
+		if err != nil {
 			return err
 		}
+		if len(animation.Image) > 1 {
+			animated = true
+			for _, size := range missing.Sizes {
+				if err := e.GenerateAnimatedThumbnail(ctx, animation, size, original, etag); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if animated && len(missing.Variants) == 0 {
+		return nil
 	}
-	return nil
-}
 
-// GenerateThumbnail generates a thumbnail and stores it in the cache if it doesn't exist
-// and copies the cached thumbail to the output directory.
-func (e Engine) GenerateThumbnail(original image.Image, size int, imagePath, etag string) error {
-	// if the cached version doesnt exist, generate it
-	// copy over the cached version
-	thumbnailCachePath := e.Config.ThumbnailCachePathOrDefault()
-	thumbnailPath := filepath.Join(thumbnailCachePath, etag, fmt.Sprintf("%d.jpg", size))
-	if !Exists(thumbnailPath) {
-		logger.MaybeDebugf(e.Log, "%s: generating cached thumbnail @ %dpx", imagePath, size)
-		if err := MakeDir(filepath.Join(thumbnailCachePath, etag)); err != nil {
-			return err
-		}
-		if err := e.Resize(original, thumbnailPath, uint(size)); err != nil {
-			return err
+	// decode the image (or the first frame of a gif) into image.Image
+	decoded, err := source.Decode()
+	if err != nil {
+		return err
+	}
+	originalContents, err := source.Contents()
+	if err != nil {
+		return err
+	}
+	metadata := GetThumbnailMetadata(originalContents, original)
+
+	var wg sync.WaitGroup
+	generateErrors := make(chan error, len(missing.Sizes)+len(missing.Variants))
+	generate := func(action func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := action(); err != nil {
+				generateErrors <- err
+			}
+		}()
+	}
+	if !animated {
+		thumbnail := decoded
+		for _, size := range missing.Sizes {
+			size, encoding := size, e.Config.ImageEncodingForSize(size)
+			started := time.Now()
+			thumbnail = DownscaleImage(thumbnail, decoded.Bounds(), size, encoding)
+			source.Stats.Work("resize", started)
+
+			thumbnail := thumbnail
+			generate(func() error {
+				logger.MaybeDebugf(e.Log, "%s: generating cached thumbnail @ %dpx", original.SourcePath, size)
+				defer source.Stats.Work("encode", time.Now())
+				return e.GenerateThumbnail(ctx, thumbnail, e.ThumbnailCachePath(original, etag, size), original.Exif.Orientation, encoding, metadata)
+			})
 		}
 	}
+	for _, variant := range missing.Variants {
+		variant := variant
+		generate(func() error {
+			defer source.Stats.Work("variants", time.Now())
+			return e.GenerateImageVariant(ctx, source, etag, variant, metadata)
+		})
+	}
+	wg.Wait()
+	close(generateErrors)
+	return <-generateErrors
+}
 
-	return nil
+// MissingThumbnails are the thumbnails and image variants of an image that aren't cached.
+type MissingThumbnails struct {
+	// Sizes are the sizes of the thumbnails, largest first.
+	Sizes    []int
+	Variants []config.ImageVariant
 }
 
-// ShouldGenerateThumbnails returns if we should process any thumbnais for a given etag.
-func (e Engine) ShouldGenerateThumbnails(etag string) bool {
-	thumbnailCachePath := e.Config.ThumbnailCachePathOrDefault()
+// IsZero returns if every thumbnail and image variant is cached.
+func (mt MissingThumbnails) IsZero() bool {
+	return len(mt.Sizes) == 0 && len(mt.Variants) == 0
+}
+
+// MissingThumbnails returns the thumbnails and image variants of an image that aren't cached.
+//
+// Files that aren't in the thumbnail cache path are fetched from the cache storage if it's shared,
+// once each, so the missing files can be generated without looking them up again.
+func (e Engine) MissingThumbnails(ctx context.Context, original model.Image, etag string) (missing MissingThumbnails, err error) {
 	for _, size := range e.Config.ImageSizesOrDefault() {
-		thumbnailPath := filepath.Join(thumbnailCachePath, etag, fmt.Sprintf(constants.ImageSizeFormat, size))
-		if !Exists(thumbnailPath) {
-			return true
+		cached, err := e.IsCached(ctx, e.ThumbnailCachePath(original, etag, size))
+		if err != nil {
+			return MissingThumbnails{}, err
 		}
+		if !cached {
+			missing.Sizes = append(missing.Sizes, size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(missing.Sizes)))
+	for _, variant := range e.Config.ImageVariants {
+		cached, err := e.IsCached(ctx, e.ImageVariantCachePath(original, etag, variant))
+		if err != nil {
+			return MissingThumbnails{}, err
+		}
+		if !cached {
+			missing.Variants = append(missing.Variants, variant)
+		}
+	}
+	return
+}
+
+// DownscaleImage downscales an image to a thumbnail size of an image with given bounds,
+// with the interpolation filter of the image encoding options.
+//
+// The image can be the image with the bounds or a thumbnail of it larger than the size,
+// so thumbnails can be downscaled progressively with the same dimensions.
+func DownscaleImage(img image.Image, originalBounds image.Rectangle, size int, encoding config.ImageEncoding) image.Image {
+	width, height := resize.ThumbnailDimensions(uint(size), uint(size), uint(originalBounds.Dx()), uint(originalBounds.Dy()))
+	if bounds := img.Bounds(); int(width) >= bounds.Dx() && int(height) >= bounds.Dy() {
+		return img
 	}
-	return false
+	return resize.Resize(width, height, img, ImageFilter(encoding))
+}
+
+// GenerateThumbnail sharpens a downscaled image per the image encoding options, rotates or flips
+// it per the exif orientation so it is upright, and writes it to a destination in the thumbnail
+// cache, encoded per the destination extension and the image encoding options with the metadata of the image.
+func (e Engine) GenerateThumbnail(ctx context.Context, thumbnail image.Image, destination string, orientation int, encoding config.ImageEncoding, metadata ThumbnailMetadata) error {
+	thumbnail = metadata.ConvertColorProfile(Orient(SharpenImage(thumbnail, encoding), orientation), encoding)
+	// write new image to file
+	return e.WriteCached(ctx, destination, func(out io.Writer) error {
+		return EncodeThumbnail(out, thumbnail, filepath.Ext(destination), encoding, metadata)
+	})
 }
 
-// Resize resizes an image to a destination.
-func (e Engine) Resize(original image.Image, destination string, maxDimension uint) error {
-	resized := resize.Thumbnail(maxDimension, maxDimension, original, resize.Bicubic)
-	out, err := os.Create(destination)
+// WriteCached writes a file to the thumbnail cache and stores it in the cache storage.
+// The file is removed if it can't be written, so it isn't taken as cached.
+func (e Engine) WriteCached(ctx context.Context, cachePath string, write func(io.Writer) error) error {
+	if err := MakeDir(filepath.Dir(cachePath)); err != nil {
+		return err
+	}
+	out, err := os.Create(cachePath)
 	if err != nil {
 		return ex.New(err)
 	}
-	defer out.Close()
-	// write new image to file
-	if err := jpeg.Encode(out, resized, nil); err != nil {
+	if err := write(out); err != nil {
+		out.Close()
+		os.Remove(cachePath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(cachePath)
 		return ex.New(err)
 	}
+	return e.StoreCached(ctx, cachePath)
+}
+
+// GenerateAnimatedThumbnail generates an animated gif thumbnail and stores it in the cache.
+func (e Engine) GenerateAnimatedThumbnail(ctx context.Context, animation *gif.GIF, size int, original model.Image, etag string) error {
+	thumbnailPath := e.ThumbnailCachePath(original, etag, size)
+	logger.MaybeDebugf(e.Log, "%s: generating cached animated thumbnail @ %dpx", original.SourcePath, size)
+	return e.WriteCached(ctx, thumbnailPath, func(out io.Writer) error {
+		return ex.New(gif.EncodeAll(out, ResizeAnimation(animation, uint(size), ImageFilter(e.Config.ImageEncodingForSize(size)))))
+	})
+}
+
+// ThumbnailCachePath returns the path of a thumbnail of an image in the thumbnail cache.
+//
+// Thumbnails with image encoding options other than the defaults include a hash of
+// the options in the file name, so changing them regenerates the thumbnails.
+func (e Engine) ThumbnailCachePath(original model.Image, etag string, size int) string {
+	name := fmt.Sprintf(constants.ImageSizeFormat, size, original.ThumbnailExtension())
+	if encoding := e.Config.ImageEncodingForSize(size); !encoding.IsDefault() {
+		name = fmt.Sprintf(constants.ImageSizeEncodingFormat, size, ImageEncodingHash(encoding), original.ThumbnailExtension())
+	}
+	return filepath.Join(e.Config.ThumbnailCachePathOrDefault(), ThumbnailCacheKey(original, etag), name)
+}
+
+// ImageCachePath returns the path of a file of data computed from an image in the thumbnail cache.
+func (e Engine) ImageCachePath(original model.Image, etag, name string) string {
+	return filepath.Join(e.Config.ThumbnailCachePathOrDefault(), ThumbnailCacheKey(original, etag), name)
+}
+
+// CachedImageData reads data computed from an image, like its placeholder, from
+// a json file in the thumbnail cache into the output.
+//
+// If the file isn't cached, generate is called to set the output, and the output is cached.
+// The file is kept next to the thumbnails of the image, so the data is only computed
+// once per version of it.
+func (e Engine) CachedImageData(ctx context.Context, original model.Image, name string, output interface{}, generate func() error) error {
+	etag, err := e.ImageETag(original.SourcePath)
+	if err != nil {
+		return err
+	}
+
+	cachePath := e.ImageCachePath(original, etag, name)
+	cached, err := e.IsCached(ctx, cachePath)
+	if err != nil {
+		return err
+	}
+	if cached {
+		contents, err := ioutil.ReadFile(cachePath)
+		if err != nil {
+			return ex.New(err)
+		}
+		if err := json.Unmarshal(contents, output); err != nil {
+			return ex.New(err).WithMessagef("cache path: %s", cachePath)
+		}
+		return nil
+	}
+
+	if err := generate(); err != nil {
+		return err
+	}
+	if err := MakeDir(filepath.Dir(cachePath)); err != nil {
+		return err
+	}
+	if err := WriteJSON(cachePath, output); err != nil {
+		return err
+	}
+	return e.StoreCached(ctx, cachePath)
+}
+
+// ProcessImages calls a function for every image of the posts, in parallel by post.
+//
+// The cover image of each post is updated after its images are processed.
+func (e Engine) ProcessImages(ctx context.Context, allPosts []*model.Post, action func(*model.Image) error) error {
+	posts := make(chan interface{}, len(allPosts))
+	batchErrors := make(chan error, len(allPosts))
+	for _, post := range allPosts {
+		posts <- post
+	}
+	async.NewBatch(posts, func(ctx context.Context, workItem interface{}) error {
+		post := workItem.(*model.Post)
+		for index := range post.Images {
+			if err := action(&post.Images[index]); err != nil {
+				return err
+			}
+		}
+		if len(post.Images) > 0 {
+			post.Image = post.Images[0]
+		}
+		return nil
+	}, async.OptBatchParallelism(e.ParallelismOrDefault()), async.OptBatchErrors(batchErrors)).Process(ctx)
+
+	if len(batchErrors) > 0 {
+		return <-batchErrors
+	}
 	return nil
 }
 
+// ProcessSourceImages calls a function for every image of the posts with its source, in parallel
+// by post, like `ProcessImages`. The source of each image is closed after the function returns.
+func (e Engine) ProcessSourceImages(ctx context.Context, allPosts []*model.Post, action func(*model.Image, *SourceImage) error) error {
+	return e.ProcessImages(ctx, allPosts, func(image *model.Image) error {
+		source := e.NewSourceImage(ctx, *image)
+		defer source.Close()
+		return action(image, source)
+	})
+}
+
+// GenerateImages sets the crops, placeholders and palettes of every image of the posts,
+// and generates their cached thumbnails, decoding each image at most once for all of them.
+//
+// Images are only decoded if something computed from them isn't cached, and only as many
+// are decoded at once as fit in the memory budget.
+func (e Engine) GenerateImages(ctx context.Context, allPosts []*model.Post) error {
+	return e.ProcessSourceImages(ctx, allPosts, func(image *model.Image, source *SourceImage) (err error) {
+		// crops are first, as the image variants use them.
+		if image.Crops, err = e.ImageCrops(ctx, source); err != nil {
+			return
+		}
+		source.Original = *image
+		if !e.Config.SkipGeneratePlaceholders {
+			placeholder, err := e.ImagePlaceholder(ctx, source)
+			if err != nil {
+				return err
+			}
+			image.BlurHash = placeholder.BlurHash
+			image.Placeholder = placeholder.DataURI
+		}
+		if !e.Config.SkipGeneratePalettes {
+			imagePalette, err := e.ImagePalette(ctx, source)
+			if err != nil {
+				return err
+			}
+			if len(imagePalette.Colors) > 0 {
+				image.Color = imagePalette.Colors[0]
+			}
+			image.ColorName = imagePalette.Name
+			image.Palette = imagePalette.Colors
+		}
+
+		etag, err := e.ImageETag(image.SourcePath)
+		if err != nil {
+			return err
+		}
+		missing, err := e.MissingThumbnails(ctx, *image, etag)
+		if err != nil {
+			return err
+		}
+		if !missing.IsZero() {
+			logger.MaybeInfof(e.Log, "%s: generating thumbnails", image.SourcePath)
+			return e.GenerateThumbnails(ctx, source, etag, missing)
+		}
+		return nil
+	})
+}
+
+// ThumbnailCacheKey returns the thumbnail cache directory for an image with a given etag.
+//
+// Images that are rotated or flipped per their exif orientation include the
+// orientation in the key, as thumbnails cached before orientation was applied are wrong.
+func ThumbnailCacheKey(original model.Image, etag string) string {
+	if original.Exif.IsOriented() {
+		return fmt.Sprintf("%s-o%d", etag, original.Exif.Orientation)
+	}
+	return etag
+}
+
 // CopyImageOriginal copies the original image to the destination.
-func (e Engine) CopyImageOriginal(ctx context.Context, originalPath, destinationPath string) error {
-	logger.MaybeDebugf(e.Log, "%s: copying original image", destinationPath)
-	return Copy(originalPath, filepath.Join(destinationPath, constants.FileImageOriginal))
+//
+// Unless the metadata config keeps everything, the metadata of the original is rewritten
+// per the metadata policy and the privacy zones; the image data is copied as is.
+func (e Engine) CopyImageOriginal(ctx context.Context, original model.Image, destinationPath string) error {
+	outputPath := filepath.Join(destinationPath, fmt.Sprintf(constants.ImageOriginalFormat, original.OriginalExtension()))
+	if e.Config.Metadata.IsKeepAll() {
+		logger.MaybeDebugf(e.Log, "%s: copying original image", destinationPath)
+		return Copy(original.SourcePath, outputPath)
+	}
+
+	logger.MaybeDebugf(e.Log, "%s: copying original image with rewritten metadata", destinationPath)
+	contents, err := ioutil.ReadFile(original.SourcePath)
+	if err != nil {
+		return ex.New(err)
+	}
+	rewritten, err := e.RewriteMetadata(original.Format, contents)
+	if err != nil {
+		return ex.New(err).WithMessagef("image path: %s", original.SourcePath)
+	}
+	return WriteFile(outputPath, rewritten)
 }
 
-// CopyThumbnails copies all thumbnails to the destination path by etag from the thumbnail cache.
-func (e Engine) CopyThumbnails(etag, destinationPath string) error {
+// CopyThumbnails copies all thumbnails and image variants to the destination path by etag from the thumbnail cache.
+func (e Engine) CopyThumbnails(original model.Image, etag, destinationPath string) error {
 	for _, size := range e.Config.ImageSizesOrDefault() {
-		if err := e.CopyThumbnail(etag, destinationPath, size); err != nil {
+		if err := e.CopyThumbnail(original, etag, destinationPath, size); err != nil {
+			return err
+		}
+	}
+	for _, variant := range e.Config.ImageVariants {
+		if err := e.CopyImageVariant(original, etag, destinationPath, variant); err != nil {
 			return err
 		}
 	}
@@ -713,10 +1547,9 @@ func (e Engine) CopyThumbnails(etag, destinationPath string) error {
 }
 
 // CopyThumbnail copies a cached thumbnail to the output directory.
-func (e Engine) CopyThumbnail(etag, destinationPath string, size int) error {
-	thumbnailCachePath := e.Config.ThumbnailCachePathOrDefault()
-	thumbnailPath := filepath.Join(thumbnailCachePath, etag, fmt.Sprintf("%d.jpg", size))
-	outputPath := filepath.Join(destinationPath, fmt.Sprintf(constants.ImageSizeFormat, size))
+func (e Engine) CopyThumbnail(original model.Image, etag, destinationPath string, size int) error {
+	thumbnailPath := e.ThumbnailCachePath(original, etag, size)
+	outputPath := filepath.Join(destinationPath, fmt.Sprintf(constants.ImageSizeFormat, size, original.ThumbnailExtension()))
 	logger.MaybeDebugf(e.Log, "%s: copying cached thumbnail @ %dpx", destinationPath, size)
 	if err := Copy(thumbnailPath, outputPath); err != nil {
 		return err
@@ -749,13 +1582,16 @@ func (e Engine) CreateSlug(slugTemplate *template.Template, p model.Post) string
 }
 
 // GetImageSizePaths gets the map that corresponds to the image sizes and the image path.
-func (e Engine) GetImageSizePaths(post model.Post) map[string]string {
+func (e Engine) GetImageSizePaths(image model.Image) map[string]string {
 	output := make(map[string]string)
 	if !e.Config.SkipCopyOriginalImage {
-		output["original"] = filepath.Join(post.Slug, constants.FileImageOriginal)
+		output[constants.ImageOriginal] = image.PathOriginal()
 	}
 	for _, size := range e.Config.ImageSizesOrDefault() {
-		output[strconv.Itoa(size)] = filepath.Join(post.Slug, fmt.Sprintf("%d.jpg", size))
+		output[strconv.Itoa(size)] = image.PathForSize(size)
+	}
+	for _, variant := range e.Config.ImageVariants {
+		output[variant.Name] = ImageVariantPath(image, variant)
 	}
 	return output
 }
@@ -3,7 +3,13 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"image"
+	"image/gif"
+	"io/ioutil"
+	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,7 +17,11 @@ import (
 	"github.com/blend/go-sdk/ref"
 
 	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/feed"
+	"github.com/wcharczuk/blogctl/pkg/geojson"
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
 	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/sitemap"
 )
 
 func TestEngineCreateSlugDefaults(t *testing.T) {
@@ -46,16 +56,19 @@ func TestEngineBuild(t *testing.T) {
 	assert.Nil(os.Chdir("testdata"))
 
 	defer func() {
-		os.Remove("thumbnails")
-		os.Remove("dist")
+		os.RemoveAll("thumbnails")
+		os.RemoveAll("dist")
+		os.Remove("manifest.json")
+		os.Chdir("..")
 	}()
 
-	cfg, path, err := config.ReadConfig(config.Flags{
+	cfg, paths, err := config.ReadConfig(config.Flags{
 		ConfigPath:  ref.String("./config.yml"),
 		Parallelism: ref.Int(4),
 	})
 	assert.Nil(err)
-	assert.Equal("./config.yml", path)
+	assert.NotEmpty(paths)
+	assert.Equal("./config.yml", paths[0])
 	assert.Nil(MustNew(OptConfig(cfg)).Build(context.TODO()))
 
 	_, err = os.Stat("dist")
@@ -76,6 +89,25 @@ func TestEngineBuild(t *testing.T) {
 	assert.Nil(err)
 	_, err = os.Stat("dist/2019/02/11/image-post/512.jpg")
 	assert.Nil(err)
+	// thumbnails keep the color profile of the image.
+	thumbnail, err := ioutil.ReadFile("dist/2019/02/11/image-post/512.jpg")
+	assert.Nil(err)
+	thumbnailSegments, _, err := jpegmeta.Split(thumbnail)
+	assert.Nil(err)
+	assert.NotEmpty(jpegmeta.ICCProfile(thumbnailSegments))
+	square, err := os.Open("dist/2019/02/11/image-post/square.jpg")
+	assert.Nil(err)
+	defer square.Close()
+	squareConfig, _, err := image.DecodeConfig(square)
+	assert.Nil(err)
+	assert.Equal(128, squareConfig.Width)
+	assert.Equal(128, squareConfig.Height)
+	strip, err := os.Open("dist/2019/02/11/image-post/strip.png")
+	assert.Nil(err)
+	defer strip.Close()
+	stripConfig, _, err := image.DecodeConfig(strip)
+	assert.Nil(err)
+	assert.Equal(100, stripConfig.Height)
 
 	f, err := os.Open("dist/data.json")
 	assert.Nil(err)
@@ -83,8 +115,208 @@ func TestEngineBuild(t *testing.T) {
 	var data model.Data
 	assert.Nil(json.NewDecoder(f).Decode(&data))
 
-	assert.Len(data.Posts, 2)
-	assert.Len(data.Posts[0].Image.Sizes, 4)
+	assert.Len(data.Posts, 5)
+	assert.Len(data.Posts[0].Image.Sizes, 6)
+	squarePath, err := data.Posts[0].ImagePath("square")
+	assert.Nil(err)
+	assert.Equal("2019/02/11/image-post/square.jpg", squarePath)
+	squareCrop := data.Posts[0].Image.Crops["square"]
+	assert.False(squareCrop.IsZero())
+	assert.Equal(squareCrop.Width, squareCrop.Height)
+	_, err = data.Posts[0].ImagePath("missing")
+	assert.NotNil(err)
+	assert.Len(data.Posts[0].Image.BlurHash, 28)
+	assert.True(strings.HasPrefix(data.Posts[0].Image.Placeholder, "data:image/jpeg;base64,"))
 	assert.Empty(data.Posts[1].Image.Sizes)
-	assert.Len(data.Tags, 4)
+	assert.Empty(data.Posts[2].Image.Sizes)
+	assert.Equal("Markdown Post", data.Posts[2].Meta.Title)
+	assert.Len(data.Tags, 6)
+	for _, tag := range data.Tags {
+		if tag.Tag == "blogctl" {
+			assert.Len(tag.Posts, 4)
+		}
+	}
+
+	gallery := data.Posts[3]
+	assert.Equal("Gallery Post", gallery.Meta.Title)
+	assert.Len(gallery.Images, 3)
+	assert.Equal("posts/2019-02-08-gallery-post/c.jpg", gallery.Images[0].SourcePath)
+	assert.Equal("The blue one.", gallery.Images[0].Caption)
+	assert.Equal("blue", gallery.Images[0].ColorName)
+	assert.NotEmpty(gallery.Images[0].Palette)
+	assert.Equal(gallery.Images[0].Palette[0], gallery.Images[0].Color)
+	assert.Equal("blue", gallery.Labels()["color"])
+	assert.Equal("posts/2019-02-08-gallery-post/a.jpg", gallery.Images[1].SourcePath)
+	assert.Equal("A red square.", gallery.Images[1].Alt)
+	assert.Equal("red", gallery.Images[1].ColorName)
+	assert.Equal(0, gallery.Images[1].Crops["square"].X)
+	assert.Equal(0, gallery.Images[1].Crops["square"].Y)
+	assert.Equal("posts/2019-02-08-gallery-post/b.jpg", gallery.Images[2].SourcePath)
+	assert.Equal(gallery.Images[0].SourcePath, gallery.Image.SourcePath)
+	assert.NotEmpty(gallery.Images[2].Exif.CameraMake)
+	assert.True(math.Abs(gallery.Images[2].Exif.Latitude-37.7749) < 0.0001)
+	assert.True(math.Abs(gallery.Images[2].Exif.Longitude+122.4194) < 0.0001)
+	assert.Equal(52.0, gallery.Images[2].Exif.Altitude)
+	assert.False(gallery.Images[0].Exif.HasLocation())
+	_, err = os.Stat("dist/2019/02/08/gallery-post/original.jpg")
+	assert.Nil(err)
+	_, err = os.Stat("dist/2019/02/08/gallery-post/2/512.jpg")
+	assert.Nil(err)
+	_, err = os.Stat("dist/2019/02/08/gallery-post/3/2048.jpg")
+	assert.Nil(err)
+
+	formats := data.Posts[4]
+	assert.Equal("Formats Post", formats.Meta.Title)
+	assert.Len(formats.Images, 3)
+	assert.Equal("png", formats.Images[0].Format)
+	assert.True(formats.Images[0].HasAlpha)
+	assert.Equal("gif", formats.Images[1].Format)
+	assert.Equal("webp", formats.Images[2].Format)
+	assert.False(formats.Images[2].HasAlpha)
+	assert.True(strings.HasPrefix(formats.Images[0].Placeholder, "data:image/png;base64,"))
+	assert.NotEmpty(formats.Images[1].BlurHash)
+	_, err = os.Stat("dist/2019/02/07/formats-post/original.png")
+	assert.Nil(err)
+	_, err = os.Stat("dist/2019/02/07/formats-post/512.png")
+	assert.Nil(err)
+	_, err = os.Stat("dist/2019/02/07/formats-post/2/original.gif")
+	assert.Nil(err)
+	animated, err := os.Open("dist/2019/02/07/formats-post/2/512.gif")
+	assert.Nil(err)
+	defer animated.Close()
+	animation, err := gif.DecodeAll(animated)
+	assert.Nil(err)
+	assert.Len(animation.Image, 3)
+	_, err = os.Stat("dist/2019/02/07/formats-post/3/original.webp")
+	assert.Nil(err)
+	_, err = os.Stat("dist/2019/02/07/formats-post/3/512.jpg")
+	assert.Nil(err)
+
+	_, err = os.Stat("dist/2019/02/13/unlisted-post/index.html")
+	assert.Nil(err)
+	_, err = os.Stat("dist/2019/02/12/draft-post")
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat("dist/2999")
+	assert.True(os.IsNotExist(err))
+
+	_, err = os.Stat("dist/feed.xml")
+	assert.Nil(err)
+	_, err = os.Stat("dist/rss.xml")
+	assert.Nil(err)
+	_, err = os.Stat("dist/tags/image-post/feed.xml")
+	assert.Nil(err)
+	_, err = os.Stat("dist/tags/image-post/rss.xml")
+	assert.Nil(err)
+
+	feedContents, err := ioutil.ReadFile("dist/feed.xml")
+	assert.Nil(err)
+	var atom feed.Atom
+	assert.Nil(xml.Unmarshal(feedContents, &atom))
+	assert.Len(atom.Entries, 5)
+	assert.Equal("https://github.com/wcharczuk/blogctl/2019/02/11/image-post/", atom.Entries[0].ID)
+	assert.Equal("https://github.com/wcharczuk/blogctl/2019/02/11/image-post/2048.jpg", atom.Entries[0].Links[1].Href)
+	assert.Contains(atom.Entries[1].Content.Body, "This is supposed to be a text post.")
+
+	sitemapContents, err := ioutil.ReadFile("dist/sitemap.xml")
+	assert.Nil(err)
+	var urlSet sitemap.URLSet
+	assert.Nil(xml.Unmarshal(sitemapContents, &urlSet))
+	assert.Len(urlSet.URLs, 12) // 1 page, 5 posts and 6 tags
+	assert.Equal("https://github.com/wcharczuk/blogctl/", urlSet.URLs[0].Loc)
+	assert.Contains(string(sitemapContents), "<image:loc>https://github.com/wcharczuk/blogctl/2019/02/11/image-post/original.jpg</image:loc>")
+	assert.Contains(string(sitemapContents), "<image:loc>https://github.com/wcharczuk/blogctl/2019/02/08/gallery-post/3/original.jpg</image:loc>")
+
+	locationsContents, err := ioutil.ReadFile("dist/locations.geojson")
+	assert.Nil(err)
+	var locations geojson.FeatureCollection
+	assert.Nil(json.Unmarshal(locationsContents, &locations))
+	assert.Len(locations.Features, 1)
+	assert.Equal("2019/02/08/gallery-post", locations.Features[0].Properties["slug"])
+	assert.Equal("https://github.com/wcharczuk/blogctl/2019/02/08/gallery-post/3/512.jpg", locations.Features[0].Properties["thumbnail"])
+	assert.Len(locations.Features[0].Geometry.Coordinates, 3)
+
+	robotsContents, err := ioutil.ReadFile("dist/robots.txt")
+	assert.Nil(err)
+	assert.Contains(string(robotsContents), "Sitemap: https://github.com/wcharczuk/blogctl/sitemap.xml")
+
+	contents, err := ioutil.ReadFile("dist/2019/02/09/markdown-post/index.html")
+	assert.Nil(err)
+	assert.Contains(string(contents), `<h1 id="markdown-post">Markdown Post</h1>`)
+	assert.Contains(string(contents), "<table>")
+	assert.Contains(string(contents), `class="footnotes"`)
+	assert.Contains(string(contents), `<code class="language-go">`)
+}
+
+func TestEngineBuildIncremental(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(os.Chdir("testdata"))
+
+	defer func() {
+		os.RemoveAll("thumbnails")
+		os.RemoveAll("dist")
+		os.Remove("manifest.json")
+		os.Chdir("..")
+	}()
+
+	cfg, _, err := config.ReadConfig(config.Flags{
+		ConfigPath:  ref.String("./config.yml"),
+		Parallelism: ref.Int(4),
+	})
+	assert.Nil(err)
+	// keep the thumbnails small so we don't spend the test resizing.
+	cfg.ImageSizes = []int{64}
+	assert.Nil(MustNew(OptConfig(cfg)).Build(context.TODO()))
+
+	_, err = os.Stat("manifest.json")
+	assert.Nil(err)
+	_, err = os.Stat("thumbnails/index.json")
+	assert.Nil(err)
+
+	// mark an output so we can tell if it was re-rendered,
+	// and add a stale output to the manifest that should be pruned.
+	assert.Nil(WriteFile("dist/index.html", []byte("sentinel")))
+	assert.Nil(MakeDir("dist/stale"))
+	assert.Nil(WriteFile("dist/stale/index.html", []byte("stale")))
+
+	manifest, err := MustNew(OptConfig(cfg)).ReadBuildManifest()
+	assert.Nil(err)
+	assert.NotEmpty(manifest.Outputs)
+	manifest.Outputs["stale/index.html"] = model.ManifestEntry{Hash: "stale"}
+	assert.Nil(MustNew(OptConfig(cfg)).WriteBuildManifest(manifest))
+
+	assert.Nil(MustNew(OptConfig(cfg)).Build(context.TODO()))
+
+	contents, err := ioutil.ReadFile("dist/index.html")
+	assert.Nil(err)
+	assert.Equal("sentinel", string(contents))
+	_, err = os.Stat("dist/stale/index.html")
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat("dist/stale")
+	assert.True(os.IsNotExist(err))
+
+	assert.Nil(MustNew(OptConfig(cfg), OptRebuild(true)).Build(context.TODO()))
+	contents, err = ioutil.ReadFile("dist/index.html")
+	assert.Nil(err)
+	assert.NotEqual("sentinel", string(contents))
+
+	// dry runs don't prune stale outputs, so they leave the manifest as is.
+	manifest, err = MustNew(OptConfig(cfg)).ReadBuildManifest()
+	assert.Nil(err)
+	manifest.Outputs["stale/index.html"] = model.ManifestEntry{Hash: "stale"}
+	assert.Nil(MustNew(OptConfig(cfg)).WriteBuildManifest(manifest))
+	assert.Nil(MustNew(OptConfig(cfg), OptDryRun(true)).Build(context.TODO()))
+	manifest, err = MustNew(OptConfig(cfg)).ReadBuildManifest()
+	assert.Nil(err)
+	assert.NotEmpty(manifest.Outputs["stale/index.html"].Hash)
+
+	// without a manifest, outputs left from before can't be pruned, so they're removed.
+	assert.Nil(MakeDir("dist/leftover"))
+	assert.Nil(WriteFile("dist/leftover/index.html", []byte("leftover")))
+	assert.Nil(os.Remove("manifest.json"))
+	assert.Nil(MustNew(OptConfig(cfg)).Build(context.TODO()))
+	_, err = os.Stat("dist/leftover/index.html")
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat("dist/index.html")
+	assert.Nil(err)
 }
@@ -0,0 +1,215 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/stringutil"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/feed"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// RenderFeeds writes the atom and rss feeds for the site, and for each tag.
+func (e Engine) RenderFeeds(ctx context.Context, manifest *Manifest) error {
+	renderContext := GetRenderContext(ctx)
+	outputPath := e.Config.OutputPathOrDefault()
+
+	if err := e.RenderFeed(manifest, outputPath, e.Config.TitleOrDefault(), "/", renderContext.Data.Posts); err != nil {
+		return err
+	}
+	if e.Config.SkipGenerateTags {
+		return nil
+	}
+	for _, tag := range renderContext.Data.Tags {
+		tagPath := filepath.Join("tags", stringutil.Slugify(tag.Tag))
+		title := fmt.Sprintf("%s: %s", e.Config.TitleOrDefault(), tag.Tag)
+		if err := MakeDir(filepath.Join(outputPath, tagPath)); err != nil {
+			return err
+		}
+		if err := e.RenderFeed(manifest, filepath.Join(outputPath, tagPath), title, "/"+filepath.ToSlash(tagPath)+"/", tag.Posts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderFeed writes an atom and an rss feed for a given set of posts to a directory.
+// The link is the path of the page the feed corresponds to, relative to the base url.
+func (e Engine) RenderFeed(manifest *Manifest, feedPath, title, link string, posts []*model.Post) error {
+	entries, err := e.FeedEntries(posts)
+	if err != nil {
+		return err
+	}
+
+	atomPath := filepath.Join(feedPath, constants.FileAtom)
+	logger.MaybeDebugf(e.Log, "%s: rendering feed", atomPath)
+	if err := WriteXML(atomPath, e.Atom(title, link, entries)); err != nil {
+		return err
+	}
+	rssPath := filepath.Join(feedPath, constants.FileRSS)
+	logger.MaybeDebugf(e.Log, "%s: rendering feed", rssPath)
+	if err := WriteXML(rssPath, e.RSS(title, link, entries)); err != nil {
+		return err
+	}
+	manifest.Record("", nil, e.OutputKey(atomPath), e.OutputKey(rssPath))
+	return nil
+}
+
+// FeedEntry is the format agnostic form of a post in a feed.
+type FeedEntry struct {
+	Post        *model.Post
+	URL         string
+	ContentHTML string
+	ImageURL    string
+	ImageType   string
+	ImageLength int64
+}
+
+// FeedEntries returns the feed entries for a set of posts, newest first,
+// limited to the configured post types and entry count.
+func (e Engine) FeedEntries(posts []*model.Post) ([]FeedEntry, error) {
+	var included []*model.Post
+	for _, post := range posts {
+		if e.Config.Feed.IncludesPostType(post.PostType()) {
+			included = append(included, post)
+		}
+	}
+	sort.Sort(model.Posts(included).Sort(constants.PostSortKeyPosted, false))
+	if entryCount := e.Config.Feed.EntryCountOrDefault(); len(included) > entryCount {
+		included = included[:entryCount]
+	}
+
+	outputPath := e.Config.OutputPathOrDefault()
+	imageSize := e.Config.Feed.ImageSizeOrDefault()
+	output := make([]FeedEntry, 0, len(included))
+	for _, post := range included {
+		entry := FeedEntry{
+			Post: post,
+			URL:  AbsoluteURL(e.Config.BaseURLOrDefault(), post.Slug) + "/",
+		}
+		if post.IsText() {
+			contents, err := renderPost(*post)
+			if err != nil {
+				return nil, ex.New(err).WithMessagef("post: %s", post.Slug)
+			}
+			entry.ContentHTML = string(contents)
+		} else if post.IsImage() {
+			imagePath := post.ImagePathForSize(imageSize)
+			entry.ImageURL = AbsoluteURL(e.Config.BaseURLOrDefault(), imagePath)
+			entry.ImageType = mime.TypeByExtension(filepath.Ext(imagePath))
+			if info, err := os.Stat(filepath.Join(outputPath, imagePath)); err == nil {
+				entry.ImageLength = info.Size()
+			}
+			for _, image := range post.Images {
+				alt := image.AltOrDefault()
+				if alt == "" {
+					alt = post.TitleOrDefault()
+				}
+				entry.ContentHTML += fmt.Sprintf(`<p><img src="%s" alt="%s" /></p>`, template.HTMLEscapeString(AbsoluteURL(e.Config.BaseURLOrDefault(), image.PathForSize(imageSize))), template.HTMLEscapeString(alt))
+				if image.Caption != "" {
+					entry.ContentHTML += fmt.Sprintf("<p>%s</p>", template.HTMLEscapeString(image.Caption))
+				}
+			}
+			if post.Meta.Location != "" {
+				entry.ContentHTML += fmt.Sprintf("<p>%s</p>", template.HTMLEscapeString(post.Meta.Location))
+			}
+		}
+		output = append(output, entry)
+	}
+	return output, nil
+}
+
+// Atom returns an atom feed for a set of entries.
+func (e Engine) Atom(title, link string, entries []FeedEntry) feed.Atom {
+	siteURL := AbsoluteURL(e.Config.BaseURLOrDefault(), link)
+	output := feed.Atom{
+		XMLNS:   feed.AtomNamespace,
+		ID:      siteURL,
+		Title:   title,
+		Updated: feedUpdated(entries).Format(time.RFC3339),
+		Links: []feed.AtomLink{
+			{Rel: "alternate", Type: "text/html", Href: siteURL},
+			{Rel: "self", Type: "application/atom+xml", Href: AbsoluteURL(siteURL, constants.FileAtom)},
+		},
+	}
+	if author := e.Config.AuthorOrDefault(); author != "" {
+		output.Author = &feed.AtomAuthor{Name: author}
+	}
+	for _, entry := range entries {
+		atomEntry := feed.AtomEntry{
+			ID:        entry.URL,
+			Title:     entry.Post.TitleOrDefault(),
+			Published: entry.Post.Meta.Posted.Format(time.RFC3339),
+			Updated:   entry.Post.Meta.Posted.Format(time.RFC3339),
+			Links: []feed.AtomLink{
+				{Rel: "alternate", Type: "text/html", Href: entry.URL},
+			},
+		}
+		if entry.ImageURL != "" {
+			atomEntry.Links = append(atomEntry.Links, feed.AtomLink{Rel: "enclosure", Type: entry.ImageType, Href: entry.ImageURL, Length: entry.ImageLength})
+		}
+		for _, tag := range entry.Post.Meta.Tags {
+			atomEntry.Categories = append(atomEntry.Categories, feed.AtomCategory{Term: tag})
+		}
+		if entry.ContentHTML != "" {
+			atomEntry.Content = &feed.AtomContent{Type: "html", Body: entry.ContentHTML}
+		}
+		output.Entries = append(output.Entries, atomEntry)
+	}
+	return output
+}
+
+// RSS returns an rss feed for a set of entries.
+func (e Engine) RSS(title, link string, entries []FeedEntry) feed.RSS {
+	output := feed.RSS{
+		Version: feed.RSSVersion,
+		Channel: feed.RSSChannel{
+			Title:         title,
+			Link:          AbsoluteURL(e.Config.BaseURLOrDefault(), link),
+			Description:   e.Config.Description,
+			LastBuildDate: feedUpdated(entries).Format(time.RFC1123Z),
+		},
+	}
+	if output.Channel.Description == "" {
+		output.Channel.Description = title
+	}
+	for _, entry := range entries {
+		item := feed.RSSItem{
+			Title:       entry.Post.TitleOrDefault(),
+			Link:        entry.URL,
+			GUID:        entry.URL,
+			PubDate:     entry.Post.Meta.Posted.Format(time.RFC1123Z),
+			Categories:  entry.Post.Meta.Tags,
+			Description: entry.ContentHTML,
+		}
+		if entry.ImageURL != "" {
+			item.Enclosure = &feed.RSSEnclosure{URL: entry.ImageURL, Type: entry.ImageType, Length: entry.ImageLength}
+		}
+		output.Channel.Items = append(output.Channel.Items, item)
+	}
+	return output
+}
+
+// feedUpdated returns the latest posted date of a set of entries,
+// or the current time if there are no entries.
+func feedUpdated(entries []FeedEntry) (latest time.Time) {
+	for _, entry := range entries {
+		if entry.Post.Meta.Posted.After(latest) {
+			latest = entry.Post.Meta.Posted
+		}
+	}
+	if latest.IsZero() {
+		latest = time.Now().UTC()
+	}
+	return
+}
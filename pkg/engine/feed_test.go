@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestEngineFeedUpdated(t *testing.T) {
+	assert := assert.New(t)
+
+	posted := time.Date(2019, 2, 11, 12, 0, 0, 0, time.UTC)
+	entries := []FeedEntry{
+		{Post: &model.Post{Meta: model.Meta{Posted: posted.Add(-time.Hour)}}},
+		{Post: &model.Post{Meta: model.Meta{Posted: posted}}},
+	}
+	e := Engine{}
+	assert.Equal(posted.Format(time.RFC3339), e.Atom("Feed", "/", entries).Updated)
+	assert.Equal(posted.Format(time.RFC1123Z), e.RSS("Feed", "/", entries).Channel.LastBuildDate)
+
+	// feeds without any entries are updated as of the build.
+	updated, err := time.Parse(time.RFC3339, e.Atom("Feed", "/", nil).Updated)
+	assert.Nil(err)
+	assert.True(time.Since(updated) < time.Minute)
+	lastBuildDate, err := time.Parse(time.RFC1123Z, e.RSS("Feed", "/", nil).Channel.LastBuildDate)
+	assert.Nil(err)
+	assert.True(time.Since(lastBuildDate) < time.Minute)
+}
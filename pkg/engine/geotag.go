@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wcharczuk/blogctl/pkg/gpx"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// GeotagOptions govern how the capture dates of images are matched against track points.
+type GeotagOptions struct {
+	// ClockOffset is how far ahead of the actual time the camera clock is,
+	// e.g. 90s if the camera clock is 90 seconds fast.
+	ClockOffset time.Duration
+	// TimeZone is the time zone of the camera clock.
+	// If it is unset, the time zone of the capture date is used.
+	TimeZone *time.Location
+	// MaxGap is the maximum time between a capture date and the nearest track point.
+	MaxGap time.Duration
+}
+
+// GeotagMatch is the position matched for a post.
+type GeotagMatch struct {
+	Post        *model.Post
+	CaptureDate time.Time
+	Position    gpx.Point
+	Gap         time.Duration
+}
+
+// IsMatched returns if a position within the max gap was found.
+func (gm GeotagMatch) IsMatched(maxGap time.Duration) bool {
+	return !gm.Position.Time.IsZero() && gm.Gap <= maxGap
+}
+
+// Geotag matches the capture date of the cover image of each image post
+// against track points, and returns the matched position for each.
+//
+// Posts without a capture date are skipped.
+func Geotag(posts []*model.Post, points gpx.Points, options GeotagOptions) (output []GeotagMatch) {
+	for _, post := range posts {
+		if post.Image.IsZero() || post.Image.Exif.CaptureDate.IsZero() {
+			continue
+		}
+		captureDate := CaptureTime(post.Image.Exif.CaptureDate, options.ClockOffset, options.TimeZone)
+		match := GeotagMatch{
+			Post:        post,
+			CaptureDate: captureDate,
+		}
+		if position, gap, ok := points.Locate(captureDate); ok {
+			match.Position = position
+			match.Gap = gap
+		}
+		output = append(output, match)
+	}
+	return
+}
+
+// CaptureTime returns the actual time an image was captured given the capture date
+// read from the camera clock, how far ahead the camera clock is, and optionally
+// the time zone of the camera clock.
+func CaptureTime(captureDate time.Time, clockOffset time.Duration, timeZone *time.Location) time.Time {
+	if timeZone != nil {
+		captureDate = time.Date(
+			captureDate.Year(), captureDate.Month(), captureDate.Day(),
+			captureDate.Hour(), captureDate.Minute(), captureDate.Second(), captureDate.Nanosecond(),
+			timeZone,
+		)
+	}
+	return captureDate.Add(-clockOffset)
+}
+
+// WriteMetaLocation sets the location in a post meta file, keeping the rest
+// of the file (including the order of the fields and comments) as is.
+//
+// The file is created if it doesn't exist.
+func WriteMetaLocation(metaPath string, latitude, longitude, altitude float64) error {
+	var document yaml.Node
+	if Exists(metaPath) {
+		contents, err := ioutil.ReadFile(metaPath)
+		if err != nil {
+			return ex.New(err)
+		}
+		if err := yaml.Unmarshal(contents, &document); err != nil {
+			return ex.New(err).WithMessagef("meta path: %s", metaPath)
+		}
+	}
+	if len(document.Content) == 0 {
+		document = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	mapping := document.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return ex.New("meta file is not a mapping", ex.OptMessagef("meta path: %s", metaPath))
+	}
+
+	setMetaField(mapping, "latitude", latitude)
+	setMetaField(mapping, "longitude", longitude)
+	if altitude != 0 {
+		setMetaField(mapping, "altitude", altitude)
+	} else {
+		removeMetaField(mapping, "altitude")
+	}
+
+	contents, err := yaml.Marshal(&document)
+	if err != nil {
+		return ex.New(err)
+	}
+	return ex.New(ioutil.WriteFile(metaPath, contents, 0644))
+}
+
+// setMetaField sets a field of a yaml mapping, adding it if it doesn't exist.
+func setMetaField(mapping *yaml.Node, key string, value interface{}) {
+	var valueNode yaml.Node
+	valueNode.Encode(value)
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if mapping.Content[index].Value == key {
+			mapping.Content[index+1] = &valueNode
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &valueNode)
+}
+
+// removeMetaField removes a field of a yaml mapping if it exists.
+func removeMetaField(mapping *yaml.Node, key string) {
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if mapping.Content[index].Value == key {
+			mapping.Content = append(mapping.Content[:index], mapping.Content[index+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/gpx"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestCaptureTime(t *testing.T) {
+	assert := assert.New(t)
+
+	captureDate := time.Date(2019, 02, 11, 10, 0, 0, 0, time.UTC)
+	assert.Equal(captureDate.Add(-90*time.Second), CaptureTime(captureDate, 90*time.Second, nil))
+
+	pacific := time.FixedZone("PST", -8*60*60)
+	assert.Equal(time.Date(2019, 02, 11, 18, 0, 0, 0, time.UTC).Unix(), CaptureTime(captureDate, 0, pacific).Unix())
+}
+
+func TestGeotag(t *testing.T) {
+	assert := assert.New(t)
+
+	points := gpx.Points{
+		{Latitude: 37, Longitude: -122, Time: time.Date(2019, 02, 11, 10, 0, 0, 0, time.UTC)},
+		{Latitude: 38, Longitude: -123, Time: time.Date(2019, 02, 11, 10, 10, 0, 0, time.UTC)},
+	}
+	posts := []*model.Post{
+		{Image: model.Image{Width: 1, Height: 1, Exif: model.Exif{CaptureDate: time.Date(2019, 02, 11, 10, 6, 0, 0, time.UTC)}}},
+		{Image: model.Image{Width: 1, Height: 1}},
+		{Image: model.Image{Width: 1, Height: 1, Exif: model.Exif{CaptureDate: time.Date(2019, 02, 11, 12, 0, 0, 0, time.UTC)}}},
+	}
+
+	matches := Geotag(posts, points, GeotagOptions{ClockOffset: time.Minute})
+	assert.Len(matches, 2)
+	assert.True(matches[0].IsMatched(5 * time.Minute))
+	assert.Equal(5*time.Minute, matches[0].Gap)
+	assert.InDelta(37.5, matches[0].Position.Latitude, 0.000001)
+	assert.False(matches[1].IsMatched(5 * time.Minute))
+}
+
+func TestWriteMetaLocation(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "blogctl")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	metaPath := filepath.Join(tempDir, "meta.yml")
+	assert.Nil(ioutil.WriteFile(metaPath, []byte("# a comment\ntitle: Test\nlatitude: 1\ntags: [one, two]\n"), 0644))
+	assert.Nil(WriteMetaLocation(metaPath, 37.5, -122.5, 0))
+
+	contents, err := ioutil.ReadFile(metaPath)
+	assert.Nil(err)
+	assert.Contains(string(contents), "# a comment")
+	var meta model.Meta
+	assert.Nil(ReadYAML(metaPath, &meta))
+	assert.Equal("Test", meta.Title)
+	assert.Equal([]string{"one", "two"}, meta.Tags)
+	assert.Equal(37.5, meta.Latitude)
+	assert.Equal(-122.5, meta.Longitude)
+
+	newMetaPath := filepath.Join(tempDir, "new.yml")
+	assert.Nil(WriteMetaLocation(newMetaPath, 37.5, -122.5, 10))
+	meta = model.Meta{}
+	assert.Nil(ReadYAML(newMetaPath, &meta))
+	assert.Equal(10.0, meta.Altitude)
+}
+
+func TestEngineLocateImage(t *testing.T) {
+	assert := assert.New(t)
+
+	e := Engine{}
+	data := model.Exif{Latitude: 1, Longitude: 2, Altitude: 3}
+	assert.Equal(data, e.LocateImage(model.Meta{}, data))
+	located := e.LocateImage(model.Meta{Latitude: 37.5, Longitude: -122.5}, data)
+	assert.Equal(37.5, located.Latitude)
+	assert.Equal(-122.5, located.Longitude)
+	assert.Zero(located.Altitude)
+}
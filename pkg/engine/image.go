@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// EncodeImage encodes an image in the format for a given file extension.
+// Extensions other than png and gif are encoded as jpeg with a given quality,
+// or the default quality if it is unset.
+func EncodeImage(w io.Writer, img image.Image, extension string, quality int) error {
+	switch strings.ToLower(extension) {
+	case constants.ExtensionPNG:
+		return ex.New(png.Encode(w, img))
+	case constants.ExtensionGIF:
+		return ex.New(gif.Encode(w, img, nil))
+	default:
+		if quality <= 0 {
+			quality = constants.DefaultImageQuality
+		}
+		return ex.New(jpeg.Encode(w, img, &jpeg.Options{Quality: quality}))
+	}
+}
+
+// Orient rotates and flips an image per an exif orientation so it is displayed upright.
+// Images with the normal or an unknown orientation are returned as is.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= constants.OrientationNormal || orientation > constants.OrientationRotate270 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	var output *image.RGBA
+	if orientation >= constants.OrientationTranspose {
+		output = image.NewRGBA(image.Rect(0, 0, height, width))
+	} else {
+		output = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case constants.OrientationFlipHorizontal:
+				dx, dy = width-1-x, y
+			case constants.OrientationRotate180:
+				dx, dy = width-1-x, height-1-y
+			case constants.OrientationFlipVertical:
+				dx, dy = x, height-1-y
+			case constants.OrientationTranspose:
+				dx, dy = y, x
+			case constants.OrientationRotate90:
+				dx, dy = height-1-y, x
+			case constants.OrientationTransverse:
+				dx, dy = height-1-y, width-1-x
+			case constants.OrientationRotate270:
+				dx, dy = y, width-1-x
+			}
+			output.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return output
+}
+
+// ResizeAnimation resizes every frame of an animated gif to fit a given max dimension with a given filter.
+//
+// Gif frames can be partial updates to the previous frames, so each frame is drawn onto
+// a canvas per the frame disposal methods first, and the full canvas is resized and
+// quantized back to the frame's palette. The delays and loop count are kept.
+func ResizeAnimation(animation *gif.GIF, maxDimension uint, filter resize.InterpolationFunction) *gif.GIF {
+	output := &gif.GIF{
+		Delay:     animation.Delay,
+		LoopCount: animation.LoopCount,
+	}
+
+	bounds := image.Rect(0, 0, animation.Config.Width, animation.Config.Height)
+	if bounds.Empty() && len(animation.Image) > 0 {
+		bounds = animation.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+	for index, frame := range animation.Image {
+		var disposal byte
+		if index < len(animation.Disposal) {
+			disposal = animation.Disposal[index]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, bounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		resized := resize.Thumbnail(maxDimension, maxDimension, canvas, filter)
+		paletted := image.NewPaletted(resized.Bounds(), frame.Palette)
+		draw.FloydSteinberg.Draw(paletted, resized.Bounds(), resized, resized.Bounds().Min)
+		output.Image = append(output.Image, paletted)
+		output.Disposal = append(output.Disposal, gif.DisposalNone)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return output
+}
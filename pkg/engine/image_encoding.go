@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"sort"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// ErrInvalidImageEncoding is returned if the image encoding options in the config are invalid.
+const ErrInvalidImageEncoding ex.Class = "invalid image encoding"
+
+// ValidateImageEncodings returns an error if the image encoding options in the config are invalid.
+func (e Engine) ValidateImageEncodings() error {
+	if err := validateImageEncoding(e.Config.ImageEncoding); err != nil {
+		return ex.New(ErrInvalidImageEncoding, ex.OptMessagef("imageEncoding: %s", err))
+	}
+
+	var sizes []int
+	for size := range e.Config.ImageSizeEncodings {
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+	for _, size := range sizes {
+		if err := validateImageEncoding(e.Config.ImageSizeEncodings[size]); err != nil {
+			return ex.New(ErrInvalidImageEncoding, ex.OptMessagef("imageSizeEncodings: %d: %s", size, err))
+		}
+	}
+	return nil
+}
+
+func validateImageEncoding(encoding config.ImageEncoding) error {
+	if _, ok := resize.ParseInterpolationFunction(encoding.FilterOrDefault()); !ok {
+		return fmt.Errorf("filter must be one of nearest, bilinear, bicubic, mitchell-netravali, lanczos2 or lanczos3; got %s", encoding.Filter)
+	}
+	if encoding.Quality < 0 || encoding.Quality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+	if encoding.Sharpen.Amount < 0 || encoding.Sharpen.Radius < 0 {
+		return fmt.Errorf("sharpen amount and radius can't be negative")
+	}
+	if encoding.Sharpen.Threshold < 0 || encoding.Sharpen.Threshold > 255 {
+		return fmt.Errorf("sharpen threshold must be between 0 and 255")
+	}
+	if profile := encoding.ColorProfileOrDefault(); profile != constants.ColorProfileKeep && profile != constants.ColorProfileSRGB {
+		return fmt.Errorf("color profile must be one of keep or srgb; got %s", encoding.ColorProfile)
+	}
+	return nil
+}
+
+// ImageVariantEncoding returns the image encoding options for a variant;
+// the quality of the variant takes precedence over the quality of the image encoding.
+func (e Engine) ImageVariantEncoding(variant config.ImageVariant) config.ImageEncoding {
+	return e.Config.ImageEncoding.Merge(config.ImageEncoding{Quality: variant.Quality})
+}
+
+// ImageEncodingHash returns a short hash of image encoding options, for the file names of cached thumbnails.
+// Equivalent options have the same hash.
+func ImageEncodingHash(encoding config.ImageEncoding) string {
+	settings, _ := json.Marshal(encoding.Normalize())
+	hash := fnv.New32a()
+	hash.Write(settings)
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
+
+// ImageFilter returns the interpolation function for image encoding options, or bicubic if the filter is unknown.
+func ImageFilter(encoding config.ImageEncoding) resize.InterpolationFunction {
+	if filter, ok := resize.ParseInterpolationFunction(encoding.FilterOrDefault()); ok {
+		return filter
+	}
+	return resize.Bicubic
+}
+
+// SharpenImage applies the unsharp mask of image encoding options to an image, if it's set.
+func SharpenImage(img image.Image, encoding config.ImageEncoding) image.Image {
+	if encoding.Sharpen.IsZero() {
+		return img
+	}
+	return resize.UnsharpMask(img, encoding.Sharpen.RadiusOrDefault(), encoding.Sharpen.Amount, uint8(encoding.Sharpen.Threshold))
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestEngineThumbnailCachePath(t *testing.T) {
+	assert := assert.New(t)
+
+	original := model.Image{Format: constants.ImageFormatJPEG}
+	e := Engine{Config: config.Config{ThumbnailCachePath: "thumbnails"}}
+	assert.Equal(filepath.Join("thumbnails", "etag", "1024.jpg"), e.ThumbnailCachePath(original, "etag", 1024))
+
+	// options equivalent to the defaults keep the plain file name.
+	e.Config.ImageEncoding = config.ImageEncoding{Filter: "Bicubic", Quality: constants.DefaultImageQuality}
+	assert.Equal(filepath.Join("thumbnails", "etag", "1024.jpg"), e.ThumbnailCachePath(original, "etag", 1024))
+
+	e.Config.ImageEncoding = config.ImageEncoding{Filter: "lanczos3"}
+	lanczos := e.ThumbnailCachePath(original, "etag", 1024)
+	assert.NotEqual(filepath.Join("thumbnails", "etag", "1024.jpg"), lanczos)
+	e.Config.ImageEncoding.Sharpen = config.UnsharpMask{Amount: 0.5}
+	assert.NotEqual(lanczos, e.ThumbnailCachePath(original, "etag", 1024))
+	e.Config.ImageEncoding = config.ImageEncoding{ColorProfile: constants.ColorProfileSRGB}
+	assert.NotEqual(filepath.Join("thumbnails", "etag", "1024.jpg"), e.ThumbnailCachePath(original, "etag", 1024))
+
+	// per size options only change the key of that size.
+	e.Config.ImageEncoding = config.ImageEncoding{}
+	e.Config.ImageSizeEncodings = map[int]config.ImageEncoding{2048: {Quality: 92}}
+	assert.Equal(filepath.Join("thumbnails", "etag", "1024.jpg"), e.ThumbnailCachePath(original, "etag", 1024))
+	assert.NotEqual(filepath.Join("thumbnails", "etag", "2048.jpg"), e.ThumbnailCachePath(original, "etag", 2048))
+	assert.Equal(92, e.Config.ImageEncodingForSize(2048).QualityOrDefault())
+}
+
+func TestEngineValidateImageEncodings(t *testing.T) {
+	assert := assert.New(t)
+
+	valid := Engine{Config: config.Config{
+		ImageEncoding:      config.ImageEncoding{Filter: "lanczos3", Quality: 85, Sharpen: config.UnsharpMask{Amount: 0.5, Radius: 0.8, Threshold: 2}},
+		ImageSizeEncodings: map[int]config.ImageEncoding{2048: {Quality: 92}},
+	}}
+	assert.Nil(valid.ValidateImageEncodings())
+
+	for _, encoding := range []config.ImageEncoding{
+		{Filter: "sinc"},
+		{Quality: 101},
+		{Sharpen: config.UnsharpMask{Amount: -1}},
+		{Sharpen: config.UnsharpMask{Amount: 1, Threshold: 256}},
+		{ColorProfile: "adobe-rgb"},
+	} {
+		invalid := Engine{Config: config.Config{ImageEncoding: encoding}}
+		assert.NotNil(invalid.ValidateImageEncodings(), encoding)
+	}
+
+	invalidSize := Engine{Config: config.Config{ImageSizeEncodings: map[int]config.ImageEncoding{512: {Quality: -1}}}}
+	assert.NotNil(invalidSize.ValidateImageEncodings())
+}
@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+func TestOrient(t *testing.T) {
+	assert := assert.New(t)
+
+	// a 3x2 image, with the top left pixel red and the rest black.
+	red := color.RGBA{R: 255, A: 255}
+	original := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	original.Set(0, 0, red)
+
+	assert.Equal(original, Orient(original, 0))
+	assert.Equal(original, Orient(original, constants.OrientationNormal))
+
+	testCases := [...]struct {
+		Orientation int
+		Size        image.Point
+		Red         image.Point
+	}{
+		{Orientation: constants.OrientationFlipHorizontal, Size: image.Pt(3, 2), Red: image.Pt(2, 0)},
+		{Orientation: constants.OrientationRotate180, Size: image.Pt(3, 2), Red: image.Pt(2, 1)},
+		{Orientation: constants.OrientationFlipVertical, Size: image.Pt(3, 2), Red: image.Pt(0, 1)},
+		{Orientation: constants.OrientationTranspose, Size: image.Pt(2, 3), Red: image.Pt(0, 0)},
+		{Orientation: constants.OrientationRotate90, Size: image.Pt(2, 3), Red: image.Pt(1, 0)},
+		{Orientation: constants.OrientationTransverse, Size: image.Pt(2, 3), Red: image.Pt(1, 2)},
+		{Orientation: constants.OrientationRotate270, Size: image.Pt(2, 3), Red: image.Pt(0, 2)},
+	}
+	for _, tc := range testCases {
+		oriented := Orient(original, tc.Orientation)
+		assert.Equal(tc.Size, oriented.Bounds().Size(), tc.Orientation)
+		assert.Equal(color.RGBAModel.Convert(red), color.RGBAModel.Convert(oriented.At(tc.Red.X, tc.Red.Y)), tc.Orientation)
+	}
+}
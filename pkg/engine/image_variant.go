@@ -0,0 +1,279 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// ErrInvalidImageVariant is returned if an image variant in the config is invalid.
+const ErrInvalidImageVariant ex.Class = "invalid image variant"
+
+// imageVariantName is what image variant names are limited to, as they're file names.
+var imageVariantName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateImageVariants returns an error if any of the image variants in the config are invalid.
+func (e Engine) ValidateImageVariants() error {
+	names := make(map[string]bool)
+	for _, variant := range e.Config.ImageVariants {
+		invalid := func(message string, args ...interface{}) error {
+			return ex.New(ErrInvalidImageVariant, ex.OptMessagef("%s: %s", variant.Name, fmt.Sprintf(message, args...)))
+		}
+		if !imageVariantName.MatchString(variant.Name) {
+			return invalid("name must be lowercase letters, numbers, dashes and underscores")
+		}
+		if _, err := strconv.Atoi(variant.Name); err == nil || variant.Name == constants.ImageOriginal {
+			return invalid("name is reserved for the image sizes")
+		}
+		if names[variant.Name] {
+			return invalid("name is used more than once")
+		}
+		names[variant.Name] = true
+
+		if variant.Width < 0 || variant.Height < 0 {
+			return invalid("width and height can't be negative")
+		}
+		switch variant.ModeOrDefault() {
+		case constants.ImageVariantModeFit:
+			if variant.Width == 0 && variant.Height == 0 {
+				return invalid("width or height is required")
+			}
+		case constants.ImageVariantModeFill, constants.ImageVariantModeCrop:
+			if variant.Width == 0 || variant.Height == 0 {
+				return invalid("width and height are required for mode %s", variant.ModeOrDefault())
+			}
+		default:
+			return invalid("mode must be one of fit, fill or crop; got %s", variant.Mode)
+		}
+		if anchor := variant.AnchorOrDefault(); anchor != constants.AnchorSmart {
+			if _, _, ok := AnchorFocus(anchor); !ok {
+				return invalid("unknown anchor %s", variant.Anchor)
+			}
+		}
+		if len(variant.Focus) > 0 {
+			if len(variant.Focus) != 2 || variant.Focus[0] < 0 || variant.Focus[0] > 1 || variant.Focus[1] < 0 || variant.Focus[1] > 1 {
+				return invalid("focus must be two fractions between 0 and 1")
+			}
+		}
+		if variant.Format != "" && variant.ExtensionOrDefault("") == "" {
+			return invalid("format must be one of jpg, png or gif; got %s", variant.Format)
+		}
+		if variant.Quality < 0 || variant.Quality > 100 {
+			return invalid("quality must be between 1 and 100")
+		}
+	}
+	return nil
+}
+
+// ImageVariantPath returns the path of a variant of an image, relative to the output path.
+func ImageVariantPath(image model.Image, variant config.ImageVariant) string {
+	return filepath.Join(image.OutputPath, fmt.Sprintf(constants.ImageVariantFormat, variant.Name, variant.ExtensionOrDefault(image.ThumbnailExtension())))
+}
+
+// ImageVariantCachePath returns the path of a variant of an image in the thumbnail cache.
+//
+// The file name includes a hash of the variant settings, the image encoding options and the crop
+// of the image for the variant, so changing any of them regenerates the variant even if its name is the same.
+func (e Engine) ImageVariantCachePath(original model.Image, etag string, variant config.ImageVariant) string {
+	encoding := e.ImageVariantEncoding(variant).Normalize()
+	variant.Mode = variant.ModeOrDefault()
+	variant.Anchor = variant.AnchorOrDefault()
+	variant.Quality = 0
+	settings, _ := json.Marshal(struct {
+		Variant  config.ImageVariant
+		Encoding config.ImageEncoding
+		Crop     model.Crop
+	}{
+		Variant:  variant,
+		Encoding: encoding,
+		Crop:     original.Crops[variant.Name],
+	})
+	hash := fnv.New32a()
+	hash.Write(settings)
+	name := fmt.Sprintf("%s-%08x", variant.Name, hash.Sum32())
+	return e.ImageCachePath(original, etag, fmt.Sprintf(constants.ImageVariantFormat, name, variant.ExtensionOrDefault(original.ThumbnailExtension())))
+}
+
+// GenerateImageVariant generates a variant of an image and stores it in the cache.
+func (e Engine) GenerateImageVariant(ctx context.Context, source *SourceImage, etag string, variant config.ImageVariant, metadata ThumbnailMetadata) error {
+	original := source.Original
+	variantPath := e.ImageVariantCachePath(original, etag, variant)
+	logger.MaybeDebugf(e.Log, "%s: generating cached variant %s", original.SourcePath, variant.Name)
+
+	decoded, err := source.Decode()
+	if err != nil {
+		return err
+	}
+	crop := original.Crops[variant.Name]
+	if crop.IsZero() && variant.ModeOrDefault() != constants.ImageVariantModeFit {
+		if crop, err = e.ImageCrop(ctx, source, variant); err != nil {
+			return err
+		}
+	}
+	encoding := e.ImageVariantEncoding(variant)
+	rendered := metadata.ConvertColorProfile(RenderImageVariant(decoded, variant, encoding, original.Exif.Orientation, crop), encoding)
+	return e.WriteCached(ctx, variantPath, func(out io.Writer) error {
+		return EncodeThumbnail(out, rendered, filepath.Ext(variantPath), encoding, metadata)
+	})
+}
+
+// CopyImageVariant copies a cached variant of an image to the output directory.
+func (e Engine) CopyImageVariant(original model.Image, etag, destinationPath string, variant config.ImageVariant) error {
+	outputPath := filepath.Join(destinationPath, filepath.Base(ImageVariantPath(original, variant)))
+	logger.MaybeDebugf(e.Log, "%s: copying cached variant %s", destinationPath, variant.Name)
+	return Copy(e.ImageVariantCachePath(original, etag, variant), outputPath)
+}
+
+// RenderImageVariant resizes and crops an image per a variant and the image encoding options,
+// keeping a given crop of the image as it's displayed for the `fill` and `crop` modes.
+//
+// The variant dimensions are of the image as it's displayed, so the image is
+// rotated or flipped per its exif orientation; as that is slow for large images,
+// the crop is taken from the image as it's stored and only the result is oriented.
+func RenderImageVariant(img image.Image, variant config.ImageVariant, encoding config.ImageEncoding, orientation int, crop model.Crop) image.Image {
+	bounds := img.Bounds()
+	switch variant.ModeOrDefault() {
+	case constants.ImageVariantModeFill:
+		cropped := CropImage(img, UnorientRectangle(crop.Rectangle(), orientation, bounds.Dx(), bounds.Dy()))
+		return resizeOriented(cropped, variant.Width, variant.Height, orientation, encoding)
+	case constants.ImageVariantModeCrop:
+		return Orient(SharpenImage(CropImage(img, UnorientRectangle(crop.Rectangle(), orientation, bounds.Dx(), bounds.Dy())), encoding), orientation)
+	default:
+		width, height := bounds.Dx(), bounds.Dy()
+		if orientation >= constants.OrientationTranspose && orientation <= constants.OrientationRotate270 {
+			width, height = height, width
+		}
+		scaledWidth, scaledHeight := FitDimensions(width, height, variant.Width, variant.Height)
+		return resizeOriented(img, scaledWidth, scaledHeight, orientation, encoding)
+	}
+}
+
+// resizeOriented resizes and sharpens an image to dimensions of the image as it's displayed,
+// and rotates or flips it per its exif orientation.
+func resizeOriented(img image.Image, width, height, orientation int, encoding config.ImageEncoding) image.Image {
+	if orientation >= constants.OrientationTranspose && orientation <= constants.OrientationRotate270 {
+		width, height = height, width
+	}
+	if bounds := img.Bounds(); bounds.Dx() == width && bounds.Dy() == height {
+		return Orient(SharpenImage(img, encoding), orientation)
+	}
+	return Orient(SharpenImage(resize.Resize(uint(width), uint(height), img, ImageFilter(encoding)), encoding), orientation)
+}
+
+// FitDimensions returns the dimensions of an image scaled down to fit within a given
+// width and height, either of which can be zero to leave it unconstrained.
+// Images are never scaled up.
+func FitDimensions(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 {
+		scale = math.Min(scale, float64(maxWidth)/float64(width))
+	}
+	if maxHeight > 0 {
+		scale = math.Min(scale, float64(maxHeight)/float64(height))
+	}
+	return scaleDimensions(width, height, scale)
+}
+
+// FillDimensions returns the dimensions of an image scaled to cover a given width and height.
+func FillDimensions(width, height, fillWidth, fillHeight int) (int, int) {
+	scale := math.Max(float64(fillWidth)/float64(width), float64(fillHeight)/float64(height))
+	scaledWidth, scaledHeight := scaleDimensions(width, height, scale)
+	// rounding can leave the scaled image a pixel short of covering the dimensions.
+	if scaledWidth < fillWidth {
+		scaledWidth = fillWidth
+	}
+	if scaledHeight < fillHeight {
+		scaledHeight = fillHeight
+	}
+	return scaledWidth, scaledHeight
+}
+
+func scaleDimensions(width, height int, scale float64) (int, int) {
+	scaledWidth := int(math.Round(float64(width) * scale))
+	scaledHeight := int(math.Round(float64(height) * scale))
+	if scaledWidth < 1 {
+		scaledWidth = 1
+	}
+	if scaledHeight < 1 {
+		scaledHeight = 1
+	}
+	return scaledWidth, scaledHeight
+}
+
+// CropImage returns a region of an image, relative to the image bounds.
+// The region is clamped to the image.
+func CropImage(img image.Image, region image.Rectangle) image.Image {
+	bounds := img.Bounds()
+	region = region.Add(bounds.Min).Intersect(bounds)
+	output := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Draw(output, output.Bounds(), img, region.Min, draw.Src)
+	return output
+}
+
+// cropOffset returns the offset of a crop of a given length centered on a focus
+// fraction, kept within the total length.
+func cropOffset(total, length int, focus float64) int {
+	offset := int(math.Round(focus*float64(total) - float64(length)/2))
+	if offset < 0 {
+		return 0
+	}
+	if offset > total-length {
+		return total - length
+	}
+	return offset
+}
+
+// ImageVariantFocus returns the focus point of a variant for an image; the focus of the image
+// if it is set, then the focus of the variant, then the anchor of the variant.
+// It returns false if the image should be smart cropped.
+func ImageVariantFocus(original model.Image, variant config.ImageVariant) (x, y float64, ok bool) {
+	if original.Focus != nil {
+		return original.Focus.X, original.Focus.Y, true
+	}
+	if len(variant.Focus) == 2 {
+		return variant.Focus[0], variant.Focus[1], true
+	}
+	return AnchorFocus(variant.AnchorOrDefault())
+}
+
+// AnchorFocus returns the focus point for an anchor, as the fractions of the width
+// and height from the top left. It returns false if the anchor is unknown.
+func AnchorFocus(anchor string) (x, y float64, ok bool) {
+	switch anchor {
+	case constants.AnchorCenter:
+		return 0.5, 0.5, true
+	case constants.AnchorTop:
+		return 0.5, 0, true
+	case constants.AnchorBottom:
+		return 0.5, 1, true
+	case constants.AnchorLeft:
+		return 0, 0.5, true
+	case constants.AnchorRight:
+		return 1, 0.5, true
+	case constants.AnchorTopLeft:
+		return 0, 0, true
+	case constants.AnchorTopRight:
+		return 1, 0, true
+	case constants.AnchorBottomLeft:
+		return 0, 1, true
+	case constants.AnchorBottomRight:
+		return 1, 1, true
+	}
+	return 0, 0, false
+}
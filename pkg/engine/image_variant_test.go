@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestFitFillDimensions(t *testing.T) {
+	assert := assert.New(t)
+
+	width, height := FitDimensions(3000, 2000, 1200, 0)
+	assert.Equal(1200, width)
+	assert.Equal(800, height)
+	width, height = FitDimensions(3000, 2000, 0, 100)
+	assert.Equal(150, width)
+	assert.Equal(100, height)
+	width, height = FitDimensions(300, 200, 1200, 1200)
+	assert.Equal(300, width)
+	assert.Equal(200, height)
+
+	width, height = FillDimensions(3000, 2000, 1200, 630)
+	assert.Equal(1200, width)
+	assert.Equal(800, height)
+	width, height = FillDimensions(2000, 3000, 1200, 630)
+	assert.Equal(1200, width)
+	assert.Equal(1800, height)
+	width, height = FillDimensions(3000, 2000, 512, 512)
+	assert.Equal(768, width)
+	assert.Equal(512, height)
+}
+
+func TestCropImage(t *testing.T) {
+	assert := assert.New(t)
+
+	// a 4x4 image that isn't at the origin, with a different color per pixel.
+	original := image.NewRGBA(image.Rect(10, 20, 14, 24))
+	for y := 20; y < 24; y++ {
+		for x := 10; x < 14; x++ {
+			original.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+
+	// the region is relative to the image bounds.
+	cropped := CropImage(original, image.Rect(1, 2, 3, 4))
+	assert.Equal(image.Rect(0, 0, 2, 2), cropped.Bounds())
+	assert.Equal(color.RGBA{R: 11, G: 22, A: 255}, cropped.At(0, 0))
+	assert.Equal(color.RGBA{R: 12, G: 23, A: 255}, cropped.At(1, 1))
+
+	// and it's clamped to the image.
+	clamped := CropImage(original, image.Rect(2, -1, 8, 2))
+	assert.Equal(image.Rect(0, 0, 2, 2), clamped.Bounds())
+	assert.Equal(color.RGBA{R: 12, G: 20, A: 255}, clamped.At(0, 0))
+}
+
+func TestRenderImageVariant(t *testing.T) {
+	assert := assert.New(t)
+
+	// a 300x200 image, black with the right third red.
+	red := color.RGBAModel.Convert(color.RGBA{R: 255, A: 255})
+	original := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(original, original.Bounds(), &image.Uniform{C: color.Black}, image.Point{}, draw.Src)
+	draw.Draw(original, image.Rect(200, 0, 300, 200), &image.Uniform{C: red}, image.Point{}, draw.Src)
+
+	square := RenderImageVariant(original, config.ImageVariant{Name: "square", Width: 100, Height: 100, Mode: constants.ImageVariantModeFill}, config.ImageEncoding{}, 0, model.Crop{X: 50, Width: 200, Height: 200})
+	assert.Equal(image.Pt(100, 100), square.Bounds().Size())
+	// the crop is of the center of the image scaled to 150x100, so the red third starts 75px in.
+	assert.NotEqual(red, color.RGBAModel.Convert(square.At(70, 50)))
+	assert.Equal(red, color.RGBAModel.Convert(square.At(80, 50)))
+
+	focused := RenderImageVariant(original, config.ImageVariant{Name: "focused", Width: 50, Height: 50, Mode: constants.ImageVariantModeCrop}, config.ImageEncoding{}, 0, model.Crop{X: 245, Y: 75, Width: 50, Height: 50})
+	assert.Equal(image.Pt(50, 50), focused.Bounds().Size())
+	assert.Equal(red, color.RGBAModel.Convert(focused.At(0, 0)))
+
+	strip := RenderImageVariant(original, config.ImageVariant{Name: "strip", Height: 50}, config.ImageEncoding{}, 0, model.Crop{})
+	assert.Equal(image.Pt(75, 50), strip.Bounds().Size())
+
+	// the dimensions and the crop are of the image as it's displayed.
+	rotated := RenderImageVariant(original, config.ImageVariant{Name: "strip", Height: 150}, config.ImageEncoding{}, constants.OrientationRotate90, model.Crop{})
+	assert.Equal(image.Pt(100, 150), rotated.Bounds().Size())
+	rotated = RenderImageVariant(original, config.ImageVariant{Name: "card", Width: 100, Height: 50, Mode: constants.ImageVariantModeFill}, config.ImageEncoding{}, constants.OrientationRotate90, model.Crop{Width: 200, Height: 100})
+	assert.Equal(image.Pt(100, 50), rotated.Bounds().Size())
+	// rotated 90 degrees clockwise, the red third of the image is at the bottom.
+	bottom := RenderImageVariant(original, config.ImageVariant{Name: "bottom", Width: 50, Height: 50, Mode: constants.ImageVariantModeCrop}, config.ImageEncoding{}, constants.OrientationRotate90, model.Crop{X: 75, Y: 250, Width: 50, Height: 50})
+	assert.Equal(red, color.RGBAModel.Convert(bottom.At(0, 0)))
+}
+
+func TestEngineImageCrop(t *testing.T) {
+	assert := assert.New(t)
+
+	e := Engine{}
+	original := model.Image{Width: 300, Height: 200}
+	square := config.ImageVariant{Name: "square", Width: 100, Height: 100, Mode: constants.ImageVariantModeFill}
+
+	// crops are centered by default.
+	crop, err := e.ImageCrop(context.TODO(), e.NewSourceImage(context.TODO(), original), square)
+	assert.Nil(err)
+	assert.Equal(model.Crop{X: 50, Width: 200, Height: 200}, crop)
+
+	square.Anchor = constants.AnchorRight
+	crop, err = e.ImageCrop(context.TODO(), e.NewSourceImage(context.TODO(), original), square)
+	assert.Nil(err)
+	assert.Equal(model.Crop{X: 100, Width: 200, Height: 200}, crop)
+
+	// the focus of the variant takes precedence over its anchor, and the focus of the image over both.
+	square.Focus = []float64{0, 0.5}
+	crop, err = e.ImageCrop(context.TODO(), e.NewSourceImage(context.TODO(), original), square)
+	assert.Nil(err)
+	assert.Equal(0, crop.X)
+	original.Focus = &model.Focus{X: 0.6, Y: 0.5}
+	crop, err = e.ImageCrop(context.TODO(), e.NewSourceImage(context.TODO(), original), square)
+	assert.Nil(err)
+	assert.Equal(80, crop.X)
+
+	card := config.ImageVariant{Name: "card", Width: 500, Height: 50, Mode: constants.ImageVariantModeCrop}
+	crop, err = e.ImageCrop(context.TODO(), e.NewSourceImage(context.TODO(), original), card)
+	assert.Nil(err)
+	assert.Equal(model.Crop{Y: 75, Width: 300, Height: 50}, crop)
+}
+
+func TestUnorientRectangle(t *testing.T) {
+	assert := assert.New(t)
+
+	stored := image.NewRGBA(image.Rect(0, 0, 6, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 6; x++ {
+			stored.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+	for orientation := constants.OrientationNormal; orientation <= constants.OrientationRotate270; orientation++ {
+		displayed := Orient(stored, orientation)
+		region := image.Rect(1, 1, 3, 2)
+		expected := Orient(CropImage(stored, UnorientRectangle(region, orientation, 6, 4)), orientation)
+		actual := CropImage(displayed, region)
+		assert.Equal(actual.Bounds(), expected.Bounds(), orientation)
+		for y := 0; y < region.Dy(); y++ {
+			for x := 0; x < region.Dx(); x++ {
+				assert.Equal(actual.At(x, y), expected.At(x, y), orientation)
+			}
+		}
+	}
+}
+
+func TestEngineValidateImageVariants(t *testing.T) {
+	assert := assert.New(t)
+
+	valid := Engine{Config: config.Config{ImageVariants: []config.ImageVariant{
+		{Name: "square", Width: 512, Height: 512, Mode: "fill"},
+		{Name: "social-card", Width: 1200, Height: 630, Mode: "fill", Anchor: "top", Format: "jpg", Quality: 90},
+		{Name: "strip", Height: 200},
+		{Name: "smart", Width: 512, Height: 512, Mode: "crop", Anchor: "smart"},
+	}}}
+	assert.Nil(valid.ValidateImageVariants())
+
+	for _, variant := range []config.ImageVariant{
+		{Name: "Square", Width: 512, Height: 512},
+		{Name: "512", Width: 512, Height: 512},
+		{Name: "original", Width: 512, Height: 512},
+		{Name: "square"},
+		{Name: "square", Width: 512, Mode: "fill"},
+		{Name: "square", Width: 512, Height: 512, Mode: "stretch"},
+		{Name: "square", Width: 512, Height: 512, Anchor: "middle"},
+		{Name: "square", Width: 512, Height: 512, Focus: []float64{0.5}},
+		{Name: "square", Width: 512, Height: 512, Format: "tiff"},
+		{Name: "square", Width: 512, Height: 512, Quality: 101},
+	} {
+		invalid := Engine{Config: config.Config{ImageVariants: []config.ImageVariant{variant}}}
+		assert.NotNil(invalid.ValidateImageVariants(), variant)
+	}
+
+	duplicate := Engine{Config: config.Config{ImageVariants: []config.ImageVariant{{Name: "square", Width: 1}, {Name: "square", Width: 2}}}}
+	assert.NotNil(duplicate.ValidateImageVariants())
+
+	image := model.Image{OutputPath: "2019/02/11/image-post", Format: constants.ImageFormatPNG}
+	assert.Equal("2019/02/11/image-post/strip.png", ImageVariantPath(image, valid.Config.ImageVariants[2]))
+	assert.Equal("2019/02/11/image-post/social-card.jpg", ImageVariantPath(image, valid.Config.ImageVariants[1]))
+}
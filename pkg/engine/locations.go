@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/geojson"
+)
+
+// RenderLocations writes the geojson map data layer of the geotagged posts.
+func (e Engine) RenderLocations(ctx context.Context, manifest *Manifest) error {
+	locationsPath := filepath.Join(e.Config.OutputPathOrDefault(), constants.FileLocations)
+	logger.MaybeDebugf(e.Log, "%s: rendering locations", locationsPath)
+	if err := WriteJSON(locationsPath, e.Locations(ctx)); err != nil {
+		return err
+	}
+	manifest.Record("", nil, e.OutputKey(locationsPath))
+	return nil
+}
+
+// Locations returns a point for each geotagged post, at the location of
+// the first image of the post with a gps location.
+//
+// Each point has the slug, title, url and posted date of the post,
+// and the url of a thumbnail of the image.
+func (e Engine) Locations(ctx context.Context) geojson.FeatureCollection {
+	renderContext := GetRenderContext(ctx)
+	baseURL := e.Config.BaseURLOrDefault()
+
+	output := geojson.FeatureCollection{
+		Type:     geojson.TypeFeatureCollection,
+		Features: []geojson.Feature{},
+	}
+	for _, post := range renderContext.Data.Posts {
+		image, ok := post.GeotaggedImage()
+		if !ok {
+			continue
+		}
+		feature := geojson.NewPoint(image.Exif.Latitude, image.Exif.Longitude, map[string]interface{}{
+			"slug":      post.Slug,
+			"title":     post.Meta.Title,
+			"url":       AbsoluteURL(baseURL, post.Slug) + "/",
+			"posted":    post.Meta.Posted.Format(time.RFC3339),
+			"thumbnail": AbsoluteURL(baseURL, image.PathForSize(e.Config.Locations.ImageSizeOrDefault())),
+		})
+		if image.Exif.Altitude != 0 {
+			feature.Geometry.Coordinates = append(feature.Geometry.Coordinates, image.Exif.Altitude)
+		}
+		output.Features = append(output.Features, feature)
+	}
+	return output
+}
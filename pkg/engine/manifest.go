@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// NewManifest returns a new manifest tracker against the manifest from a previous build.
+func NewManifest(previous model.Manifest) *Manifest {
+	return &Manifest{
+		Previous: previous,
+		Current: model.Manifest{
+			Outputs: make(map[string]model.ManifestEntry),
+		},
+	}
+}
+
+// Manifest tracks the outputs of the current build against the outputs of the previous build.
+// It is safe to use from multiple goroutines.
+type Manifest struct {
+	sync.Mutex
+	Previous model.Manifest
+	Current  model.Manifest
+
+	Rendered int
+	Skipped  int
+}
+
+// IsCurrent returns if every given output was produced by the previous build
+// from inputs with the same hash, and all of the outputs still exist on disk.
+// If it is current, the outputs are carried forward to the current manifest.
+func (m *Manifest) IsCurrent(outputPath, hash string, outputs ...string) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, output := range outputs {
+		entry, ok := m.Previous.Outputs[output]
+		if !ok || entry.Hash != hash {
+			return false
+		}
+		if !Exists(filepath.Join(outputPath, output)) {
+			return false
+		}
+	}
+	for _, output := range outputs {
+		m.Current.Outputs[output] = m.Previous.Outputs[output]
+	}
+	m.Skipped += len(outputs)
+	return true
+}
+
+// Record records that the given outputs were produced from inputs with a given hash.
+func (m *Manifest) Record(hash string, inputs []string, outputs ...string) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, output := range outputs {
+		m.Current.Outputs[output] = model.ManifestEntry{
+			Hash:   hash,
+			Inputs: inputs,
+		}
+	}
+	m.Rendered += len(outputs)
+}
+
+// Stale returns the outputs of the previous build that were not produced by the current build.
+func (m *Manifest) Stale() (output []string) {
+	m.Lock()
+	defer m.Unlock()
+
+	for path := range m.Previous.Outputs {
+		if _, ok := m.Current.Outputs[path]; !ok {
+			output = append(output, path)
+		}
+	}
+	sort.Strings(output)
+	return
+}
+
+// NewInputHash returns a new input hash.
+func NewInputHash() *InputHash {
+	return &InputHash{
+		hash: md5.New(),
+	}
+}
+
+// InputHash is a running hash of the inputs for a set of outputs.
+type InputHash struct {
+	hash   hash.Hash
+	Inputs []string
+}
+
+// AddFile adds the contents of a file to the hash.
+func (ih *InputHash) AddFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return ex.New(err)
+	}
+	defer f.Close()
+	io.WriteString(ih.hash, path)
+	if _, err := io.Copy(ih.hash, f); err != nil {
+		return ex.New(err)
+	}
+	ih.Inputs = append(ih.Inputs, path)
+	return nil
+}
+
+// AddIndexedFile adds a file to the hash by its etag from the source index,
+// so large files that haven't changed aren't read again.
+func (ih *InputHash) AddIndexedFile(path, etag string) {
+	io.WriteString(ih.hash, path)
+	io.WriteString(ih.hash, etag)
+	ih.Inputs = append(ih.Inputs, path)
+}
+
+// AddString adds a string value to the hash.
+func (ih *InputHash) AddString(value string) {
+	io.WriteString(ih.hash, value)
+}
+
+// AddValue adds the json representation of a value to the hash.
+func (ih *InputHash) AddValue(value interface{}) error {
+	return ex.New(json.NewEncoder(ih.hash).Encode(value))
+}
+
+// Sum returns the current hash as a hex string.
+func (ih *InputHash) Sum() string {
+	return hex.EncodeToString(ih.hash.Sum(nil))
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"gopkg.in/yaml.v3"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// markdown is the markdown renderer for markdown text posts.
+//
+// It supports github flavored markdown (tables, strikethrough, autolinks and task lists),
+// footnotes, fenced code blocks and ids on headings so they can be linked to.
+// Raw html in posts is passed through as is.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		extension.Footnote,
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+	),
+	goldmark.WithRendererOptions(
+		html.WithUnsafe(),
+	),
+)
+
+// frontMatterDelimiter delimits the yaml front matter at the start of a markdown post.
+var frontMatterDelimiter = []byte("---")
+
+// SplitFrontMatter splits the yaml front matter from the body of a markdown post.
+// The front matter must start on the first line of the contents and be delimited by lines of `---`.
+// If there is no front matter, the front matter returned is empty and the body is the full contents.
+func SplitFrontMatter(contents []byte) (frontMatter, body []byte) {
+	contents = bytes.TrimPrefix(contents, []byte("\xef\xbb\xbf"))
+	firstLine, rest := splitLine(contents)
+	if !bytes.Equal(bytes.TrimSpace(firstLine), frontMatterDelimiter) {
+		body = contents
+		return
+	}
+	for offset := 0; offset < len(rest); {
+		line, remaining := splitLine(rest[offset:])
+		if bytes.Equal(bytes.TrimSpace(line), frontMatterDelimiter) {
+			frontMatter = rest[:offset]
+			body = remaining
+			return
+		}
+		offset = len(rest) - len(remaining)
+	}
+	// no closing delimiter; treat the whole file as the body.
+	body = contents
+	return
+}
+
+// splitLine returns the first line of the contents (without the newline), and everything after it.
+func splitLine(contents []byte) (line, rest []byte) {
+	if index := bytes.IndexByte(contents, '\n'); index >= 0 {
+		return contents[:index], contents[index+1:]
+	}
+	return contents, nil
+}
+
+// ReadFrontMatter reads the front matter of a markdown post into the post meta.
+// Fields in the front matter take precedence over fields already set on the meta.
+func ReadFrontMatter(path string, meta *model.Meta) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ex.New(err)
+	}
+	frontMatter, _ := SplitFrontMatter(contents)
+	if len(bytes.TrimSpace(frontMatter)) == 0 {
+		return nil
+	}
+	if err := yaml.Unmarshal(frontMatter, meta); err != nil {
+		return ex.New(err).WithMessagef("front matter path: %s", path)
+	}
+	return nil
+}
+
+// RenderMarkdown renders markdown to html.
+func RenderMarkdown(contents []byte) (string, error) {
+	buffer := new(bytes.Buffer)
+	if err := markdown.Convert(contents, buffer); err != nil {
+		return "", ex.New(err)
+	}
+	return buffer.String(), nil
+}
+
+// CompileMarkdown reads a markdown post and renders its body to html.
+//
+// It returns the rendered html, and a template that writes the html as is, so markdown
+// posts can be rendered with `render_post` like html text posts. Markdown posts are not
+// themselves parsed as templates, so code blocks can contain template delimiters.
+func (e Engine) CompileMarkdown(markdownPath string) (contents string, final *template.Template, err error) {
+	fileContents, fileErr := ioutil.ReadFile(markdownPath)
+	if fileErr != nil {
+		err = ex.New(fileErr).WithMessagef("markdown path: %s", markdownPath)
+		return
+	}
+	_, body := SplitFrontMatter(fileContents)
+	contents, err = RenderMarkdown(body)
+	if err != nil {
+		err = ex.New(err).WithMessagef("markdown path: %s", markdownPath)
+		return
+	}
+	rendered := template.HTML(contents)
+	final, err = template.New(markdownPath).Funcs(template.FuncMap{
+		"contents": func() template.HTML { return rendered },
+	}).Parse("{{ contents }}")
+	if err != nil {
+		err = ex.New(err).WithMessagef("markdown path: %s", markdownPath)
+	}
+	return
+}
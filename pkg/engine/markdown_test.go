@@ -0,0 +1,27 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	assert := assert.New(t)
+
+	frontMatter, body := SplitFrontMatter([]byte("---\ntitle: test\n---\n# Body\n"))
+	assert.Equal("title: test\n", string(frontMatter))
+	assert.Equal("# Body\n", string(body))
+
+	frontMatter, body = SplitFrontMatter([]byte("---\r\ntitle: test\r\n---\r\n# Body\r\n"))
+	assert.Equal("title: test\r\n", string(frontMatter))
+	assert.Equal("# Body\r\n", string(body))
+
+	frontMatter, body = SplitFrontMatter([]byte("# Body\n---\n"))
+	assert.Empty(frontMatter)
+	assert.Equal("# Body\n---\n", string(body))
+
+	frontMatter, body = SplitFrontMatter([]byte("---\ntitle: unterminated\n"))
+	assert.Empty(frontMatter)
+	assert.Equal("---\ntitle: unterminated\n", string(body))
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"sync"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// NewMemoryBudget returns a new memory budget of a given number of bytes.
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	mb := &MemoryBudget{Limit: limit}
+	mb.available = sync.NewCond(&mb.Mutex)
+	return mb
+}
+
+// MemoryBudget limits how much memory the decoded images being processed take at once.
+//
+// Memory is reserved before an image is decoded and released when it's done with; reserving
+// waits until there's room, so large images are processed with less parallelism.
+// It is safe to use from multiple goroutines, and a nil budget doesn't limit anything.
+type MemoryBudget struct {
+	sync.Mutex
+	Limit int64
+
+	available *sync.Cond
+	reserved  int64
+	peak      int64
+}
+
+// Reserve waits until a given number of bytes fit in the budget and reserves them,
+// returning the number of bytes reserved to release later.
+//
+// More than the limit can't ever fit, so it waits for the budget to be empty and reserves the limit.
+func (mb *MemoryBudget) Reserve(bytes int64) int64 {
+	if mb == nil || bytes <= 0 {
+		return 0
+	}
+	if bytes > mb.Limit {
+		bytes = mb.Limit
+	}
+	mb.Lock()
+	defer mb.Unlock()
+	for mb.reserved+bytes > mb.Limit {
+		mb.available.Wait()
+	}
+	mb.reserved += bytes
+	if mb.reserved > mb.peak {
+		mb.peak = mb.reserved
+	}
+	return bytes
+}
+
+// Release releases a number of bytes returned by `Reserve`.
+func (mb *MemoryBudget) Release(bytes int64) {
+	if mb == nil || bytes <= 0 {
+		return
+	}
+	mb.Lock()
+	defer mb.Unlock()
+	mb.reserved -= bytes
+	mb.available.Broadcast()
+}
+
+// Peak returns the most bytes that have been reserved at once.
+func (mb *MemoryBudget) Peak() int64 {
+	if mb == nil {
+		return 0
+	}
+	mb.Lock()
+	defer mb.Unlock()
+	return mb.peak
+}
+
+// ImageMemory returns roughly how much memory processing an image takes; the contents of
+// the file, the image decoded, every frame of a gif, the largest of the thumbnails derived
+// from it for each of the sizes being generated along with a copy to sharpen, orient and
+// encode it, and for each of a number of image variants a crop as large as the image along
+// with a sharpened copy.
+func ImageMemory(original model.Image, sizes []int, variants int) int64 {
+	pixels := int64(original.Width) * int64(original.Height)
+	memory := pixels
+	for _, size := range sizes {
+		thumbnail := int64(size) * int64(size)
+		if thumbnail > pixels {
+			thumbnail = pixels
+		}
+		memory += 2 * thumbnail
+	}
+	memory += 2 * int64(variants) * pixels
+	// gif frames are paletted, so they take a byte per pixel.
+	return memory*constants.BytesPerPixel + int64(original.Frames)*pixels + original.FileSize
+}
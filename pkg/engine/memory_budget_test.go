@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	assert := assert.New(t)
+
+	mb := NewMemoryBudget(100)
+	assert.Equal(60, mb.Reserve(60))
+
+	reserved := make(chan int64)
+	go func() {
+		reserved <- mb.Reserve(50)
+	}()
+	select {
+	case <-reserved:
+		assert.FailNow("reserving more than fits should wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+	mb.Release(60)
+	assert.Equal(50, <-reserved)
+	mb.Release(50)
+
+	// more than the limit waits for the budget to be empty and reserves the limit.
+	assert.Equal(100, mb.Reserve(500))
+	mb.Release(100)
+	assert.Equal(100, mb.Peak())
+
+	var unlimited *MemoryBudget
+	assert.Zero(unlimited.Reserve(500))
+	unlimited.Release(500)
+}
+
+func TestImageMemory(t *testing.T) {
+	assert := assert.New(t)
+
+	// the image, and two copies of each thumbnail no larger than the image.
+	assert.Equal((100*100+2*50*50+2*100*100)*4, ImageMemory(model.Image{Width: 100, Height: 100}, []int{50, 200}, 0))
+	// two copies of the image for each variant, a byte per pixel for each gif frame, and the file contents.
+	assert.Equal((100*100+2*50*50+2*100*100+2*2*100*100)*4, ImageMemory(model.Image{Width: 100, Height: 100}, []int{50, 200}, 2))
+	assert.Equal(100*100*4+3*100*100+5000, ImageMemory(model.Image{Width: 100, Height: 100, Frames: 3, FileSize: 5000}, nil, 0))
+}
+
+func TestDownscaleImage(t *testing.T) {
+	assert := assert.New(t)
+
+	original := image.NewRGBA(image.Rect(0, 0, 3000, 2001))
+	direct := DownscaleImage(original, original.Bounds(), 500, config.ImageEncoding{})
+	progressive := DownscaleImage(DownscaleImage(original, original.Bounds(), 1000, config.ImageEncoding{}), original.Bounds(), 500, config.ImageEncoding{})
+	assert.Equal(image.Pt(500, 333), direct.Bounds().Size())
+	assert.Equal(direct.Bounds().Size(), progressive.Bounds().Size())
+
+	// images are never upscaled.
+	assert.Equal(image.Pt(3000, 2001), DownscaleImage(original, original.Bounds(), 4000, config.ImageEncoding{}).Bounds().Size())
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/exif"
+	"github.com/wcharczuk/blogctl/pkg/gifmeta"
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/pngmeta"
+	"github.com/wcharczuk/blogctl/pkg/webpmeta"
+)
+
+// Errors
+const (
+	ErrInvalidMetadataPolicy    ex.Class = "invalid metadata policy; must be one of keep, strip or allowlist"
+	ErrInvalidPrivacyZoneAction ex.Class = "invalid privacy zone action; must be one of strip or round"
+	ErrUnsupportedMetadata      ex.Class = "unsupported image format; can't rewrite its metadata"
+)
+
+// EarthRadius is the mean radius of the earth in meters.
+const EarthRadius = 6371008.8
+
+// RewriteMetadata rewrites the metadata of a published original image per the metadata
+// policy and the privacy zones; jpegs by segment, pngs and webps by chunk and gifs by block.
+//
+// The exif data of pngs and webps is in a chunk of its own, so that's what's checked against the privacy zones.
+func (e Engine) RewriteMetadata(format string, contents []byte) ([]byte, error) {
+	switch format {
+	case constants.ImageFormatJPEG:
+		filter, err := e.MetadataFilter(contents)
+		if err != nil {
+			return nil, err
+		}
+		return jpegmeta.Rewrite(contents, filter)
+	case constants.ImageFormatPNG:
+		chunks, err := pngmeta.Split(contents)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := e.MetadataFilter(pngmeta.Exif(chunks))
+		if err != nil {
+			return nil, err
+		}
+		return pngmeta.Rewrite(contents, filter)
+	case constants.ImageFormatWebP:
+		chunks, err := webpmeta.Split(contents)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := e.MetadataFilter(webpmeta.Exif(chunks))
+		if err != nil {
+			return nil, err
+		}
+		return webpmeta.Rewrite(contents, filter)
+	case constants.ImageFormatGIF:
+		filter, err := e.MetadataFilter(nil)
+		if err != nil {
+			return nil, err
+		}
+		return gifmeta.Rewrite(contents, filter)
+	default:
+		return nil, ex.New(ErrUnsupportedMetadata, ex.OptMessagef("format: %s", format))
+	}
+}
+
+// MetadataFilter returns the filter for the metadata of a published original image
+// per the metadata policy and the privacy zones, given the image or its exif data.
+func (e Engine) MetadataFilter(contents []byte) (filter jpegmeta.Filter, err error) {
+	switch policy := e.Config.Metadata.PolicyOrDefault(); policy {
+	case constants.MetadataPolicyKeep:
+	case constants.MetadataPolicyStrip:
+		filter.Tag = AllowFields()
+		filter.StripExtended = true
+	case constants.MetadataPolicyAllowlist:
+		filter.Tag = AllowFields(e.Config.Metadata.AllowOrDefault()...)
+		filter.StripExtended = true
+	default:
+		err = ex.New(ErrInvalidMetadataPolicy, ex.OptMessagef("policy: %s", policy))
+		return
+	}
+
+	rawExifData, decodeErr := exif.Decode(bytes.NewReader(contents))
+	if decodeErr != nil {
+		return
+	}
+	latitude, longitude, locationErr := rawExifData.LatLong()
+	if locationErr != nil {
+		return
+	}
+	zone, ok := e.PrivacyZone(latitude, longitude)
+	if !ok {
+		return
+	}
+	// xmp can hold the location too, and can't be filtered by tag.
+	filter.StripExtended = true
+	switch action := zone.ActionOrDefault(); action {
+	case constants.PrivacyZoneActionStrip:
+		filter.GPS = jpegmeta.GPSStrip
+	case constants.PrivacyZoneActionRound:
+		filter.GPS = jpegmeta.GPSRound
+		filter.Precision = zone.PrecisionOrDefault()
+	default:
+		err = ex.New(ErrInvalidPrivacyZoneAction, ex.OptMessagef("zone: %s, action: %s", zone.Name, action))
+	}
+	return
+}
+
+// LocateImage returns the exif data of an image of a post with the location from the
+// post meta, if it is set, and redacted per the metadata config.
+func (e Engine) LocateImage(meta model.Meta, data model.Exif) model.Exif {
+	if meta.HasLocation() {
+		data.Latitude = meta.Latitude
+		data.Longitude = meta.Longitude
+		data.Altitude = meta.Altitude
+	}
+	return e.RedactLocation(data)
+}
+
+// RedactLocation returns exif data with the gps location removed or rounded
+// the same way it is in the published original, per the metadata config.
+func (e Engine) RedactLocation(data model.Exif) model.Exif {
+	if !data.HasLocation() {
+		return data
+	}
+	if !e.PublishesLocation() {
+		data.Latitude, data.Longitude, data.Altitude = 0, 0, 0
+		return data
+	}
+	zone, ok := e.PrivacyZone(data.Latitude, data.Longitude)
+	if !ok {
+		return data
+	}
+	if zone.ActionOrDefault() == constants.PrivacyZoneActionRound {
+		scale := math.Pow10(zone.PrecisionOrDefault())
+		data.Latitude = math.Round(data.Latitude*scale) / scale
+		data.Longitude = math.Round(data.Longitude*scale) / scale
+		data.Altitude = 0
+		return data
+	}
+	data.Latitude, data.Longitude, data.Altitude = 0, 0, 0
+	return data
+}
+
+// PublishesLocation returns if the metadata policy keeps the gps location of published originals.
+func (e Engine) PublishesLocation() bool {
+	switch e.Config.Metadata.PolicyOrDefault() {
+	case constants.MetadataPolicyKeep:
+		return true
+	case constants.MetadataPolicyAllowlist:
+		allow := AllowFields(e.Config.Metadata.AllowOrDefault()...)
+		return allow(exif.GPSLatitude) && allow(exif.GPSLongitude)
+	default:
+		return false
+	}
+}
+
+// PrivacyZone returns the first privacy zone a location is in, if any.
+func (e Engine) PrivacyZone(latitude, longitude float64) (config.PrivacyZone, bool) {
+	for _, zone := range e.Config.Metadata.PrivacyZones {
+		if Distance(latitude, longitude, zone.Latitude, zone.Longitude) <= zone.RadiusOrDefault() {
+			return zone, true
+		}
+	}
+	return config.PrivacyZone{}, false
+}
+
+// AllowFields returns a tag filter that keeps the given exif fields.
+// The orientation is always kept so images are displayed upright.
+func AllowFields(fields ...string) func(exif.FieldName) bool {
+	allowed := map[exif.FieldName]bool{
+		exif.Orientation: true,
+	}
+	for _, field := range fields {
+		allowed[exif.FieldName(field)] = true
+	}
+	return func(name exif.FieldName) bool {
+		return allowed[name]
+	}
+}
+
+// Distance returns the great circle distance in meters between two locations in decimal degrees.
+func Distance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	deltaLatitude := toRadians(latitude2 - latitude1)
+	deltaLongitude := toRadians(longitude2 - longitude1)
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(toRadians(latitude1))*math.Cos(toRadians(latitude2))*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,223 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"golang.org/x/image/webp"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/exif"
+	"github.com/wcharczuk/blogctl/pkg/gifmeta"
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/pngmeta"
+	"github.com/wcharczuk/blogctl/pkg/webpmeta"
+)
+
+func TestDistance(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Zero(Distance(37.7749, -122.4194, 37.7749, -122.4194))
+	// san francisco to los angeles is roughly 559km.
+	distance := Distance(37.7749, -122.4194, 34.0522, -118.2437)
+	assert.True(math.Abs(distance-559000) < 1000, distance)
+}
+
+func TestEnginePrivacyZone(t *testing.T) {
+	assert := assert.New(t)
+
+	e := Engine{
+		Config: config.Config{
+			Metadata: config.Metadata{
+				PrivacyZones: []config.PrivacyZone{
+					{Name: "home", Latitude: 37.7749, Longitude: -122.4194, Radius: 500},
+					{Name: "city", Latitude: 37.7749, Longitude: -122.4194, Radius: 10000},
+				},
+			},
+		},
+	}
+
+	zone, ok := e.PrivacyZone(37.7760, -122.4200)
+	assert.True(ok)
+	assert.Equal("home", zone.Name)
+	zone, ok = e.PrivacyZone(37.8000, -122.4194)
+	assert.True(ok)
+	assert.Equal("city", zone.Name)
+	_, ok = e.PrivacyZone(34.0522, -118.2437)
+	assert.False(ok)
+}
+
+func TestEngineRedactLocation(t *testing.T) {
+	assert := assert.New(t)
+
+	data := model.Exif{Latitude: 37.7749, Longitude: -122.4194, Altitude: 52}
+
+	e := Engine{}
+	assert.Equal(data, e.RedactLocation(data))
+
+	e.Config.Metadata.Policy = constants.MetadataPolicyStrip
+	assert.False(e.RedactLocation(data).HasLocation())
+
+	e.Config.Metadata.Policy = constants.MetadataPolicyAllowlist
+	assert.False(e.RedactLocation(data).HasLocation())
+	e.Config.Metadata.Allow = []string{"GPSLatitude", "GPSLongitude"}
+	assert.Equal(data, e.RedactLocation(data))
+
+	e.Config.Metadata.PrivacyZones = []config.PrivacyZone{
+		{Latitude: 37.7749, Longitude: -122.4194, Action: constants.PrivacyZoneActionRound},
+	}
+	rounded := e.RedactLocation(data)
+	assert.Equal(37.77, rounded.Latitude)
+	assert.Equal(-122.42, rounded.Longitude)
+	assert.Zero(rounded.Altitude)
+
+	e.Config.Metadata.PrivacyZones[0].Action = constants.PrivacyZoneActionStrip
+	assert.False(e.RedactLocation(data).HasLocation())
+}
+
+func TestEngineMetadataFilterPrivacyZone(t *testing.T) {
+	assert := assert.New(t)
+
+	// b.jpg is geotagged at 37.7749, -122.4194; add xmp with the same location.
+	contents, err := ioutil.ReadFile("testdata/posts/2019-02-08-gallery-post/b.jpg")
+	assert.Nil(err)
+	xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"><rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" exif:GPSLatitude="37,46.494N" exif:GPSLongitude="122,25.164W"/></rdf:RDF></x:xmpmeta>`
+	segments, scan, err := jpegmeta.Split(contents)
+	assert.Nil(err)
+	segments = append(segments, jpegmeta.Segment{Marker: jpegmeta.MarkerAPP1, Data: []byte(jpegmeta.HeaderXMP + xmp)})
+	contents, err = jpegmeta.Join(segments, scan)
+	assert.Nil(err)
+
+	hasXMP := func(contents []byte) bool {
+		segments, _, err := jpegmeta.Split(contents)
+		assert.Nil(err)
+		for _, segment := range segments {
+			if segment.IsXMP() {
+				return true
+			}
+		}
+		return false
+	}
+
+	// outside of a privacy zone the xmp is kept with the keep policy.
+	e := Engine{}
+	filter, err := e.MetadataFilter(contents)
+	assert.Nil(err)
+	assert.True(filter.IsKeepAll())
+
+	e.Config.Metadata.PrivacyZones = []config.PrivacyZone{
+		{Latitude: 37.7749, Longitude: -122.4194, Action: constants.PrivacyZoneActionRound},
+	}
+	filter, err = e.MetadataFilter(contents)
+	assert.Nil(err)
+	assert.True(filter.StripExtended)
+	rewritten, err := jpegmeta.Rewrite(contents, filter)
+	assert.Nil(err)
+	assert.True(hasXMP(contents))
+	assert.False(hasXMP(rewritten))
+}
+
+func TestEngineRewriteMetadata(t *testing.T) {
+	assert := assert.New(t)
+
+	// the exif data of b.jpg is geotagged at 37.7749, -122.4194.
+	contents, err := ioutil.ReadFile("testdata/posts/2019-02-08-gallery-post/b.jpg")
+	assert.Nil(err)
+	segments, _, err := jpegmeta.Split(contents)
+	assert.Nil(err)
+	var rawExif []byte
+	for _, segment := range segments {
+		if segment.IsExif() {
+			rawExif = segment.Data[len(jpegmeta.HeaderExif):]
+		}
+	}
+	assert.NotEmpty(rawExif)
+	xmp := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`)
+
+	pngContents, err := ioutil.ReadFile("testdata/posts/2019-02-07-formats-post/1.png")
+	assert.Nil(err)
+	pngChunks, err := pngmeta.Split(pngContents)
+	assert.Nil(err)
+	pngChunks = append(pngChunks[:1], append([]pngmeta.Chunk{
+		{Type: pngmeta.ChunkExif, Data: rawExif},
+		{Type: pngmeta.ChunkInternationalText, Data: append([]byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"), xmp...)},
+	}, pngChunks[1:]...)...)
+	pngContents = pngmeta.Join(pngChunks)
+
+	webpContents, err := ioutil.ReadFile("testdata/posts/2019-02-07-formats-post/3.webp")
+	assert.Nil(err)
+	webpConfig, err := webp.DecodeConfig(bytes.NewReader(webpContents))
+	assert.Nil(err)
+	webpChunks, err := webpmeta.Split(webpContents)
+	assert.Nil(err)
+	extended := make([]byte, 10)
+	extended[0] = webpmeta.FlagExif | webpmeta.FlagXMP
+	extended[4], extended[5] = byte(webpConfig.Width-1), byte((webpConfig.Width-1)>>8)
+	extended[7], extended[8] = byte(webpConfig.Height-1), byte((webpConfig.Height-1)>>8)
+	webpChunks = append([]webpmeta.Chunk{{FourCC: webpmeta.ChunkExtended, Data: extended}}, webpChunks...)
+	webpChunks = append(webpChunks, webpmeta.Chunk{FourCC: webpmeta.ChunkExif, Data: rawExif}, webpmeta.Chunk{FourCC: webpmeta.ChunkXMP, Data: xmp})
+	webpContents = webpmeta.Join(webpChunks)
+
+	gifContents, err := ioutil.ReadFile("testdata/posts/2019-02-07-formats-post/2.gif")
+	assert.Nil(err)
+	gifHeader, gifBlocks, err := gifmeta.Split(gifContents)
+	assert.Nil(err)
+	gifBlocks = append([]gifmeta.Block{{Contents: []byte("\x21\xfe\x09a comment\x00")}}, gifBlocks...)
+	gifContents = gifmeta.Join(gifHeader, gifBlocks)
+
+	// the location is stripped in a privacy zone, as is the xmp, and the images still decode.
+	e := Engine{}
+	e.Config.Metadata.PrivacyZones = []config.PrivacyZone{
+		{Latitude: 37.7749, Longitude: -122.4194},
+	}
+
+	rewritten, err := e.RewriteMetadata(constants.ImageFormatPNG, pngContents)
+	assert.Nil(err)
+	_, _, err = image.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+	chunks, err := pngmeta.Split(rewritten)
+	assert.Nil(err)
+	for _, chunk := range chunks {
+		assert.False(chunk.IsText())
+	}
+	rawExifData, err := exif.Decode(bytes.NewReader(pngmeta.Exif(chunks)))
+	assert.Nil(err)
+	_, _, err = rawExifData.LatLong()
+	assert.NotNil(err)
+
+	rewritten, err = e.RewriteMetadata(constants.ImageFormatWebP, webpContents)
+	assert.Nil(err)
+	_, _, err = image.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+	webpChunks, err = webpmeta.Split(rewritten)
+	assert.Nil(err)
+	assert.Equal(webpmeta.FlagExif, webpChunks[0].Data[0])
+	for _, chunk := range webpChunks {
+		assert.False(chunk.IsXMP())
+	}
+	rawExifData, err = exif.Decode(bytes.NewReader(webpmeta.Exif(webpChunks)))
+	assert.Nil(err)
+	_, _, err = rawExifData.LatLong()
+	assert.NotNil(err)
+
+	// gifs only have comments and xmp, which are kept unless the policy strips them.
+	rewritten, err = e.RewriteMetadata(constants.ImageFormatGIF, gifContents)
+	assert.Nil(err)
+	assert.Equal(gifContents, rewritten)
+	e.Config.Metadata.Policy = constants.MetadataPolicyStrip
+	rewritten, err = e.RewriteMetadata(constants.ImageFormatGIF, gifContents)
+	assert.Nil(err)
+	_, _, err = image.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+	_, gifBlocks, err = gifmeta.Split(rewritten)
+	assert.Nil(err)
+	for _, block := range gifBlocks {
+		assert.False(block.IsComment())
+	}
+}
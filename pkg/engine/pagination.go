@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// Paginate splits a set of posts into pages per the pagination config.
+//
+// The base path is the path of the first page relative to the output path, i.e. empty
+// for the index page or `tags/<tag>` for a tag page; subsequent pages are nested under it.
+// It returns each page and the directory each page is rendered to, relative to the output path.
+// If pagination is disabled there is a single page with every post.
+func (e Engine) Paginate(basePath string, posts []*model.Post) (pages []model.Pagination, paths []string) {
+	pageSize := e.Config.Pagination.PageSize
+	if !e.Config.Pagination.IsEnabled() || len(posts) == 0 {
+		pageSize = len(posts)
+	}
+	totalPages := 1
+	if pageSize > 0 {
+		totalPages = (len(posts) + pageSize - 1) / pageSize
+	}
+
+	var urls []string
+	for page := 1; page <= totalPages; page++ {
+		pagePath := basePath
+		if page > 1 {
+			pagePath = filepath.Join(basePath, fmt.Sprintf(e.Config.Pagination.PathFormatOrDefault(), page))
+		}
+		paths = append(paths, pagePath)
+		urls = append(urls, PageURL(pagePath))
+	}
+	for page := 1; page <= totalPages; page++ {
+		start, end := (page-1)*pageSize, page*pageSize
+		if end > len(posts) {
+			end = len(posts)
+		}
+		pages = append(pages, model.Pagination{
+			Page:       page,
+			TotalPages: totalPages,
+			Posts:      posts[start:end],
+			URLs:       urls,
+		})
+	}
+	return
+}
+
+// PageURL returns the url of a page given the directory it is rendered to,
+// relative to the output path, e.g. `/` or `/tags/foo/page/2/`.
+func PageURL(pagePath string) string {
+	pageURL := path.Join("/", filepath.ToSlash(pagePath))
+	if pageURL == "/" {
+		return pageURL
+	}
+	return pageURL + "/"
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestEnginePaginate(t *testing.T) {
+	assert := assert.New(t)
+
+	posts := make([]*model.Post, 5)
+	for index := range posts {
+		posts[index] = &model.Post{Index: index}
+	}
+
+	e := Engine{}
+	pages, paths := e.Paginate("", posts)
+	assert.Len(pages, 1)
+	assert.Equal([]string{""}, paths)
+	assert.Len(pages[0].Posts, 5)
+	assert.False(pages[0].HasNext())
+	assert.Equal("/", pages[0].URL())
+
+	e.Config = config.Config{Pagination: config.Pagination{PageSize: 2}}
+	pages, paths = e.Paginate("tags/foo", posts)
+	assert.Len(pages, 3)
+	assert.Equal([]string{"tags/foo", "tags/foo/page/2", "tags/foo/page/3"}, paths)
+	assert.Len(pages[0].Posts, 2)
+	assert.Len(pages[2].Posts, 1)
+	assert.Equal(4, pages[2].Posts[0].Index)
+
+	assert.False(pages[0].HasPrevious())
+	assert.Equal("/tags/foo/page/2/", pages[0].NextURL())
+	assert.Equal("/tags/foo/", pages[1].PreviousURL())
+	assert.Equal("/tags/foo/page/3/", pages[1].NextURL())
+	assert.False(pages[2].HasNext())
+	assert.Empty(pages[2].NextURL())
+
+	pages, paths = e.Paginate("", nil)
+	assert.Len(pages, 1)
+	assert.Equal([]string{""}, paths)
+	assert.Empty(pages[0].Posts)
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	"image"
+
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/palette"
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// Palette are the dominant colors of an image.
+type Palette struct {
+	// Colors are the dominant colors as hex strings, most dominant first.
+	Colors []string `json:"colors"`
+	// Name is the name of the basic color nearest to the most dominant color.
+	Name string `json:"name"`
+}
+
+// GeneratePalettes sets the dominant color and palette of every image of the posts.
+func (e Engine) GeneratePalettes(ctx context.Context, posts []*model.Post) error {
+	return e.ProcessSourceImages(ctx, posts, func(image *model.Image, source *SourceImage) error {
+		imagePalette, err := e.ImagePalette(ctx, source)
+		if err != nil {
+			return err
+		}
+		if len(imagePalette.Colors) > 0 {
+			image.Color = imagePalette.Colors[0]
+		}
+		image.ColorName = imagePalette.Name
+		image.Palette = imagePalette.Colors
+		return nil
+	})
+}
+
+// ImagePalette returns the palette of an image from the thumbnail cache,
+// generating and caching it if it isn't cached.
+func (e Engine) ImagePalette(ctx context.Context, source *SourceImage) (imagePalette Palette, err error) {
+	err = e.CachedImageData(ctx, source.Original, constants.FilePalette, &imagePalette, func() error {
+		logger.MaybeDebugf(e.Log, "%s: generating cached palette", source.Original.SourcePath)
+		decoded, err := source.Decode()
+		if err != nil {
+			return err
+		}
+		imagePalette = GeneratePalette(decoded)
+		return nil
+	})
+	return
+}
+
+// GeneratePalette extracts the dominant colors of an image from a downscaled copy of it.
+//
+// Transparent pixels are ignored, and for gifs the colors are of the first frame.
+func GeneratePalette(decoded image.Image) (output Palette) {
+	sample := resize.Thumbnail(constants.PaletteSampleSize, constants.PaletteSampleSize, decoded, resize.Bilinear)
+	colors := palette.Extract(sample, constants.PaletteSize)
+	for _, c := range colors {
+		output.Colors = append(output.Colors, palette.Hex(c))
+	}
+	if len(colors) > 0 {
+		output.Name = palette.Name(colors[0])
+	}
+	return
+}
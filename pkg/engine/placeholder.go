@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"mime"
+
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/blogctl/pkg/blurhash"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// Placeholder is what is drawn in place of an image while it loads.
+type Placeholder struct {
+	// BlurHash is the blurhash of the image.
+	BlurHash string `json:"blurHash"`
+	// DataURI is a tiny preview of the image as a data uri.
+	DataURI string `json:"dataURI"`
+}
+
+// GeneratePlaceholders sets the blurhash and inline preview of every image of the posts.
+func (e Engine) GeneratePlaceholders(ctx context.Context, posts []*model.Post) error {
+	return e.ProcessSourceImages(ctx, posts, func(image *model.Image, source *SourceImage) error {
+		placeholder, err := e.ImagePlaceholder(ctx, source)
+		if err != nil {
+			return err
+		}
+		image.BlurHash = placeholder.BlurHash
+		image.Placeholder = placeholder.DataURI
+		return nil
+	})
+}
+
+// ImagePlaceholder returns the placeholder of an image from the thumbnail cache,
+// generating and caching it if it isn't cached.
+func (e Engine) ImagePlaceholder(ctx context.Context, source *SourceImage) (placeholder Placeholder, err error) {
+	err = e.CachedImageData(ctx, source.Original, constants.FilePlaceholder, &placeholder, func() error {
+		logger.MaybeDebugf(e.Log, "%s: generating cached placeholder", source.Original.SourcePath)
+		decoded, err := source.Decode()
+		if err != nil {
+			return err
+		}
+		placeholder, err = GeneratePlaceholder(decoded, source.Original)
+		return err
+	})
+	return
+}
+
+// GeneratePlaceholder computes the blurhash and the inline preview of an image.
+//
+// The preview is a jpeg, or a png for images with transparency, and for gifs
+// it is of the first frame.
+func GeneratePlaceholder(decoded image.Image, original model.Image) (output Placeholder, err error) {
+	sample := Orient(resize.Thumbnail(constants.BlurHashSampleSize, constants.BlurHashSampleSize, decoded, resize.Bilinear), original.Exif.Orientation)
+	if output.BlurHash, err = blurhash.Encode(constants.BlurHashComponentsX, constants.BlurHashComponentsY, sample); err != nil {
+		return
+	}
+
+	extension := constants.ExtensionJPG
+	if original.HasAlpha {
+		extension = constants.ExtensionPNG
+	}
+	preview := resize.Thumbnail(constants.PlaceholderSize, constants.PlaceholderSize, sample, resize.Bilinear)
+	buffer := new(bytes.Buffer)
+	if err = EncodeImage(buffer, preview, extension, 0); err != nil {
+		return
+	}
+	output.DataURI = fmt.Sprintf("data:%s;base64,%s", mime.TypeByExtension(extension), base64.StdEncoding.EncodeToString(buffer.Bytes()))
+	return
+}
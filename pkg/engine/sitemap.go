@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/stringutil"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+	"github.com/wcharczuk/blogctl/pkg/sitemap"
+)
+
+// RenderSitemap writes the sitemap for the posts, tags and pages of the site.
+//
+// If there are more urls than fit in a single sitemap file, the urls are split
+// across numbered sitemap files and the sitemap is written as a sitemap index.
+func (e Engine) RenderSitemap(ctx context.Context, manifest *Manifest) error {
+	outputPath := e.Config.OutputPathOrDefault()
+	urls, err := e.SitemapURLs(ctx)
+	if err != nil {
+		return err
+	}
+
+	sitemapPath := filepath.Join(outputPath, constants.FileSitemap)
+	maxURLs := e.Config.Sitemap.MaxURLsOrDefault()
+	if len(urls) <= maxURLs {
+		logger.MaybeDebugf(e.Log, "%s: rendering sitemap", sitemapPath)
+		if err := WriteXML(sitemapPath, e.SitemapURLSet(urls)); err != nil {
+			return err
+		}
+		manifest.Record("", nil, e.OutputKey(sitemapPath))
+		return nil
+	}
+
+	index := sitemap.Index{
+		XMLNS: sitemap.Namespace,
+	}
+	for part := 0; part*maxURLs < len(urls); part++ {
+		partURLs := urls[part*maxURLs:]
+		if len(partURLs) > maxURLs {
+			partURLs = partURLs[:maxURLs]
+		}
+		partName := fmt.Sprintf(constants.SitemapPartFormat, part+1)
+		partPath := filepath.Join(outputPath, partName)
+		logger.MaybeDebugf(e.Log, "%s: rendering sitemap", partPath)
+		if err := WriteXML(partPath, e.SitemapURLSet(partURLs)); err != nil {
+			return err
+		}
+		manifest.Record("", nil, e.OutputKey(partPath))
+		index.Sitemaps = append(index.Sitemaps, sitemap.Entry{
+			Loc:     AbsoluteURL(e.Config.BaseURLOrDefault(), partName),
+			LastMod: sitemapLastMod(partURLs),
+		})
+	}
+	logger.MaybeDebugf(e.Log, "%s: rendering sitemap index", sitemapPath)
+	if err := WriteXML(sitemapPath, index); err != nil {
+		return err
+	}
+	manifest.Record("", nil, e.OutputKey(sitemapPath))
+	return nil
+}
+
+// SitemapURLs returns the sitemap urls for the pages, posts and tags of the site.
+//
+// The last modified time of a post is the time its files were last modified, or
+// the posted date if that is unknown. Pages and tags list posts, and are as new
+// as the newest post they list.
+func (e Engine) SitemapURLs(ctx context.Context) ([]sitemap.URL, error) {
+	renderContext := GetRenderContext(ctx)
+	baseURL := e.Config.BaseURLOrDefault()
+
+	var output []sitemap.URL
+	pages, err := ListDirectory(e.Config.PagesPathOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	siteLastMod := postsLastMod(renderContext.Data.Posts)
+	for _, page := range pages {
+		pagePath := page.Name()
+		if pagePath == constants.FileIndex {
+			pagePath = ""
+		}
+		lastMod := siteLastMod
+		if page.ModTime().After(lastMod) {
+			lastMod = page.ModTime()
+		}
+		output = append(output, sitemap.URL{
+			Loc:     AbsoluteURL(baseURL, pagePath),
+			LastMod: formatLastMod(lastMod),
+		})
+		if page.Name() == constants.FileIndex {
+			_, paginationPaths := e.Paginate("", renderContext.Data.Posts)
+			for _, paginationPath := range paginationPaths[1:] {
+				output = append(output, sitemap.URL{
+					Loc:     AbsoluteURL(baseURL, PageURL(paginationPath)),
+					LastMod: formatLastMod(lastMod),
+				})
+			}
+		}
+	}
+
+	for _, post := range renderContext.Data.Posts {
+		url := sitemap.URL{
+			Loc:     AbsoluteURL(baseURL, post.Slug) + "/",
+			LastMod: formatLastMod(postLastMod(post)),
+		}
+		if !e.Config.Sitemap.SkipImages {
+			for _, image := range post.Images {
+				url.Images = append(url.Images, sitemap.Image{
+					Loc: AbsoluteURL(baseURL, e.SitemapImagePath(image)),
+				})
+			}
+		}
+		output = append(output, url)
+	}
+
+	if !e.Config.SkipGenerateTags {
+		if tagTemplatePath := e.Config.TagTemplateOrDefault(); len(tagTemplatePath) > 0 && Exists(tagTemplatePath) {
+			for _, tag := range renderContext.Data.Tags {
+				_, paginationPaths := e.Paginate(filepath.Join("tags", stringutil.Slugify(tag.Tag)), tag.Posts)
+				for _, paginationPath := range paginationPaths {
+					output = append(output, sitemap.URL{
+						Loc:     AbsoluteURL(baseURL, PageURL(paginationPath)),
+						LastMod: formatLastMod(postsLastMod(tag.Posts)),
+					})
+				}
+			}
+		}
+	}
+	return output, nil
+}
+
+// SitemapImagePath returns the path of an image as listed in the sitemap,
+// that is the original if it is published, otherwise the largest thumbnail.
+func (e Engine) SitemapImagePath(image model.Image) string {
+	if !e.Config.SkipCopyOriginalImage {
+		return image.PathOriginal()
+	}
+	var largest int
+	for _, size := range e.Config.ImageSizesOrDefault() {
+		if size > largest {
+			largest = size
+		}
+	}
+	return image.PathForSize(largest)
+}
+
+// SitemapURLSet returns a sitemap for a given set of urls.
+func (e Engine) SitemapURLSet(urls []sitemap.URL) sitemap.URLSet {
+	output := sitemap.URLSet{
+		XMLNS: sitemap.Namespace,
+		URLs:  urls,
+	}
+	for _, url := range urls {
+		if len(url.Images) > 0 {
+			output.XMLNSImage = sitemap.ImageNamespace
+			break
+		}
+	}
+	return output
+}
+
+// RenderRobots writes the robots.txt file, unless there is one in the statics path.
+func (e Engine) RenderRobots(manifest *Manifest) error {
+	if staticPath := filepath.Join(e.Config.StaticsPathOrDefault(), constants.FileRobots); Exists(staticPath) {
+		logger.MaybeDebugf(e.Log, "%s: using robots from statics", staticPath)
+		return nil
+	}
+	robotsPath := filepath.Join(e.Config.OutputPathOrDefault(), constants.FileRobots)
+	logger.MaybeDebugf(e.Log, "%s: rendering robots", robotsPath)
+	if err := ioutil.WriteFile(robotsPath, e.Robots(), 0644); err != nil {
+		return ex.New(err)
+	}
+	manifest.Record("", nil, e.OutputKey(robotsPath))
+	return nil
+}
+
+// Robots returns the contents of the robots.txt file.
+//
+// A rule without any allow or disallow paths allows everything.
+func (e Engine) Robots() []byte {
+	output := new(bytes.Buffer)
+	for index, rule := range e.Config.Robots.RulesOrDefault() {
+		if index > 0 {
+			fmt.Fprintln(output)
+		}
+		fmt.Fprintf(output, "User-agent: %s\n", rule.UserAgentOrDefault())
+		for _, path := range rule.Allow {
+			fmt.Fprintf(output, "Allow: %s\n", path)
+		}
+		for _, path := range rule.Disallow {
+			fmt.Fprintf(output, "Disallow: %s\n", path)
+		}
+		if len(rule.Allow) == 0 && len(rule.Disallow) == 0 {
+			fmt.Fprintln(output, "Disallow:")
+		}
+	}
+	if !e.Config.SkipGenerateSitemap && !e.Config.Robots.SkipSitemap {
+		fmt.Fprintf(output, "\nSitemap: %s\n", AbsoluteURL(e.Config.BaseURLOrDefault(), constants.FileSitemap))
+	}
+	return output.Bytes()
+}
+
+// postLastMod returns the last modified time of a post.
+func postLastMod(post *model.Post) time.Time {
+	if !post.ModTime.IsZero() {
+		return post.ModTime
+	}
+	return post.Meta.Posted
+}
+
+// postsLastMod returns the latest last modified time of a set of posts.
+func postsLastMod(posts []*model.Post) (latest time.Time) {
+	for _, post := range posts {
+		if lastMod := postLastMod(post); lastMod.After(latest) {
+			latest = lastMod
+		}
+	}
+	return
+}
+
+// sitemapLastMod returns the latest last modified time of a set of urls.
+func sitemapLastMod(urls []sitemap.URL) (latest string) {
+	for _, url := range urls {
+		// the lastmod values are all utc rfc3339 so they sort as strings.
+		if strings.Compare(url.LastMod, latest) > 0 {
+			latest = url.LastMod
+		}
+	}
+	return
+}
+
+// formatLastMod formats a sitemap last modified time, or returns
+// an empty string if the time is unknown.
+func formatLastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
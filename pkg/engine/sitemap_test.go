@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestEngineRobots(t *testing.T) {
+	assert := assert.New(t)
+
+	e := Engine{Config: config.Config{BaseURL: "https://example.com"}}
+	assert.Equal("User-agent: *\nDisallow:\n\nSitemap: https://example.com/sitemap.xml\n", string(e.Robots()))
+
+	e.Config.Robots = config.Robots{
+		Rules: []config.RobotsRule{
+			{Disallow: []string{"/drafts/"}},
+			{UserAgent: "BadBot", Disallow: []string{"/"}},
+		},
+		SkipSitemap: true,
+	}
+	assert.Equal("User-agent: *\nDisallow: /drafts/\n\nUser-agent: BadBot\nDisallow: /\n", string(e.Robots()))
+}
+
+func TestEngineRenderRobots(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "blogctl")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	e := Engine{Config: config.Config{
+		OutputPath:  filepath.Join(tempDir, "dist"),
+		StaticsPath: filepath.Join(tempDir, "static"),
+	}}
+	assert.Nil(MakeDir(e.Config.OutputPath))
+	assert.Nil(e.RenderRobots(NewManifest(model.Manifest{})))
+	_, err = os.Stat(filepath.Join(e.Config.OutputPath, constants.FileRobots))
+	assert.Nil(err)
+
+	// a robots.txt in the statics path takes precedence.
+	assert.Nil(os.Remove(filepath.Join(e.Config.OutputPath, constants.FileRobots)))
+	assert.Nil(MakeDir(e.Config.StaticsPath))
+	assert.Nil(WriteFile(filepath.Join(e.Config.StaticsPath, constants.FileRobots), []byte("User-agent: *\n")))
+	manifest := NewManifest(model.Manifest{})
+	assert.Nil(e.RenderRobots(manifest))
+	_, err = os.Stat(filepath.Join(e.Config.OutputPath, constants.FileRobots))
+	assert.True(os.IsNotExist(err))
+	assert.Empty(manifest.Current.Outputs)
+}
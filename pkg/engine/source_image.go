@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/gif"
+	"io/ioutil"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// NewSourceImage returns the source of an image of a post.
+//
+// The source reserves memory from the memory budget of the engine for the image and its
+// thumbnails when it's first read, which is released when the source is closed.
+func (e Engine) NewSourceImage(ctx context.Context, original model.Image) *SourceImage {
+	return &SourceImage{
+		Original: original,
+		Budget:   e.MemoryBudget,
+		Stats:    GetBuildStats(ctx),
+		Memory:   ImageMemory(original, e.Config.ImageSizesOrDefault(), len(e.Config.ImageVariants)),
+	}
+}
+
+// SourceImage is an image of a post that's read and decoded at most once, when it's first
+// needed, so the data computed from it and its thumbnails share a single decoded copy.
+//
+// Sources aren't safe to use from multiple goroutines until they're decoded, after which
+// the decoded image can be read from many goroutines.
+type SourceImage struct {
+	Original model.Image
+	Budget   *MemoryBudget
+	Stats    *BuildStats
+	// Memory is how many bytes to reserve for the image.
+	Memory int64
+
+	contents []byte
+	decoded  image.Image
+	reserved int64
+}
+
+// Contents returns the contents of the image file.
+func (si *SourceImage) Contents() ([]byte, error) {
+	if si.contents != nil {
+		return si.contents, nil
+	}
+	si.reserve()
+	started := time.Now()
+	contents, err := ioutil.ReadFile(si.Original.SourcePath)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	si.Stats.Work("read", started)
+	si.contents = contents
+	return contents, nil
+}
+
+// Decode returns the decoded image, or the first frame of a gif.
+func (si *SourceImage) Decode() (image.Image, error) {
+	if si.decoded != nil {
+		return si.decoded, nil
+	}
+	contents, err := si.Contents()
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	decoded, _, err := image.Decode(bytes.NewReader(contents))
+	if err != nil {
+		return nil, ex.New(err).WithMessagef("image path: %s", si.Original.SourcePath)
+	}
+	si.Stats.Work("decode", started)
+	si.decoded = decoded
+	return decoded, nil
+}
+
+// DecodeAnimation returns every frame of a gif decoded.
+// The frames aren't kept, so they're decoded every time.
+func (si *SourceImage) DecodeAnimation() (*gif.GIF, error) {
+	contents, err := si.Contents()
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	animation, err := gif.DecodeAll(bytes.NewReader(contents))
+	if err != nil {
+		return nil, ex.New(err).WithMessagef("image path: %s", si.Original.SourcePath)
+	}
+	si.Stats.Work("decode", started)
+	return animation, nil
+}
+
+// Close drops the contents and decoded copy of the image, and releases the memory reserved for them.
+func (si *SourceImage) Close() {
+	si.contents = nil
+	si.decoded = nil
+	si.Budget.Release(si.reserved)
+	si.reserved = 0
+}
+
+func (si *SourceImage) reserve() {
+	if si.reserved == 0 {
+		si.reserved = si.Budget.Reserve(si.Memory)
+	}
+}
@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/fileutil"
+	"github.com/blend/go-sdk/logger"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// NewSourceIndex returns a new source index persisted at a given path.
+// The index is read from disk when it's first used.
+func NewSourceIndex(path string) *SourceIndex {
+	return &SourceIndex{
+		Path: path,
+	}
+}
+
+// SourceIndex tracks the etags and metadata of the images of the posts by path, size
+// and modification time, so images that haven't changed aren't read again.
+// It is safe to use from multiple goroutines, and a nil index indexes nothing.
+type SourceIndex struct {
+	sync.Mutex
+	Path string
+	Log  logger.Log
+
+	index   model.SourceIndex
+	loaded  bool
+	changed bool
+}
+
+// Get returns the entry for a path if the file hasn't changed since it was indexed.
+func (si *SourceIndex) Get(path string, info os.FileInfo) (entry model.SourceIndexEntry, ok bool) {
+	if si == nil {
+		return
+	}
+	si.Lock()
+	defer si.Unlock()
+	si.load()
+	entry, ok = si.index.Entries[filepath.Clean(path)]
+	if ok && !entry.IsCurrent(info) {
+		return model.SourceIndexEntry{}, false
+	}
+	return
+}
+
+// Set sets the entry for a path.
+func (si *SourceIndex) Set(path string, entry model.SourceIndexEntry) {
+	if si == nil {
+		return
+	}
+	si.Lock()
+	defer si.Unlock()
+	si.load()
+	si.index.Entries[filepath.Clean(path)] = entry
+	si.changed = true
+}
+
+// Prune removes the entries for paths that aren't in a given set of paths.
+func (si *SourceIndex) Prune(paths map[string]bool) {
+	if si == nil {
+		return
+	}
+	si.Lock()
+	defer si.Unlock()
+	si.load()
+	for path := range si.index.Entries {
+		if !paths[path] {
+			delete(si.index.Entries, path)
+			si.changed = true
+		}
+	}
+}
+
+// Reset removes every entry, so every image is read again.
+func (si *SourceIndex) Reset() {
+	if si == nil {
+		return
+	}
+	si.Lock()
+	defer si.Unlock()
+	si.index = model.SourceIndex{Entries: make(map[string]model.SourceIndexEntry)}
+	si.loaded = true
+	si.changed = true
+}
+
+// Save writes the index to disk if it changed.
+func (si *SourceIndex) Save() error {
+	if si == nil {
+		return nil
+	}
+	si.Lock()
+	defer si.Unlock()
+	if !si.changed {
+		return nil
+	}
+	if err := MakeDir(filepath.Dir(si.Path)); err != nil {
+		return err
+	}
+	si.index.Version = constants.SourceIndexVersion
+	if err := WriteJSON(si.Path, si.index); err != nil {
+		return err
+	}
+	si.changed = false
+	return nil
+}
+
+// load reads the index from disk the first time it's used.
+// Indexes that can't be read, or have a different version, are ignored and rebuilt.
+func (si *SourceIndex) load() {
+	if si.loaded {
+		return
+	}
+	si.loaded = true
+	si.index = model.SourceIndex{Entries: make(map[string]model.SourceIndexEntry)}
+
+	contents, err := ioutil.ReadFile(si.Path)
+	if err != nil {
+		return
+	}
+	var index model.SourceIndex
+	if err := json.Unmarshal(contents, &index); err != nil {
+		logger.MaybeWarningf(si.Log, "%s: ignoring unreadable source index: %v", si.Path, err)
+		return
+	}
+	if index.Version != constants.SourceIndexVersion {
+		logger.MaybeInfof(si.Log, "%s: ignoring source index with version %q", si.Path, index.Version)
+		return
+	}
+	if index.Entries != nil {
+		si.index = index
+	}
+}
+
+// IndexedImage returns the source index entry of an image, reading and indexing the image
+// only if it changed since it was last indexed.
+func (e Engine) IndexedImage(path string) (model.SourceIndexEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return model.SourceIndexEntry{}, ex.New(err)
+	}
+	if entry, ok := e.SourceIndex.Get(path, info); ok {
+		return entry, nil
+	}
+
+	logger.MaybeDebugf(e.Log, "%s: indexing image", path)
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return model.SourceIndexEntry{}, ex.New(err)
+	}
+	image, err := ReadImageContents(path, contents)
+	if err != nil {
+		return model.SourceIndexEntry{}, err
+	}
+	etag, err := fileutil.ETag(contents)
+	if err != nil {
+		return model.SourceIndexEntry{}, err
+	}
+	entry := model.SourceIndexEntry{
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		ETag:     etag,
+		Format:   image.Format,
+		HasAlpha: image.HasAlpha,
+		Width:    image.Width,
+		Height:   image.Height,
+		Frames:   image.Frames,
+		Exif:     image.Exif,
+	}
+	e.SourceIndex.Set(path, entry)
+	return entry, nil
+}
+
+// ReadIndexedImage reads the metadata of an image through the source index.
+func (e Engine) ReadIndexedImage(path string) (model.Image, error) {
+	entry, err := e.IndexedImage(path)
+	if err != nil {
+		return model.Image{}, err
+	}
+	return entry.Image(path), nil
+}
+
+// ImageETag returns the etag of an image through the source index.
+func (e Engine) ImageETag(path string) (string, error) {
+	entry, err := e.IndexedImage(path)
+	if err != nil {
+		return "", err
+	}
+	return entry.ETag, nil
+}
+
+// SaveSourceIndex writes the source index to disk if it changed, unless it's a dry run.
+func (e Engine) SaveSourceIndex() error {
+	if e.DryRun {
+		return nil
+	}
+	return e.SourceIndex.Save()
+}
+
+// CleanSourceIndex removes the source index, so it's rebuilt from every image.
+func (e Engine) CleanSourceIndex() error {
+	indexPath := filepath.Join(e.Config.ThumbnailCachePathOrDefault(), constants.FileSourceIndex)
+	if e.DryRun {
+		logger.MaybeInfof(e.Log, "%s: (dry-run) would remove source index", indexPath)
+		return nil
+	}
+	logger.MaybeInfof(e.Log, "%s: removing source index", indexPath)
+	e.SourceIndex.Reset()
+	if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+		return ex.New(err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestEngineIndexedImage(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "blogctl")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	encoded := new(bytes.Buffer)
+	assert.Nil(png.Encode(encoded, image.NewNRGBA(image.Rect(0, 0, 4, 2))))
+	imagePath := filepath.Join(tempDir, "image.png")
+	assert.Nil(ioutil.WriteFile(imagePath, encoded.Bytes(), 0644))
+
+	cfg := config.Config{ThumbnailCachePath: filepath.Join(tempDir, "thumbnails")}
+	e := MustNew(OptConfig(cfg))
+
+	entry, err := e.IndexedImage(imagePath)
+	assert.Nil(err)
+	assert.NotEmpty(entry.ETag)
+	assert.Equal(4, entry.Width)
+	assert.Equal(2, entry.Height)
+	assert.True(entry.HasAlpha)
+	assert.Nil(e.SourceIndex.Save())
+
+	// an unchanged image is read from the saved index; tamper with the entry to tell.
+	indexPath := filepath.Join(tempDir, "thumbnails", constants.FileSourceIndex)
+	var index model.SourceIndex
+	contents, err := ioutil.ReadFile(indexPath)
+	assert.Nil(err)
+	assert.Nil(json.Unmarshal(contents, &index))
+	assert.Equal(constants.SourceIndexVersion, index.Version)
+	indexed := index.Entries[imagePath]
+	indexed.ETag = "indexed"
+	index.Entries[imagePath] = indexed
+	assert.Nil(WriteJSON(indexPath, index))
+
+	etag, err := MustNew(OptConfig(cfg)).ImageETag(imagePath)
+	assert.Nil(err)
+	assert.Equal("indexed", etag)
+
+	// a changed modification time invalidates the entry.
+	modTime := time.Now().Add(time.Hour)
+	assert.Nil(os.Chtimes(imagePath, modTime, modTime))
+	etag, err = MustNew(OptConfig(cfg)).ImageETag(imagePath)
+	assert.Nil(err)
+	assert.Equal(entry.ETag, etag)
+
+	// an index with a different version is ignored.
+	index.Version = "v0"
+	indexed.ModTime = modTime
+	index.Entries[imagePath] = indexed
+	assert.Nil(WriteJSON(indexPath, index))
+	etag, err = MustNew(OptConfig(cfg)).ImageETag(imagePath)
+	assert.Nil(err)
+	assert.Equal(entry.ETag, etag)
+
+	// cleaning the index removes it.
+	assert.Nil(MustNew(OptConfig(cfg)).CleanSourceIndex())
+	_, err = os.Stat(indexPath)
+	assert.True(os.IsNotExist(err))
+
+	// dry runs don't save the index.
+	dryRun := MustNew(OptConfig(cfg), OptDryRun(true))
+	_, err = dryRun.ImageETag(imagePath)
+	assert.Nil(err)
+	assert.Nil(dryRun.SaveSourceIndex())
+	_, err = os.Stat(indexPath)
+	assert.True(os.IsNotExist(err))
+}
@@ -3,7 +3,11 @@ package engine
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"html/template"
+	"mime"
+	"path/filepath"
+	"strings"
 
 	"github.com/blend/go-sdk/ex"
 	sdkTemplate "github.com/blend/go-sdk/template"
@@ -16,6 +20,9 @@ func ViewFuncs() template.FuncMap {
 	base["partition"] = partition
 	base["set_title"] = setTitle
 	base["render_post"] = renderPost
+	base["image_srcset"] = imageSrcset
+	base["image_tag"] = imageTag
+	base["picture_tag"] = pictureTag
 	return base
 }
 
@@ -79,3 +86,83 @@ func renderPost(post model.Post) (template.HTML, error) {
 	}
 	return template.HTML(buffer.String()), nil
 }
+
+// DefaultImageSizesAttribute is the default `sizes` attribute for responsive images,
+// i.e. that the image is shown at the full width of the viewport.
+const DefaultImageSizesAttribute = "100vw"
+
+// imageSrcset returns the `srcset` attribute value for an image, listing every thumbnail by its width.
+func imageSrcset(image model.Image) string {
+	var candidates []string
+	var previousWidth int
+	for _, size := range image.ThumbnailSizes() {
+		// thumbnails larger than the image are all the size of the image; list it once.
+		width := image.ScaleThumbnail(size).Dx()
+		if width == previousWidth {
+			continue
+		}
+		previousWidth = width
+		candidates = append(candidates, fmt.Sprintf("/%s %dw", filepath.ToSlash(image.PathForSize(size)), width))
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// imageTag returns a responsive `<img>` element for an image, with an optional `sizes` attribute.
+//
+// The `src` is the largest thumbnail, and the width and height are its dimensions so
+// the browser can reserve space for the image before it loads.
+func imageTag(image model.Image, sizes ...string) (template.HTML, error) {
+	thumbnailSizes := image.ThumbnailSizes()
+	if len(thumbnailSizes) == 0 {
+		return "", fmt.Errorf("image has no thumbnails; cannot render. image: %s", image.SourcePath)
+	}
+	largest := thumbnailSizes[len(thumbnailSizes)-1]
+	dimensions := image.ScaleThumbnail(largest)
+	return template.HTML(fmt.Sprintf(
+		`<img src="/%s" srcset="%s" sizes="%s" width="%d" height="%d" loading="lazy" alt="%s"%s>`,
+		html.EscapeString(filepath.ToSlash(image.PathForSize(largest))),
+		html.EscapeString(imageSrcset(image)),
+		html.EscapeString(imageSizesAttribute(sizes)),
+		dimensions.Dx(),
+		dimensions.Dy(),
+		html.EscapeString(image.AltOrDefault()),
+		imagePlaceholderAttributes(image),
+	)), nil
+}
+
+// imagePlaceholderAttributes returns the attributes that show the placeholder of an image while it loads,
+// that is the inline preview as the background and the blurhash for scripts to decode.
+func imagePlaceholderAttributes(image model.Image) string {
+	var output string
+	if image.Placeholder != "" {
+		output += fmt.Sprintf(` style="background-image: url(%s); background-size: cover"`, html.EscapeString(image.Placeholder))
+	}
+	if image.BlurHash != "" {
+		output += fmt.Sprintf(` data-blurhash="%s"`, html.EscapeString(image.BlurHash))
+	}
+	return output
+}
+
+// pictureTag returns a `<picture>` element for an image, with an optional `sizes` attribute.
+//
+// It has a `<source>` with the type of the thumbnails, and falls back to the `<img>` from `image_tag`.
+func pictureTag(image model.Image, sizes ...string) (template.HTML, error) {
+	img, err := imageTag(image, sizes...)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(fmt.Sprintf(
+		`<picture><source type="%s" srcset="%s" sizes="%s">%s</picture>`,
+		html.EscapeString(mime.TypeByExtension(image.ThumbnailExtension())),
+		html.EscapeString(imageSrcset(image)),
+		html.EscapeString(imageSizesAttribute(sizes)),
+		img,
+	)), nil
+}
+
+func imageSizesAttribute(sizes []string) string {
+	if len(sizes) > 0 && sizes[0] != "" {
+		return strings.Join(sizes, ", ")
+	}
+	return DefaultImageSizesAttribute
+}
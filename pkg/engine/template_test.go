@@ -38,3 +38,47 @@ func TestPartitionPosts(t *testing.T) {
 	assert.Equal("four", partition1[1].Meta.Title)
 	assert.Equal("six", partition1[2].Meta.Title)
 }
+
+func TestImageTag(t *testing.T) {
+	assert := assert.New(t)
+
+	image := model.Image{
+		OutputPath: "2019/02/11/image-post",
+		Width:      3000,
+		Height:     2000,
+		Alt:        `A "quoted" alt`,
+		Sizes: map[string]string{
+			"original": "2019/02/11/image-post/original.jpg",
+			"4096":     "2019/02/11/image-post/4096.jpg",
+			"1024":     "2019/02/11/image-post/1024.jpg",
+			"512":      "2019/02/11/image-post/512.jpg",
+		},
+	}
+
+	assert.Equal("/2019/02/11/image-post/512.jpg 512w, /2019/02/11/image-post/1024.jpg 1024w, /2019/02/11/image-post/4096.jpg 3000w", imageSrcset(image))
+
+	tag, err := imageTag(image)
+	assert.Nil(err)
+	assert.Contains(string(tag), `src="/2019/02/11/image-post/4096.jpg"`)
+	assert.Contains(string(tag), `sizes="100vw"`)
+	assert.Contains(string(tag), `width="3000" height="2000"`)
+	assert.Contains(string(tag), `loading="lazy"`)
+	assert.Contains(string(tag), `alt="A &#34;quoted&#34; alt"`)
+
+	picture, err := pictureTag(image, "(min-width: 800px) 50vw", "100vw")
+	assert.Nil(err)
+	assert.Contains(string(picture), `<source type="image/jpeg"`)
+	assert.Contains(string(picture), `sizes="(min-width: 800px) 50vw, 100vw"`)
+	assert.Contains(string(picture), string(tag[:len(`<img src="/2019/02/11/image-post/4096.jpg"`)]))
+
+	assert.NotContains(string(tag), "data-blurhash")
+	image.BlurHash = "LfTI:j|cfQ|c|csUfQsUfQfQfQfQ"
+	image.Placeholder = "data:image/jpeg;base64,AAAA"
+	tag, err = imageTag(image)
+	assert.Nil(err)
+	assert.Contains(string(tag), `style="background-image: url(data:image/jpeg;base64,AAAA); background-size: cover"`)
+	assert.Contains(string(tag), `data-blurhash="LfTI:j|cfQ|c|csUfQsUfQfQfQfQ"`)
+
+	_, err = imageTag(model.Image{})
+	assert.NotNil(err)
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/exif"
+	"github.com/wcharczuk/blogctl/pkg/icc"
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// ThumbnailMetadata is the metadata of a jpeg carried into its thumbnails, as the
+// image encoder writes thumbnails without any metadata.
+type ThumbnailMetadata struct {
+	// ICC are the segments of the icc color profile of the image.
+	ICC []jpegmeta.Segment
+	// Profile is the icc color profile of the image, if it can be converted to srgb.
+	Profile *icc.Profile
+	// Attribution is an exif segment with only the artist and copyright of the image.
+	Attribution *jpegmeta.Segment
+}
+
+// GetThumbnailMetadata returns the metadata of an image carried into its thumbnails.
+// Only jpegs have metadata that is carried into their thumbnails.
+func GetThumbnailMetadata(originalContents []byte, original model.Image) (output ThumbnailMetadata) {
+	if original.Format != constants.ImageFormatJPEG {
+		return
+	}
+	segments, _, err := jpegmeta.Split(originalContents)
+	if err != nil {
+		return
+	}
+	for _, segment := range segments {
+		switch {
+		case segment.IsICC():
+			output.ICC = append(output.ICC, segment)
+		case segment.IsExif() && output.Attribution == nil:
+			// only the artist and copyright are kept; notably not the orientation, as thumbnails are upright.
+			allowed := make(map[string]bool)
+			for _, field := range constants.AttributionFields {
+				allowed[field] = true
+			}
+			rewritten, err := jpegmeta.RewriteTIFF(segment.Data[len(jpegmeta.HeaderExif):], jpegmeta.Filter{
+				Tag:           func(name exif.FieldName) bool { return allowed[string(name)] },
+				GPS:           jpegmeta.GPSStrip,
+				StripExtended: true,
+			})
+			if err != nil || len(rewritten) == 0 {
+				continue
+			}
+			output.Attribution = &jpegmeta.Segment{
+				Marker: jpegmeta.MarkerAPP1,
+				Data:   append([]byte(jpegmeta.HeaderExif), rewritten...),
+			}
+		}
+	}
+	if profile := jpegmeta.ICCProfile(output.ICC); profile != nil {
+		// profiles that can't be converted are embedded instead.
+		output.Profile, _ = icc.Parse(profile)
+	}
+	return
+}
+
+// ConvertColorProfile converts a thumbnail to srgb if the image encoding options say to,
+// and the color profile of the image can be converted.
+func (tm ThumbnailMetadata) ConvertColorProfile(img image.Image, encoding config.ImageEncoding) image.Image {
+	if tm.IsConverted(encoding) {
+		return tm.Profile.ToSRGB(img)
+	}
+	return img
+}
+
+// IsConverted returns if thumbnails are converted to srgb per the image encoding options.
+func (tm ThumbnailMetadata) IsConverted(encoding config.ImageEncoding) bool {
+	return tm.Profile != nil && encoding.ColorProfileOrDefault() == constants.ColorProfileSRGB
+}
+
+// Segments returns the segments written to a jpeg thumbnail per the image encoding options.
+func (tm ThumbnailMetadata) Segments(encoding config.ImageEncoding) (output []jpegmeta.Segment) {
+	if tm.Attribution != nil && !encoding.SkipAttribution {
+		output = append(output, *tm.Attribution)
+	}
+	if !tm.IsConverted(encoding) {
+		output = append(output, tm.ICC...)
+	}
+	return
+}
+
+// EncodeThumbnail encodes a thumbnail in the format for a given file extension per the image
+// encoding options, writing the metadata of the image to jpegs.
+func EncodeThumbnail(w io.Writer, img image.Image, extension string, encoding config.ImageEncoding, metadata ThumbnailMetadata) error {
+	segments := metadata.Segments(encoding)
+	if extension = strings.ToLower(extension); extension == constants.ExtensionPNG || extension == constants.ExtensionGIF || len(segments) == 0 {
+		return EncodeImage(w, img, extension, encoding.QualityOrDefault())
+	}
+	buffer := new(bytes.Buffer)
+	if err := EncodeImage(buffer, img, extension, encoding.QualityOrDefault()); err != nil {
+		return err
+	}
+	contents, err := jpegmeta.Insert(buffer.Bytes(), segments...)
+	if err != nil {
+		return ex.New(err)
+	}
+	_, err = w.Write(contents)
+	return ex.New(err)
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/exif"
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestThumbnailMetadata(t *testing.T) {
+	assert := assert.New(t)
+
+	// a jpeg with the icc profile of the image post, and exif data with an orientation, a camera and attribution.
+	source, err := ioutil.ReadFile("testdata/posts/2019-02-11-image-post/0D8A5197.jpg")
+	assert.Nil(err)
+	sourceSegments, _, err := jpegmeta.Split(source)
+	assert.Nil(err)
+	profile := jpegmeta.ICCProfile(sourceSegments)
+	assert.NotEmpty(profile)
+
+	exifData := new(bytes.Buffer)
+	exifData.WriteString("II")
+	binary.Write(exifData, binary.LittleEndian, uint16(42))
+	binary.Write(exifData, binary.LittleEndian, uint32(8))
+	tags := []struct {
+		id    uint16
+		typ   uint16
+		value []byte
+	}{
+		{0x010F, 2, []byte("Cam\x00")},
+		{0x0112, 3, []byte{6, 0, 0, 0}},
+		{0x013B, 2, []byte("Me\x00\x00")},
+	}
+	binary.Write(exifData, binary.LittleEndian, uint16(len(tags)))
+	for _, tag := range tags {
+		binary.Write(exifData, binary.LittleEndian, tag.id)
+		binary.Write(exifData, binary.LittleEndian, tag.typ)
+		binary.Write(exifData, binary.LittleEndian, uint32(len(bytes.TrimRight(tag.value, "\x00"))+1))
+		exifData.Write(tag.value)
+	}
+	binary.Write(exifData, binary.LittleEndian, uint32(0))
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	encoded := new(bytes.Buffer)
+	assert.Nil(jpeg.Encode(encoded, img, nil))
+	contents, err := jpegmeta.Insert(encoded.Bytes(), append([]jpegmeta.Segment{{Marker: jpegmeta.MarkerAPP1, Data: append([]byte(jpegmeta.HeaderExif), exifData.Bytes()...)}}, jpegmeta.ICCSegments(profile)...)...)
+	assert.Nil(err)
+
+	metadata := GetThumbnailMetadata(contents, model.Image{Format: constants.ImageFormatJPEG})
+	assert.NotEmpty(metadata.ICC)
+	assert.NotNil(metadata.Profile)
+	assert.NotNil(metadata.Attribution)
+
+	// thumbnails keep the profile and the artist, but not the orientation or the camera.
+	thumbnail := new(bytes.Buffer)
+	assert.Nil(EncodeThumbnail(thumbnail, img, constants.ExtensionJPG, config.ImageEncoding{}, metadata))
+	thumbnailSegments, _, err := jpegmeta.Split(thumbnail.Bytes())
+	assert.Nil(err)
+	assert.Equal(profile, jpegmeta.ICCProfile(thumbnailSegments))
+	thumbnailExif, err := exif.Decode(bytes.NewReader(thumbnail.Bytes()))
+	assert.Nil(err)
+	artist, err := thumbnailExif.Get(exif.Artist)
+	assert.Nil(err)
+	assert.Equal(`"Me"`, artist.String())
+	_, err = thumbnailExif.Get(exif.Orientation)
+	assert.NotNil(err)
+	_, err = thumbnailExif.Get(exif.Make)
+	assert.NotNil(err)
+
+	// converted thumbnails don't have the profile, and attribution can be skipped.
+	converted := config.ImageEncoding{ColorProfile: constants.ColorProfileSRGB, SkipAttribution: true}
+	assert.Empty(metadata.Segments(converted))
+	// the profile of the image post is srgb, so converting keeps the colors.
+	img.Set(0, 0, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+	pixel := color.RGBAModel.Convert(metadata.ConvertColorProfile(img, converted).At(0, 0)).(color.RGBA)
+	assert.True(pixel.R >= 198 && pixel.R <= 202 && pixel.G >= 98 && pixel.G <= 102 && pixel.B >= 48 && pixel.B <= 52, pixel)
+
+	// pngs don't have metadata.
+	assert.Empty(GetThumbnailMetadata(contents, model.Image{Format: constants.ImageFormatPNG}).ICC)
+}
@@ -2,6 +2,8 @@ package engine
 
 import (
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,14 +12,19 @@ import (
 	"time"
 
 	"image"
+	"image/color"
 	// these are needed to read an image metadata
+	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
 
+	_ "golang.org/x/image/webp"
+
 	"gopkg.in/yaml.v3"
 
 	"github.com/blend/go-sdk/ex"
 
+	"github.com/wcharczuk/blogctl/pkg/constants"
 	"github.com/wcharczuk/blogctl/pkg/exif"
 	"github.com/wcharczuk/blogctl/pkg/model"
 )
@@ -66,30 +73,119 @@ func ReadImage(path string) (model.Image, error) {
 	if err != nil {
 		return model.Image{}, err
 	}
+	return ReadImageContents(path, contents)
+}
 
-	image, _, err := image.DecodeConfig(bytes.NewBuffer(contents))
+// ReadImageContents reads image metadata from the contents of an image file.
+func ReadImageContents(path string, contents []byte) (model.Image, error) {
+	image, format, err := image.DecodeConfig(bytes.NewBuffer(contents))
 	if err != nil {
 		return model.Image{}, err
 	}
 
+	// exif data is required for jpegs, but is optional for other formats
+	// as they rarely have it.
+	var exifData model.Exif
 	rawExifData, err := exif.Decode(bytes.NewBuffer(contents))
-	if err != nil {
+	if err == nil {
+		exifData, err = GetExifData(rawExifData)
+	}
+	if err != nil && format == constants.ImageFormatJPEG {
 		return model.Image{}, err
 	}
 
-	exifData, err := GetExifData(rawExifData)
-	if err != nil {
-		return model.Image{}, err
+	// the width and height are as the image is displayed, i.e. after it is
+	// rotated per its exif orientation.
+	width, height := image.Width, image.Height
+	if exifData.IsTransposed() {
+		width, height = height, width
+	}
+
+	var frames int
+	if format == constants.ImageFormatGIF {
+		frames = GIFFrames(contents)
 	}
 
 	return model.Image{
 		SourcePath: path,
-		Width:      image.Width,
-		Height:     image.Height,
+		Format:     format,
+		HasAlpha:   HasAlpha(image.ColorModel),
+		Width:      width,
+		Height:     height,
 		Exif:       exifData,
+		FileSize:   int64(len(contents)),
+		Frames:     frames,
 	}, nil
 }
 
+// GIFFrames returns the number of frames of a gif by walking its blocks, without decoding them.
+// It stops at the first block it can't read.
+func GIFFrames(contents []byte) (frames int) {
+	// the header and logical screen descriptor, then the global color table if there is one.
+	offset := 13
+	if len(contents) < offset {
+		return
+	}
+	if flags := contents[10]; flags&0x80 != 0 {
+		offset += 3 << ((flags & 0x07) + 1)
+	}
+	// skipBlocks skips data sub-blocks, which end with an empty block.
+	skipBlocks := func() bool {
+		for offset < len(contents) {
+			size := int(contents[offset])
+			offset += size + 1
+			if size == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	for offset < len(contents) {
+		switch contents[offset] {
+		case 0x21: // extension
+			offset += 2
+			if !skipBlocks() {
+				return
+			}
+		case 0x2C: // image descriptor
+			if offset+10 > len(contents) {
+				return
+			}
+			flags := contents[offset+9]
+			offset += 10
+			if flags&0x80 != 0 {
+				offset += 3 << ((flags & 0x07) + 1)
+			}
+			// the lzw minimum code size, then the image data.
+			offset++
+			if !skipBlocks() {
+				return
+			}
+			frames++
+		default: // the trailer, or a block that isn't valid
+			return
+		}
+	}
+	return
+}
+
+// HasAlpha returns if a color model can have transparency.
+func HasAlpha(colorModel color.Model) bool {
+	switch colorModel {
+	case color.RGBAModel, color.RGBA64Model, color.NRGBAModel, color.NRGBA64Model, color.AlphaModel, color.Alpha16Model:
+		return true
+	}
+	// palette images are only transparent if one of their colors is.
+	if palette, isPalette := colorModel.(color.Palette); isPalette {
+		for _, c := range palette {
+			if _, _, _, a := c.RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // GetExifData generates the parsed exif data for the post.
 func GetExifData(exifData *exif.Exif) (data model.Exif, err error) {
 	// fnumber
@@ -131,6 +227,26 @@ func GetExifData(exifData *exif.Exif) (data model.Exif, err error) {
 	if tag, tagErr := exifData.Get(exif.LensModel); tagErr == nil {
 		data.LensModel = StripQuotes(tag.String())
 	}
+	if tag, tagErr := exifData.Get(exif.Orientation); tagErr == nil {
+		data.Orientation, _ = tag.Int(0)
+	}
+
+	// location ...
+	if latitude, longitude, locationErr := exifData.LatLong(); locationErr == nil {
+		data.Latitude = latitude
+		data.Longitude = longitude
+	}
+	if tag, tagErr := exifData.Get(exif.GPSAltitude); tagErr == nil {
+		if nominator, denominator, ratErr := tag.Rat2(0); ratErr == nil && denominator != 0 {
+			data.Altitude = float64(nominator) / float64(denominator)
+		}
+		// an altitude ref of 1 means below sea level.
+		if refTag, refErr := exifData.Get(exif.GPSAltitudeRef); refErr == nil {
+			if ref, intErr := refTag.Int(0); intErr == nil && ref == 1 {
+				data.Altitude = -data.Altitude
+			}
+		}
+	}
 
 	// date time ...
 	data.CaptureDate, _ = exifData.DateTime()
@@ -195,3 +311,37 @@ func StripQuotes(v string) string {
 	v = strings.TrimSuffix(v, "\"")
 	return v
 }
+
+// AbsoluteURL joins a base url and a path.
+func AbsoluteURL(baseURL, path string) string {
+	path = filepath.ToSlash(path)
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// WriteXML writes an object as xml to disk, with an xml header.
+func WriteXML(path string, obj interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return ex.New(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return ex.New(err)
+	}
+	encoder := xml.NewEncoder(f)
+	encoder.Indent("", "\t")
+	return ex.New(encoder.Encode(obj))
+}
+
+// WriteJSON writes an object as json to disk.
+func WriteJSON(path string, obj interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return ex.New(err)
+	}
+	defer f.Close()
+	return ex.New(json.NewEncoder(f).Encode(obj))
+}
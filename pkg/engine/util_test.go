@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"io/ioutil"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestHasAlpha(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(HasAlpha(color.NRGBAModel))
+	assert.False(HasAlpha(color.YCbCrModel))
+	assert.False(HasAlpha(color.GrayModel))
+
+	// palettes only have alpha if one of their colors is transparent.
+	assert.False(HasAlpha(color.Palette{color.Black, color.White}))
+	assert.True(HasAlpha(color.Palette{color.Black, color.Transparent}))
+	assert.True(HasAlpha(color.Palette{color.Black, color.NRGBA{R: 255, A: 128}}))
+}
+
+func TestGIFFrames(t *testing.T) {
+	assert := assert.New(t)
+
+	animation := &gif.GIF{}
+	for index := 0; index < 3; index++ {
+		// frames with a palette of their own have a local color table.
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 8), palette.Plan9[index:index+16])
+		frame.Set(index, index, palette.Plan9[index+1])
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, 10)
+	}
+	encoded := new(bytes.Buffer)
+	assert.Nil(gif.EncodeAll(encoded, animation))
+	assert.Equal(3, GIFFrames(encoded.Bytes()))
+	// frames cut off aren't counted.
+	assert.Equal(2, GIFFrames(encoded.Bytes()[:encoded.Len()*3/4]))
+	assert.Zero(GIFFrames([]byte("GIF89a")))
+
+	contents, err := ioutil.ReadFile("testdata/posts/2019-02-07-formats-post/2.gif")
+	assert.Nil(err)
+	decoded, err := gif.DecodeAll(bytes.NewReader(contents))
+	assert.Nil(err)
+	assert.Equal(len(decoded.Image), GIFFrames(contents))
+}
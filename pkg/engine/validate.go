@@ -0,0 +1,593 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/stringutil"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/constants"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+// Validate checks the config, the posts and the templates of the blog without building it.
+//
+// Where a build stops at the first mistake, or renders around it, validate returns every problem
+// it finds along with the file, and the line where it's known, that each problem is in.
+// The config paths are the config files that were read, so problems with the config point at them.
+func (e Engine) Validate(ctx context.Context, configPaths ...string) model.Problems {
+	v := validation{
+		Engine:     e,
+		seen:       make(map[model.Problem]bool),
+		configKeys: make(map[string]model.Problem),
+	}
+	v.validateConfig(configPaths)
+	v.validatePaths()
+	textPostPaths := v.validatePosts(ctx)
+	v.validateTemplates(textPostPaths)
+
+	sort.Stable(v.problems)
+	return v.problems
+}
+
+// validation collects the problems found validating the blog, once each.
+type validation struct {
+	Engine
+
+	problems model.Problems
+	seen     map[model.Problem]bool
+	// configKeys are where the top level keys of the config are set, by key.
+	configKeys map[string]model.Problem
+}
+
+func (v *validation) add(path string, line int, format string, args ...interface{}) {
+	problem := model.Problem{Path: filepath.Clean(path), Line: line, Message: fmt.Sprintf(format, args...)}
+	if v.seen[problem] {
+		return
+	}
+	v.seen[problem] = true
+	v.problems = append(v.problems, problem)
+}
+
+// addConfig adds a problem with a config key, at the config file that sets the key
+// or the fallback path if the key is left to its default.
+func (v *validation) addConfig(key, fallbackPath string, format string, args ...interface{}) {
+	message := key + ": " + fmt.Sprintf(format, args...)
+	if location, ok := v.configKeys[key]; ok {
+		v.add(location.Path, location.Line, "%s", message)
+		return
+	}
+	v.add(fallbackPath, 0, "%s", message)
+}
+
+func (v *validation) validateConfig(configPaths []string) {
+	// the same file can be read more than once by different paths, e.g. `./config.yml` and `config.yml`.
+	var cleanPaths []string
+	read := make(map[string]bool)
+	for _, configPath := range configPaths {
+		if configPath = filepath.Clean(configPath); !read[configPath] {
+			read[configPath] = true
+			cleanPaths = append(cleanPaths, configPath)
+		}
+	}
+	configPaths = cleanPaths
+
+	for _, configPath := range configPaths {
+		contents, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			v.add(configPath, 0, "%v", err)
+			continue
+		}
+		problems, _ := validateYAML(configPath, contents, reflect.TypeOf(config.Config{}), 0)
+		for _, problem := range problems {
+			v.add(problem.Path, problem.Line, "%s", problem.Message)
+		}
+		// later config files take precedence, so keys are located in the last file that sets them.
+		var document yaml.Node
+		if err := yaml.Unmarshal(contents, &document); err == nil && len(document.Content) > 0 && document.Content[0].Kind == yaml.MappingNode {
+			mapping := document.Content[0]
+			for index := 0; index+1 < len(mapping.Content); index += 2 {
+				v.configKeys[mapping.Content[index].Value] = model.Problem{Path: configPath, Line: mapping.Content[index].Line}
+			}
+		}
+	}
+
+	configPath := "config"
+	if len(configPaths) > 0 {
+		configPath = configPaths[len(configPaths)-1]
+	}
+	if err := v.ValidateImageVariants(); err != nil {
+		v.addConfig("imageVariants", configPath, "%s", problemMessage(err))
+	}
+	if err := v.ValidateImageEncodings(); err != nil {
+		key := "imageEncoding"
+		if strings.HasPrefix(ex.ErrMessage(err), "imageSizeEncodings") {
+			key = "imageSizeEncodings"
+		}
+		v.addConfig(key, configPath, "%s", problemMessage(err))
+	}
+	if _, err := v.ParseSlugTemplate(); err != nil {
+		v.addConfig("slugTemplate", configPath, "%s", problemMessage(err))
+	}
+	if err := v.Config.Pagination.Validate(); err != nil {
+		v.addConfig("pagination", configPath, "%s", problemMessage(err))
+	}
+	if err := v.Config.ThumbnailCache.Validate(v.Config.S3); err != nil {
+		v.addConfig("thumbnailCache", configPath, "%s", problemMessage(err))
+	}
+	if !v.Config.SkipGenerateFeeds {
+		if err := v.Config.Feed.Validate(v.Config.ImageSizesOrDefault()); err != nil {
+			v.addConfig("feed", configPath, "%s", problemMessage(err))
+		}
+	}
+	if v.Config.Locations.Enabled {
+		if err := v.Config.Locations.Validate(v.Config.ImageSizesOrDefault()); err != nil {
+			v.addConfig("locations", configPath, "%s", problemMessage(err))
+		}
+	}
+}
+
+// requiredPath is a path the build reads, by its config key.
+type requiredPath struct {
+	Key   string
+	Path  string
+	IsDir bool
+}
+
+// validatePaths checks the directories and templates the build reads exist.
+func (v *validation) validatePaths() {
+	required := []requiredPath{
+		{Key: "postsPath", Path: v.Config.PostsPathOrDefault(), IsDir: true},
+		{Key: "pagesPath", Path: v.Config.PagesPathOrDefault(), IsDir: true},
+		{Key: "partialsPath", Path: v.Config.PartialsPathOrDefault(), IsDir: true},
+		{Key: "imagePostTemplatePath", Path: v.Config.ImagePostTemplateOrDefault()},
+		{Key: "textPostTemplatePath", Path: v.Config.TextPostTemplateOrDefault()},
+	}
+	// the statics are optional unless they're configured.
+	if v.Config.StaticsPath != "" {
+		required = append(required, requiredPath{Key: "staticsPath", Path: v.Config.StaticsPath, IsDir: true})
+	}
+	// the build skips the tags if the tag template is missing, which is rarely what's wanted.
+	if !v.Config.SkipGenerateTags {
+		required = append(required, requiredPath{Key: "tagTemplatePath", Path: v.Config.TagTemplateOrDefault()})
+	}
+
+	for _, path := range required {
+		info, err := os.Stat(path.Path)
+		switch {
+		case os.IsNotExist(err):
+			if path.IsDir {
+				v.addConfig(path.Key, path.Path, "directory %s not found", path.Path)
+			} else {
+				v.addConfig(path.Key, path.Path, "file %s not found", path.Path)
+			}
+		case err != nil:
+			v.addConfig(path.Key, path.Path, "%v", err)
+		case path.IsDir && !info.IsDir():
+			v.addConfig(path.Key, path.Path, "%s is not a directory", path.Path)
+		case !path.IsDir && info.IsDir():
+			v.addConfig(path.Key, path.Path, "%s is a directory", path.Path)
+		}
+	}
+}
+
+// validatePosts checks every post, regardless of its publication state, returning
+// the paths of the text posts that are templates so they can be checked with the templates.
+func (v *validation) validatePosts(ctx context.Context) (textPostPaths []string) {
+	postsPath := v.Config.PostsPathOrDefault()
+	if !Exists(postsPath) {
+		return
+	}
+	slugTemplate, err := v.ParseSlugTemplate()
+	if err != nil {
+		return
+	}
+
+	slugs := make(map[string]string)
+	var postIndex int
+	walkErr := filepath.Walk(postsPath, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			v.add(currentPath, 0, "%v", err)
+			return nil
+		}
+		if currentPath == postsPath || !info.IsDir() {
+			return nil
+		}
+		defer func() {
+			postIndex++
+		}()
+
+		post, metaPath := v.validatePost(ctx, slugTemplate, currentPath, postIndex)
+		if post == nil {
+			return nil
+		}
+		if strings.TrimSpace(post.Meta.Title) == "" {
+			v.add(metaPath, 0, "title is empty; posts need a title for their slug")
+		} else if stringutil.Slugify(post.Meta.Title) == "" {
+			v.add(metaPath, 0, "title %q has no letters or numbers for its slug", post.Meta.Title)
+		}
+		if post.Slug == "" {
+			v.add(currentPath, 0, "slug is empty; check the slug template")
+		} else if otherPath, ok := slugs[post.Slug]; ok {
+			v.add(currentPath, 0, "slug %s is also used by %s", post.Slug, otherPath)
+		} else {
+			slugs[post.Slug] = currentPath
+		}
+		if post.Text.SourcePath != "" && !post.Text.IsMarkdown() {
+			textPostPaths = append(textPostPaths, post.Text.SourcePath)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		v.add(postsPath, 0, "%v", walkErr)
+	}
+	return
+}
+
+// validatePost checks the meta and the front matter of a post against the meta schema, returning
+// the post if it can be read, and the file its meta is read from last, or the post path if it has none.
+// Posts whose meta can't be read aren't read, as they'd fail the same way.
+func (v *validation) validatePost(ctx context.Context, slugTemplate *template.Template, postPath string, postIndex int) (*model.Post, string) {
+	metaPath := postPath
+	files, err := ListDirectory(postPath)
+	if err != nil {
+		v.add(postPath, 0, "%s", problemMessage(err))
+		return nil, metaPath
+	}
+
+	valid := true
+	var frontMatterPath string
+	metaType := reflect.TypeOf(model.Meta{})
+	for _, file := range files {
+		filePath := filepath.Join(postPath, file.Name())
+		var problems []model.Problem
+		var ok bool
+		if strings.ToLower(file.Name()) == constants.FileMeta {
+			contents, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				v.add(filePath, 0, "%v", err)
+				valid = false
+				continue
+			}
+			problems, ok = validateYAML(filePath, contents, metaType, 0)
+			if frontMatterPath == "" {
+				metaPath = filePath
+			}
+		} else if HasExtension(strings.ToLower(file.Name()), constants.MarkdownExtensions...) {
+			contents, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				v.add(filePath, 0, "%v", err)
+				valid = false
+				continue
+			}
+			frontMatter, _ := SplitFrontMatter(contents)
+			if len(bytes.TrimSpace(frontMatter)) == 0 {
+				continue
+			}
+			// the front matter starts after the opening delimiter.
+			problems, ok = validateYAML(filePath, frontMatter, metaType, 1)
+			// the front matter takes precedence over the `meta.yml`.
+			frontMatterPath = filePath
+			metaPath = filePath
+		} else {
+			continue
+		}
+		for _, problem := range problems {
+			v.add(problem.Path, problem.Line, "%s", problem.Message)
+		}
+		valid = valid && ok
+	}
+	if !valid {
+		return nil, metaPath
+	}
+
+	post, err := v.GeneratePost(ctx, slugTemplate, postPath, postIndex)
+	if err != nil {
+		v.add(postPath, 0, "%s", problemMessage(err))
+		return nil, metaPath
+	}
+	return post, metaPath
+}
+
+// validatedPartial is a partial that parses on its own.
+type validatedPartial struct {
+	Path     string
+	Contents string
+}
+
+// validateTemplates checks the partials, then the templates with the partials; the post
+// templates, the text posts, the tag template and the pages.
+func (v *validation) validateTemplates(textPostPaths []string) {
+	var partials []validatedPartial
+	partialsPath := v.Config.PartialsPathOrDefault()
+	if Exists(partialsPath) {
+		partialFiles, err := ListDirectory(partialsPath)
+		if err != nil {
+			v.add(partialsPath, 0, "%s", problemMessage(err))
+		}
+		for _, partialFile := range partialFiles {
+			partialPath := filepath.Join(partialsPath, partialFile.Name())
+			contents, err := ioutil.ReadFile(partialPath)
+			if err != nil {
+				v.add(partialPath, 0, "%v", err)
+				continue
+			}
+			if _, err := template.New(partialPath).Funcs(ViewFuncs()).Parse(string(contents)); err != nil {
+				v.addTemplateError(partialPath, err)
+				continue
+			}
+			partials = append(partials, validatedPartial{Path: partialPath, Contents: string(contents)})
+		}
+	}
+
+	templatePaths := []string{
+		v.Config.ImagePostTemplateOrDefault(),
+		v.Config.TextPostTemplateOrDefault(),
+	}
+	if !v.Config.SkipGenerateTags {
+		templatePaths = append(templatePaths, v.Config.TagTemplateOrDefault())
+	}
+	templatePaths = append(templatePaths, textPostPaths...)
+	pagesPath := v.Config.PagesPathOrDefault()
+	if Exists(pagesPath) {
+		pages, err := ListDirectory(pagesPath)
+		if err != nil {
+			v.add(pagesPath, 0, "%s", problemMessage(err))
+		}
+		for _, page := range pages {
+			templatePaths = append(templatePaths, filepath.Join(pagesPath, page.Name()))
+		}
+	}
+
+	for _, templatePath := range templatePaths {
+		// missing templates are reported with the paths.
+		if info, err := os.Stat(templatePath); err != nil || info.IsDir() {
+			continue
+		}
+		v.validateTemplate(templatePath, partials)
+	}
+}
+
+// validateTemplate checks a template parses with the partials, and that every
+// template it or the partials include is defined.
+func (v *validation) validateTemplate(templatePath string, partials []validatedPartial) {
+	contents, err := ioutil.ReadFile(templatePath)
+	if err != nil {
+		v.add(templatePath, 0, "%v", err)
+		return
+	}
+	final := template.New(templatePath).Funcs(ViewFuncs())
+	for _, partial := range partials {
+		if _, err := final.New(partial.Path).Parse(partial.Contents); err != nil {
+			v.addTemplateError(partial.Path, err)
+			return
+		}
+	}
+	if _, err := final.Parse(string(contents)); err != nil {
+		v.addTemplateError(templatePath, err)
+		return
+	}
+
+	for _, tpl := range final.Templates() {
+		if tpl.Tree == nil || tpl.Tree.Root == nil {
+			continue
+		}
+		walkTemplateNodes(tpl.Tree.Root, func(node *parse.TemplateNode) {
+			if final.Lookup(node.Name) != nil {
+				return
+			}
+			path, line := templatePath, 0
+			if location, _ := tpl.Tree.ErrorContext(node); location != "" {
+				if matches := templateLocation.FindStringSubmatch(location); matches != nil {
+					path = matches[1]
+					line, _ = strconv.Atoi(matches[2])
+				}
+			}
+			v.add(path, line, "template %q is not defined", node.Name)
+		})
+	}
+}
+
+var (
+	// templateError matches the file and line of template parse errors.
+	templateError = regexp.MustCompile(`(?s)^template: (.*?):(\d+): (.*)$`)
+	// templateLocation matches the file, line and column of a template node.
+	templateLocation = regexp.MustCompile(`^(.*):(\d+):\d+$`)
+)
+
+func (v *validation) addTemplateError(templatePath string, err error) {
+	if matches := templateError.FindStringSubmatch(err.Error()); matches != nil {
+		line, _ := strconv.Atoi(matches[2])
+		v.add(matches[1], line, "%s", matches[3])
+		return
+	}
+	v.add(templatePath, 0, "%v", err)
+}
+
+// walkTemplateNodes calls an action for every node that includes another template.
+func walkTemplateNodes(node parse.Node, action func(*parse.TemplateNode)) {
+	switch typed := node.(type) {
+	case *parse.ListNode:
+		if typed == nil {
+			return
+		}
+		for _, child := range typed.Nodes {
+			walkTemplateNodes(child, action)
+		}
+	case *parse.IfNode:
+		walkTemplateNodes(typed.List, action)
+		walkTemplateNodes(typed.ElseList, action)
+	case *parse.RangeNode:
+		walkTemplateNodes(typed.List, action)
+		walkTemplateNodes(typed.ElseList, action)
+	case *parse.WithNode:
+		walkTemplateNodes(typed.List, action)
+		walkTemplateNodes(typed.ElseList, action)
+	case *parse.TemplateNode:
+		action(typed)
+	}
+}
+
+// problemMessage returns the message of an error without its stack trace.
+func problemMessage(err error) string {
+	if message := ex.ErrMessage(err); message != "" {
+		return fmt.Sprintf("%v: %s", ex.ErrClass(err), message)
+	}
+	return fmt.Sprintf("%v", ex.ErrClass(err))
+}
+
+var (
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	// yamlErrorLine matches the line of yaml syntax and type errors.
+	yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+)
+
+// validateYAML checks a yaml document against the type it's read into, returning a problem
+// for every key the type doesn't have, and for every value that can't be read into its field.
+//
+// It returns if the document can be read into the type; unknown keys are ignored when
+// the document is read, so they don't stop it from being read. The line offset is
+// added to the lines of the problems, for documents embedded in a larger file.
+func validateYAML(path string, contents []byte, typ reflect.Type, lineOffset int) (problems []model.Problem, ok bool) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		var line int
+		message := err.Error()
+		if matches := yamlErrorLine.FindStringSubmatch(message); matches != nil {
+			line, _ = strconv.Atoi(matches[1])
+			line += lineOffset
+			message = message[len(matches[0]):]
+		}
+		return []model.Problem{{Path: path, Line: line, Message: message}}, false
+	}
+	if len(document.Content) == 0 {
+		return nil, true
+	}
+	ok = true
+	validateYAMLNode(document.Content[0], typ, "", func(line int, format string, args ...interface{}) {
+		problems = append(problems, model.Problem{Path: path, Line: line + lineOffset, Message: fmt.Sprintf(format, args...)})
+	}, &ok)
+	return
+}
+
+func validateYAMLNode(node *yaml.Node, typ reflect.Type, name string, report func(int, string, ...interface{}), ok *bool) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	decodesItself := reflect.PtrTo(typ).Implements(yamlUnmarshalerType) || reflect.PtrTo(typ).Implements(textUnmarshalerType)
+
+	switch {
+	case !decodesItself && typ.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(typ)
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			key, value := node.Content[index], node.Content[index+1]
+			fieldType, known := fields[key.Value]
+			if !known {
+				report(key.Line, "%s", joinYAMLName(name, fmt.Sprintf("unknown key %q", key.Value), ": "))
+				continue
+			}
+			validateYAMLNode(value, fieldType, joinYAMLName(name, key.Value, "."), report, ok)
+		}
+		return
+	case !decodesItself && typ.Kind() == reflect.Slice && isYAMLStruct(typ.Elem()) && node.Kind == yaml.SequenceNode:
+		for index, value := range node.Content {
+			validateYAMLNode(value, typ.Elem(), fmt.Sprintf("%s[%d]", name, index), report, ok)
+		}
+		return
+	case !decodesItself && typ.Kind() == reflect.Map && isYAMLStruct(typ.Elem()) && node.Kind == yaml.MappingNode:
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			key, value := node.Content[index], node.Content[index+1]
+			if err := key.Decode(reflect.New(typ.Key()).Interface()); err != nil {
+				*ok = false
+				report(key.Line, "%s", joinYAMLName(name, describeYAMLError(err), ": "))
+				continue
+			}
+			validateYAMLNode(value, typ.Elem(), joinYAMLName(name, key.Value, "."), report, ok)
+		}
+		return
+	}
+
+	if err := node.Decode(reflect.New(typ).Interface()); err != nil {
+		*ok = false
+		report(node.Line, "%s", joinYAMLName(name, describeYAMLError(err), ": "))
+	}
+}
+
+// yamlFields returns the types of the fields of a struct by their yaml keys.
+func yamlFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for index := 0; index < typ.NumField(); index++ {
+		field := typ.Field(index)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		if field.Type.Kind() == reflect.Struct && len(parts) > 1 && parts[1] == "inline" {
+			for key, fieldType := range yamlFields(field.Type) {
+				fields[key] = fieldType
+			}
+			continue
+		}
+		key := parts[0]
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		fields[key] = field.Type
+	}
+	return fields
+}
+
+func isYAMLStruct(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && !reflect.PtrTo(typ).Implements(yamlUnmarshalerType) && !reflect.PtrTo(typ).Implements(textUnmarshalerType)
+}
+
+// joinYAMLName joins the name of a yaml value to the name of the value it's in, if it's in one.
+func joinYAMLName(parent, name, separator string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + separator + name
+}
+
+// describeYAMLError returns the message of an error reading a yaml value, without its line.
+func describeYAMLError(err error) string {
+	switch typed := err.(type) {
+	case *yaml.TypeError:
+		messages := make([]string, 0, len(typed.Errors))
+		for _, message := range typed.Errors {
+			messages = append(messages, yamlErrorLine.ReplaceAllString(message, ""))
+		}
+		return strings.Join(messages, "; ")
+	case *time.ParseError:
+		return fmt.Sprintf("invalid date %q%s; dates are like 2006-01-02 or 2006-01-02T15:04:05-07:00", typed.Value, typed.Message)
+	}
+	return err.Error()
+}
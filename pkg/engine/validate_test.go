@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ref"
+
+	"github.com/wcharczuk/blogctl/pkg/config"
+	"github.com/wcharczuk/blogctl/pkg/model"
+)
+
+func TestEngineValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(os.Chdir("testdata"))
+	defer os.Chdir("..")
+
+	cfg, cfgPaths, err := config.ReadConfig(config.Flags{
+		ConfigPath: ref.String("./config.yml"),
+	})
+	assert.Nil(err)
+	assert.Empty(MustNew(OptConfig(cfg)).Validate(context.TODO(), cfgPaths...))
+
+	// problems with config keys point at where the key is set, or the config if it isn't, and posts with the same slug are found.
+	cfg.TagTemplatePath = "./layout/missing.html"
+	cfg.SlugTemplate = "{{ .Meta.Posted.Year }}"
+	cfg.Pagination.PathFormat = "page"
+	cfg.ImageSizes = []int{1024, 512}
+	cfg.Feed.ImageSize = 100
+	cfg.Locations.ImageSize = 200
+	problems := MustNew(OptConfig(cfg)).Validate(context.TODO(), cfgPaths...)
+	hasProblem := func(expected model.Problem) func(interface{}) bool {
+		return func(item interface{}) bool {
+			return item.(model.Problem) == expected
+		}
+	}
+	assert.Any(problems, hasProblem(model.Problem{Path: "config.yml", Line: 11, Message: "tagTemplatePath: file ./layout/missing.html not found"}))
+	assert.Any(problems, hasProblem(model.Problem{Path: "posts/2019-02-08-gallery-post", Message: "slug 2019 is also used by posts/2019-02-07-formats-post"}))
+	assert.Any(problems, hasProblem(model.Problem{Path: "config.yml", Message: "pagination: invalid pagination: pathFormat must include the page number as %d; got page"}))
+	assert.Any(problems, hasProblem(model.Problem{Path: "config.yml", Message: "feed: invalid feed: imageSize must be one of the image sizes [1024 512]; got 100"}))
+	assert.Any(problems, hasProblem(model.Problem{Path: "config.yml", Line: 13, Message: "locations: invalid locations: imageSize must be one of the image sizes [1024 512]; got 200"}))
+}
+
+func TestEngineValidateSlugs(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(os.Chdir("testdata"))
+	defer os.Chdir("..")
+
+	tempDir, err := ioutil.TempDir("", "blogctl")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	postPath := filepath.Join(tempDir, "2019-02-10-punctuation-post")
+	assert.Nil(os.MkdirAll(postPath, 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(postPath, "meta.yml"), []byte("posted: 2019-02-10T16:21:27-08:00\ntitle: \"!!!\"\n"), 0644))
+	assert.Nil(ioutil.WriteFile(filepath.Join(postPath, "post.html"), []byte("<p>Punctuation.</p>"), 0644))
+
+	cfg, cfgPaths, err := config.ReadConfig(config.Flags{
+		ConfigPath: ref.String("./config.yml"),
+	})
+	assert.Nil(err)
+	cfg.PostsPath = tempDir
+
+	// titles without letters or numbers slugify to nothing, and so does the slug template here.
+	cfg.SlugTemplate = "{{ .Meta.Location }}"
+	problems := MustNew(OptConfig(cfg)).Validate(context.TODO(), cfgPaths...)
+	hasProblem := func(expected model.Problem) func(interface{}) bool {
+		return func(item interface{}) bool {
+			return item.(model.Problem) == expected
+		}
+	}
+	assert.Any(problems, hasProblem(model.Problem{Path: filepath.Join(postPath, "meta.yml"), Message: `title "!!!" has no letters or numbers for its slug`}))
+	assert.Any(problems, hasProblem(model.Problem{Path: postPath, Message: "slug is empty; check the slug template"}))
+}
+
+func TestValidateYAML(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := []byte(`posted: 2019-13-45
+title: Post
+tagz: [a]
+images:
+- file: a.jpg
+  focus:
+    x: 0.5
+    z: 1
+`)
+	problems, ok := validateYAML("meta.yml", contents, reflect.TypeOf(model.Meta{}), 1)
+	assert.False(ok)
+	assert.Len(problems, 3)
+	assert.Equal(model.Problem{Path: "meta.yml", Line: 2, Message: `posted: invalid date "2019-13-45": month out of range; dates are like 2006-01-02 or 2006-01-02T15:04:05-07:00`}, problems[0])
+	assert.Equal(model.Problem{Path: "meta.yml", Line: 4, Message: `unknown key "tagz"`}, problems[1])
+	assert.Equal(model.Problem{Path: "meta.yml", Line: 9, Message: `images[0].focus: unknown key "z"`}, problems[2])
+
+	// unknown keys don't stop the meta from being read.
+	problems, ok = validateYAML("meta.yml", []byte("title: Post\ntagz: [a]\n"), reflect.TypeOf(model.Meta{}), 0)
+	assert.True(ok)
+	assert.Len(problems, 1)
+
+	problems, ok = validateYAML("meta.yml", []byte("title: [\n"), reflect.TypeOf(model.Meta{}), 0)
+	assert.False(ok)
+	assert.Len(problems, 1)
+}
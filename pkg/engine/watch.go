@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// WatchPaths returns the paths that are inputs to the build, i.e. the paths
+// that should trigger a rebuild when their contents change.
+func (e Engine) WatchPaths() []string {
+	return []string{
+		e.Config.PostsPathOrDefault(),
+		e.Config.PagesPathOrDefault(),
+		e.Config.PartialsPathOrDefault(),
+		e.Config.StaticsPathOrDefault(),
+		e.Config.ImagePostTemplateOrDefault(),
+		e.Config.TextPostTemplateOrDefault(),
+		e.Config.TagTemplateOrDefault(),
+	}
+}
+
+// SnapshotEntry is the state of a single file in a snapshot.
+type SnapshotEntry struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// Snapshot is the state of every file under a set of paths.
+type Snapshot map[string]SnapshotEntry
+
+// Diff returns the paths that were added, removed or modified between the snapshot and another snapshot.
+func (s Snapshot) Diff(other Snapshot) (changed []string) {
+	for path, entry := range s {
+		if otherEntry, ok := other[path]; !ok || !otherEntry.ModTime.Equal(entry.ModTime) || otherEntry.Size != entry.Size {
+			changed = append(changed, path)
+		}
+	}
+	for path := range other {
+		if _, ok := s[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return
+}
+
+// TakeSnapshot walks the given paths and records the state of every file under them.
+// Paths that do not exist, including files removed while walking, are ignored.
+func TakeSnapshot(paths ...string) (Snapshot, error) {
+	output := make(Snapshot)
+	for _, path := range paths {
+		if path == "" || !Exists(path) {
+			continue
+		}
+		err := filepath.Walk(path, func(currentPath string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			output[currentPath] = SnapshotEntry{
+				ModTime: info.ModTime(),
+				Size:    info.Size(),
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, ex.New(err)
+		}
+	}
+	return output, nil
+}
+
+// WaitForChanges polls the given paths every interval until they differ from a previous snapshot.
+// Once a change is seen, it keeps polling until the paths settle, so that a burst of writes
+// (e.g. an editor saving several files) is reported as a single change.
+// If there is no previous snapshot, every path is reported as changed once a snapshot is taken.
+// It returns the changed paths, or an error if the context is cancelled.
+func WaitForChanges(ctx context.Context, previous Snapshot, interval time.Duration, paths ...string) ([]string, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var settling bool
+	current := previous
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+			next, err := TakeSnapshot(paths...)
+			if err != nil {
+				return nil, err
+			}
+			if diff := current.Diff(next); len(diff) > 0 {
+				settling = true
+				current = next
+				continue
+			}
+			if settling {
+				return previous.Diff(current), nil
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2019, 2, 11, 12, 0, 0, 0, time.UTC)
+	previous := Snapshot{
+		"posts/a/meta.yml":  {ModTime: now, Size: 10},
+		"posts/b/meta.yml":  {ModTime: now, Size: 10},
+		"posts/c/image.jpg": {ModTime: now, Size: 10},
+		"posts/d/meta.yml":  {ModTime: now, Size: 10},
+	}
+	next := Snapshot{
+		"posts/a/meta.yml":  {ModTime: now, Size: 10},
+		"posts/b/meta.yml":  {ModTime: now.Add(time.Second), Size: 10},
+		"posts/c/image.jpg": {ModTime: now, Size: 20},
+		"posts/e/meta.yml":  {ModTime: now, Size: 10},
+	}
+	assert.Equal([]string{"posts/b/meta.yml", "posts/c/image.jpg", "posts/d/meta.yml", "posts/e/meta.yml"}, previous.Diff(next))
+	assert.Empty(previous.Diff(previous))
+
+	// without a previous snapshot everything has changed.
+	assert.Len(Snapshot(nil).Diff(next), 4)
+}
+
+func TestWaitForChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "blogctl")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	assert.Nil(WriteFile(filepath.Join(tempDir, "a.md"), []byte("a")))
+	snapshot, err := TakeSnapshot(tempDir, filepath.Join(tempDir, "missing"))
+	assert.Nil(err)
+	assert.Len(snapshot, 1)
+
+	// several writes in a row are reported as one change.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		WriteFile(filepath.Join(tempDir, "a.md"), []byte("aa"))
+		WriteFile(filepath.Join(tempDir, "b.md"), []byte("b"))
+	}()
+	changed, err := WaitForChanges(context.TODO(), snapshot, 10*time.Millisecond, tempDir)
+	assert.Nil(err)
+	assert.Equal([]string{filepath.Join(tempDir, "a.md"), filepath.Join(tempDir, "b.md")}, changed)
+
+	// without a previous snapshot the first snapshot is a change.
+	changed, err = WaitForChanges(context.TODO(), nil, 10*time.Millisecond, tempDir)
+	assert.Nil(err)
+	assert.Len(changed, 2)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	snapshot, err = TakeSnapshot(tempDir)
+	assert.Nil(err)
+	_, err = WaitForChanges(ctx, snapshot, 10*time.Millisecond, tempDir)
+	assert.Equal(context.DeadlineExceeded, err)
+}
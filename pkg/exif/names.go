@@ -0,0 +1,15 @@
+package exif
+
+// TagFieldName returns the field name for a tag id in IFD0 or the exif sub-IFD,
+// and if the tag is known.
+func TagFieldName(id uint16) (FieldName, bool) {
+	name, ok := exifFields[id]
+	return name, ok
+}
+
+// GPSTagFieldName returns the field name for a tag id in the gps sub-IFD,
+// and if the tag is known.
+func GPSTagFieldName(id uint16) (FieldName, bool) {
+	name, ok := gpsFields[id]
+	return name, ok
+}
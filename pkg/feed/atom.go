@@ -0,0 +1,55 @@
+package feed
+
+import "encoding/xml"
+
+// AtomNamespace is the xml namespace for atom feeds.
+const AtomNamespace = "http://www.w3.org/2005/Atom"
+
+// Atom is an atom feed.
+//
+// See: https://tools.ietf.org/html/rfc4287
+type Atom struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *AtomAuthor `xml:"author,omitempty"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// AtomAuthor is the author of an atom feed or entry.
+type AtomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// AtomLink is a link in an atom feed or entry.
+type AtomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+// AtomEntry is an entry in an atom feed.
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Links      []AtomLink     `xml:"link"`
+	Categories []AtomCategory `xml:"category,omitempty"`
+	Content    *AtomContent   `xml:"content,omitempty"`
+}
+
+// AtomCategory is a category (i.e. a tag) of an atom entry.
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// AtomContent is the content of an atom entry.
+type AtomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
@@ -0,0 +1,42 @@
+package feed
+
+import "encoding/xml"
+
+// RSSVersion is the rss version we produce.
+const RSSVersion = "2.0"
+
+// RSS is an rss feed.
+//
+// See: https://validator.w3.org/feed/docs/rss2.html
+type RSS struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+// RSSChannel is the channel of an rss feed.
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []RSSItem `xml:"item"`
+}
+
+// RSSItem is an item in an rss channel.
+type RSSItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        string        `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Categories  []string      `xml:"category,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	Enclosure   *RSSEnclosure `xml:"enclosure,omitempty"`
+}
+
+// RSSEnclosure is a media attachment of an rss item.
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
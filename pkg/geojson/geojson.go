@@ -0,0 +1,42 @@
+package geojson
+
+// Types are the geojson object types.
+const (
+	TypeFeatureCollection = "FeatureCollection"
+	TypeFeature           = "Feature"
+	TypePoint             = "Point"
+)
+
+// FeatureCollection is a list of features.
+//
+// See: https://tools.ietf.org/html/rfc7946
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a located object with properties.
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is the location of a feature.
+type Geometry struct {
+	Type string `json:"type"`
+	// Coordinates are the longitude, the latitude and optionally the altitude of a point.
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// NewPoint returns a new point feature.
+func NewPoint(latitude, longitude float64, properties map[string]interface{}) Feature {
+	return Feature{
+		Type: TypeFeature,
+		Geometry: Geometry{
+			Type:        TypePoint,
+			Coordinates: []float64{longitude, latitude},
+		},
+		Properties: properties,
+	}
+}
@@ -0,0 +1,144 @@
+package gifmeta
+
+import (
+	"bytes"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+)
+
+// Blocks are the gif block introducers and extension labels relevant to the metadata.
+const (
+	BlockExtension   byte = 0x21
+	BlockImage       byte = 0x2C
+	BlockTrailer     byte = 0x3B
+	LabelComment     byte = 0xFE
+	LabelApplication byte = 0xFF
+)
+
+// ApplicationXMP is the identifier and authentication code of the application extension that holds xmp.
+const ApplicationXMP = "XMP DataXMP"
+
+// Errors
+const (
+	ErrNotGIF       ex.Class = "gifmeta; not a gif"
+	ErrTruncated    ex.Class = "gifmeta; truncated block"
+	ErrInvalidBlock ex.Class = "gifmeta; invalid block"
+)
+
+// Block is a block of a gif after its header, i.e. an extension, an image or the trailer.
+type Block struct {
+	// Contents are the bytes of the block, including its introducer and any sub-blocks.
+	Contents []byte
+}
+
+// IsComment returns if the block is a comment extension.
+func (b Block) IsComment() bool {
+	return len(b.Contents) > 1 && b.Contents[0] == BlockExtension && b.Contents[1] == LabelComment
+}
+
+// IsXMP returns if the block is an application extension that holds xmp data.
+func (b Block) IsXMP() bool {
+	return len(b.Contents) > 3+len(ApplicationXMP) &&
+		b.Contents[0] == BlockExtension && b.Contents[1] == LabelApplication &&
+		int(b.Contents[2]) == len(ApplicationXMP) &&
+		string(b.Contents[3:3+len(ApplicationXMP)]) == ApplicationXMP
+}
+
+// Split splits a gif into its header, i.e. the signature, the logical screen descriptor
+// and the global color table, and the blocks that follow it up to and including the trailer.
+func Split(contents []byte) (header []byte, blocks []Block, err error) {
+	if len(contents) < 13 || !(bytes.HasPrefix(contents, []byte("GIF87a")) || bytes.HasPrefix(contents, []byte("GIF89a"))) {
+		err = ex.New(ErrNotGIF)
+		return
+	}
+	offset := 13
+	if flags := contents[10]; flags&0x80 != 0 {
+		offset += 3 << ((flags & 0x07) + 1)
+	}
+	if offset > len(contents) {
+		err = ex.New(ErrTruncated, ex.OptMessage("global color table"))
+		return
+	}
+	header = contents[:offset]
+
+	// skipBlocks skips data sub-blocks, which end with an empty block.
+	skipBlocks := func() bool {
+		for offset < len(contents) {
+			size := int(contents[offset])
+			offset += size + 1
+			if size == 0 {
+				return offset <= len(contents)
+			}
+		}
+		return false
+	}
+	for offset < len(contents) {
+		start := offset
+		switch contents[offset] {
+		case BlockExtension:
+			offset += 2
+			if !skipBlocks() {
+				err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", start))
+				return
+			}
+		case BlockImage:
+			if offset+10 > len(contents) {
+				err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", start))
+				return
+			}
+			flags := contents[offset+9]
+			offset += 10
+			if flags&0x80 != 0 {
+				offset += 3 << ((flags & 0x07) + 1)
+			}
+			// the lzw minimum code size, then the image data.
+			offset++
+			if !skipBlocks() {
+				err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", start))
+				return
+			}
+		case BlockTrailer:
+			blocks = append(blocks, Block{Contents: contents[offset : offset+1]})
+			return
+		default:
+			err = ex.New(ErrInvalidBlock, ex.OptMessagef("offset: %d, introducer: %#x", start, contents[offset]))
+			return
+		}
+		blocks = append(blocks, Block{Contents: contents[start:offset]})
+	}
+	err = ex.New(ErrTruncated, ex.OptMessage("missing trailer"))
+	return
+}
+
+// Join joins a header and blocks back into a gif.
+func Join(header []byte, blocks []Block) []byte {
+	output := bytes.NewBuffer(append([]byte(nil), header...))
+	for _, block := range blocks {
+		output.Write(block.Contents)
+	}
+	return output.Bytes()
+}
+
+// Rewrite rewrites the metadata of a gif per a given filter.
+//
+// Gifs don't have exif data, so the comment and xmp blocks are removed where the comment and
+// xmp segments of a jpeg would be; the other blocks are copied as is.
+func Rewrite(contents []byte, filter jpegmeta.Filter) ([]byte, error) {
+	header, blocks, err := Split(contents)
+	if err != nil {
+		return nil, err
+	}
+	if !filter.StripExtended {
+		return contents, nil
+	}
+	var output []Block
+	for _, block := range blocks {
+		if block.IsComment() || block.IsXMP() {
+			continue
+		}
+		output = append(output, block)
+	}
+	return Join(header, output), nil
+}
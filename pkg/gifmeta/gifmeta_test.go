@@ -0,0 +1,72 @@
+package gifmeta
+
+import (
+	"bytes"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+)
+
+// testGIF returns a small gif with two frames, a comment and xmp.
+func testGIF(t *testing.T) []byte {
+	frame := image.NewPaletted(image.Rect(0, 0, 8, 8), palette.Plan9)
+	buffer := new(bytes.Buffer)
+	if err := gif.EncodeAll(buffer, &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{0, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	header, blocks, err := Split(buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// xmp isn't in sub-blocks; it's followed by a trailer that any sub-block length in it skips to the end of.
+	xmp := []byte("\x21\xff\x0b" + ApplicationXMP + `<x:xmpmeta xmlns:x="adobe:ns:meta/"/>` + "\x01")
+	for size := 0xFF; size >= 0; size-- {
+		xmp = append(xmp, byte(size))
+	}
+	xmp = append(xmp, 0)
+	blocks = append([]Block{{Contents: []byte("\x21\xfe\x09a comment\x00")}, {Contents: xmp}}, blocks...)
+	return Join(header, blocks)
+}
+
+func TestSplitJoin(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := testGIF(t)
+	header, blocks, err := Split(contents)
+	assert.Nil(err)
+	assert.True(blocks[0].IsComment())
+	assert.True(blocks[1].IsXMP())
+	assert.Equal([]byte{BlockTrailer}, blocks[len(blocks)-1].Contents)
+	assert.Equal(contents, Join(header, blocks))
+
+	_, _, err = Split([]byte("not a gif"))
+	assert.NotNil(err)
+	_, _, err = Split(contents[:len(contents)-1])
+	assert.NotNil(err)
+}
+
+func TestRewrite(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := testGIF(t)
+	rewritten, err := Rewrite(contents, jpegmeta.Filter{})
+	assert.Nil(err)
+	assert.Equal(contents, rewritten)
+
+	rewritten, err = Rewrite(contents, jpegmeta.Filter{StripExtended: true})
+	assert.Nil(err)
+	_, blocks, err := Split(rewritten)
+	assert.Nil(err)
+	for _, block := range blocks {
+		assert.False(block.IsComment())
+		assert.False(block.IsXMP())
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(rewritten))
+	assert.Nil(err)
+	assert.Len(decoded.Image, 2)
+}
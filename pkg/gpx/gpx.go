@@ -0,0 +1,116 @@
+package gpx
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// GPX is a gpx document.
+//
+// See: https://www.topografix.com/gpx/1/1/
+type GPX struct {
+	XMLName xml.Name `xml:"gpx"`
+	Tracks  []Track  `xml:"trk"`
+}
+
+// Track is an ordered list of track segments.
+type Track struct {
+	Name     string    `xml:"name,omitempty"`
+	Segments []Segment `xml:"trkseg"`
+}
+
+// Segment is an ordered list of track points.
+type Segment struct {
+	Points []Point `xml:"trkpt"`
+}
+
+// Point is a recorded position.
+type Point struct {
+	Latitude  float64   `xml:"lat,attr"`
+	Longitude float64   `xml:"lon,attr"`
+	Elevation float64   `xml:"ele,omitempty"`
+	Time      time.Time `xml:"time,omitempty"`
+}
+
+// Read reads a gpx document.
+func Read(r io.Reader) (output GPX, err error) {
+	err = ex.New(xml.NewDecoder(r).Decode(&output))
+	return
+}
+
+// ReadFile reads a gpx document from a file.
+func ReadFile(path string) (GPX, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return GPX{}, ex.New(err)
+	}
+	defer f.Close()
+	output, err := Read(f)
+	if err != nil {
+		return GPX{}, ex.New(err).WithMessagef("gpx path: %s", path)
+	}
+	return output, nil
+}
+
+// Points returns the track points of every track with a time, sorted by time.
+func (g GPX) Points() (output Points) {
+	for _, track := range g.Tracks {
+		for _, segment := range track.Segments {
+			for _, point := range segment.Points {
+				if !point.Time.IsZero() {
+					output = append(output, point)
+				}
+			}
+		}
+	}
+	sort.Slice(output, func(i, j int) bool { return output[i].Time.Before(output[j].Time) })
+	return
+}
+
+// Points are track points sorted by time.
+type Points []Point
+
+// Locate returns the position at a given time, and how far apart in time
+// the given time is from the nearest track point.
+//
+// Between two track points the position is interpolated linearly; before the
+// first or after the last track point it is the position of that point.
+// It returns false if there are no track points.
+func (p Points) Locate(t time.Time) (position Point, gap time.Duration, ok bool) {
+	if len(p) == 0 {
+		return
+	}
+	ok = true
+
+	next := sort.Search(len(p), func(index int) bool { return !p[index].Time.Before(t) })
+	if next == 0 {
+		position = p[0]
+		gap = p[0].Time.Sub(t)
+		return
+	}
+	if next == len(p) {
+		position = p[len(p)-1]
+		gap = t.Sub(position.Time)
+		return
+	}
+
+	previous := p[next-1]
+	after := p[next]
+	gap = t.Sub(previous.Time)
+	if untilNext := after.Time.Sub(t); untilNext < gap {
+		gap = untilNext
+	}
+	fraction := float64(t.Sub(previous.Time)) / float64(after.Time.Sub(previous.Time))
+	position = Point{
+		Latitude:  previous.Latitude + fraction*(after.Latitude-previous.Latitude),
+		Longitude: previous.Longitude + fraction*(after.Longitude-previous.Longitude),
+		Elevation: previous.Elevation + fraction*(after.Elevation-previous.Elevation),
+		Time:      t,
+	}
+	return
+}
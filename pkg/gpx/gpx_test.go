@@ -0,0 +1,51 @@
+package gpx
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+const testGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+	<trk>
+		<name>Test</name>
+		<trkseg>
+			<trkpt lat="37.0" lon="-122.0"><ele>10</ele><time>2019-02-11T10:00:00Z</time></trkpt>
+			<trkpt lat="38.0" lon="-123.0"><ele>20</ele><time>2019-02-11T10:10:00Z</time></trkpt>
+			<trkpt lat="39.0" lon="-124.0"></trkpt>
+		</trkseg>
+	</trk>
+</gpx>`
+
+func TestPointsLocate(t *testing.T) {
+	assert := assert.New(t)
+
+	document, err := Read(strings.NewReader(testGPX))
+	assert.Nil(err)
+	points := document.Points()
+	assert.Len(points, 2)
+
+	position, gap, ok := points.Locate(time.Date(2019, 02, 11, 10, 2, 0, 0, time.UTC))
+	assert.True(ok)
+	assert.Equal(2*time.Minute, gap)
+	assert.InDelta(37.2, position.Latitude, 0.000001)
+	assert.InDelta(-122.2, position.Longitude, 0.000001)
+	assert.InDelta(12, position.Elevation, 0.000001)
+
+	// times are compared as instants regardless of the time zone.
+	position, gap, ok = points.Locate(time.Date(2019, 02, 11, 2, 9, 0, 0, time.FixedZone("PST", -8*60*60)))
+	assert.True(ok)
+	assert.Equal(time.Minute, gap)
+	assert.InDelta(37.9, position.Latitude, 0.000001)
+
+	position, gap, ok = points.Locate(time.Date(2019, 02, 11, 9, 0, 0, 0, time.UTC))
+	assert.True(ok)
+	assert.Equal(time.Hour, gap)
+	assert.Equal(37.0, position.Latitude)
+
+	_, _, ok = Points(nil).Locate(time.Now())
+	assert.False(ok)
+}
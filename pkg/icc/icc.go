@@ -0,0 +1,221 @@
+package icc
+
+import (
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// Errors
+const (
+	ErrInvalidProfile     ex.Class = "icc; invalid profile"
+	ErrUnsupportedProfile ex.Class = "icc; unsupported profile, only rgb matrix profiles are supported"
+)
+
+// headerLength is the length of the profile header, which is followed by the tag table.
+const headerLength = 128
+
+// D50ToSRGB converts colors from the d50 xyz profile connection space to linear srgb,
+// with the bradford chromatic adaptation to the d65 white point of srgb.
+var D50ToSRGB = [3][3]float64{
+	{3.1338561, -1.6168667, -0.4906146},
+	{-0.9787684, 1.9161415, 0.0334540},
+	{0.0719453, -0.2289914, 1.4052427},
+}
+
+// Profile is an rgb matrix icc color profile, e.g. Adobe RGB or Display P3; the
+// profile has a tone curve for each channel and the primaries of each channel.
+type Profile struct {
+	// Curves are the tone curves of the red, green and blue channels, from encoded values to linear values.
+	Curves [3]Curve
+	// Matrix converts linear rgb to the d50 xyz profile connection space.
+	Matrix [3][3]float64
+}
+
+// Curve maps an encoded channel value between 0 and 1 to a linear value between 0 and 1.
+type Curve func(float64) float64
+
+// Parse parses an rgb matrix icc profile.
+//
+// Profiles that use lookup tables instead of a matrix, like most printer profiles, are unsupported.
+func Parse(profile []byte) (*Profile, error) {
+	if len(profile) < headerLength+4 || string(profile[36:40]) != "acsp" {
+		return nil, ex.New(ErrInvalidProfile)
+	}
+	if string(profile[16:20]) != "RGB " || string(profile[20:24]) != "XYZ " {
+		return nil, ex.New(ErrUnsupportedProfile, ex.OptMessagef("color space: %q, connection space: %q", profile[16:20], profile[20:24]))
+	}
+
+	tags := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint32(profile[headerLength:]))
+	for index := 0; index < count; index++ {
+		entry := headerLength + 4 + 12*index
+		if entry+12 > len(profile) {
+			return nil, ex.New(ErrInvalidProfile, ex.OptMessage("truncated tag table"))
+		}
+		offset := int(binary.BigEndian.Uint32(profile[entry+4:]))
+		length := int(binary.BigEndian.Uint32(profile[entry+8:]))
+		if offset < 0 || length < 0 || offset+length > len(profile) {
+			return nil, ex.New(ErrInvalidProfile, ex.OptMessagef("tag: %q", profile[entry:entry+4]))
+		}
+		tags[string(profile[entry:entry+4])] = profile[offset : offset+length]
+	}
+
+	var output Profile
+	for channel, names := range [3][2]string{{"rXYZ", "rTRC"}, {"gXYZ", "gTRC"}, {"bXYZ", "bTRC"}} {
+		xyz, ok := tags[names[0]]
+		if !ok || len(xyz) < 20 || string(xyz[:4]) != "XYZ " {
+			return nil, ex.New(ErrUnsupportedProfile, ex.OptMessagef("missing tag: %s", names[0]))
+		}
+		for component := 0; component < 3; component++ {
+			output.Matrix[component][channel] = s15Fixed16(xyz[8+4*component:])
+		}
+		trc, ok := tags[names[1]]
+		if !ok {
+			return nil, ex.New(ErrUnsupportedProfile, ex.OptMessagef("missing tag: %s", names[1]))
+		}
+		curve, err := parseCurve(trc)
+		if err != nil {
+			return nil, ex.New(err, ex.OptMessagef("tag: %s", names[1]))
+		}
+		output.Curves[channel] = curve
+	}
+	return &output, nil
+}
+
+// ToSRGB converts an image in the color space of the profile to srgb.
+// Transparent pixels are converted as if they're opaque.
+func (p *Profile) ToSRGB(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	output := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(output, output.Bounds(), img, bounds.Min, draw.Src)
+
+	// the conversion is the same for every value of a channel, so the curves are tabulated.
+	var linear [3][256]float64
+	for channel, curve := range p.Curves {
+		for value := range linear[channel] {
+			linear[channel][value] = curve(float64(value) / 255)
+		}
+	}
+	matrix := multiply(D50ToSRGB, p.Matrix)
+	for offset := 0; offset < len(output.Pix); offset += 4 {
+		pixel := output.Pix[offset : offset+3 : offset+3]
+		r, g, b := linear[0][pixel[0]], linear[1][pixel[1]], linear[2][pixel[2]]
+		for channel := 0; channel < 3; channel++ {
+			pixel[channel] = encodeSRGB(matrix[channel][0]*r + matrix[channel][1]*g + matrix[channel][2]*b)
+		}
+	}
+	return output
+}
+
+// parseCurve parses a `curv` or `para` tone curve.
+func parseCurve(data []byte) (Curve, error) {
+	if len(data) < 12 {
+		return nil, ex.New(ErrInvalidProfile, ex.OptMessage("truncated curve"))
+	}
+	switch string(data[:4]) {
+	case "curv":
+		count := int(binary.BigEndian.Uint32(data[8:]))
+		if len(data) < 12+2*count {
+			return nil, ex.New(ErrInvalidProfile, ex.OptMessage("truncated curve"))
+		}
+		switch count {
+		case 0:
+			return func(value float64) float64 { return value }, nil
+		case 1:
+			gamma := float64(binary.BigEndian.Uint16(data[12:])) / 256
+			return func(value float64) float64 { return math.Pow(value, gamma) }, nil
+		}
+		table := make([]float64, count)
+		for index := range table {
+			table[index] = float64(binary.BigEndian.Uint16(data[12+2*index:])) / 65535
+		}
+		return func(value float64) float64 {
+			position := value * float64(count-1)
+			index := int(position)
+			if index >= count-1 {
+				return table[count-1]
+			}
+			fraction := position - float64(index)
+			return table[index]*(1-fraction) + table[index+1]*fraction
+		}, nil
+	case "para":
+		function := binary.BigEndian.Uint16(data[8:])
+		parameterCounts := map[uint16]int{0: 1, 1: 3, 2: 4, 3: 5, 4: 7}
+		parameterCount, ok := parameterCounts[function]
+		if !ok || len(data) < 12+4*parameterCount {
+			return nil, ex.New(ErrInvalidProfile, ex.OptMessagef("parametric curve function: %d", function))
+		}
+		// the parameters are g, a, b, c, d, e and f; unused parameters default to the identity.
+		parameters := []float64{1, 1, 0, 0, 0, 0, 0}
+		for index := 0; index < parameterCount; index++ {
+			parameters[index] = s15Fixed16(data[12+4*index:])
+		}
+		return parametricCurve(function, parameters), nil
+	}
+	return nil, ex.New(ErrUnsupportedProfile, ex.OptMessagef("curve type: %q", data[:4]))
+}
+
+// parametricCurve returns a parametric tone curve per the icc specification.
+func parametricCurve(function uint16, p []float64) Curve {
+	g, a, b, c, d, e, f := p[0], p[1], p[2], p[3], p[4], p[5], p[6]
+	power := func(value float64) float64 {
+		if value <= 0 {
+			return 0
+		}
+		return math.Pow(value, g)
+	}
+	return func(x float64) float64 {
+		switch function {
+		case 1:
+			if x < -b/a {
+				return 0
+			}
+			return power(a*x + b)
+		case 2:
+			if x < -b/a {
+				return c
+			}
+			return power(a*x+b) + c
+		case 3:
+			if x < d {
+				return c * x
+			}
+			return power(a*x + b)
+		case 4:
+			if x < d {
+				return c*x + f
+			}
+			return power(a*x+b) + e
+		}
+		return power(x)
+	}
+}
+
+// encodeSRGB encodes a linear value with the srgb tone curve.
+func encodeSRGB(value float64) uint8 {
+	if value <= 0.0031308 {
+		value *= 12.92
+	} else {
+		value = 1.055*math.Pow(value, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(math.Max(0, math.Min(1, value)) * 255))
+}
+
+func s15Fixed16(data []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(data))) / 65536
+}
+
+func multiply(a, b [3][3]float64) (output [3][3]float64) {
+	for row := 0; row < 3; row++ {
+		for column := 0; column < 3; column++ {
+			for index := 0; index < 3; index++ {
+				output[row][column] += a[row][index] * b[index][column]
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,79 @@
+package icc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func fixed(value float64) []byte {
+	output := make([]byte, 4)
+	binary.BigEndian.PutUint32(output, uint32(int32(value*65536)))
+	return output
+}
+
+// testProfile returns an rgb matrix profile with the given d50 primaries and tone curve.
+func testProfile(primaries [3][3]float64, curve []byte) []byte {
+	var tags [][2]interface{}
+	for index, name := range []string{"r", "g", "b"} {
+		xyz := append([]byte("XYZ \x00\x00\x00\x00"), fixed(primaries[index][0])...)
+		xyz = append(append(xyz, fixed(primaries[index][1])...), fixed(primaries[index][2])...)
+		tags = append(tags, [2]interface{}{name + "XYZ", xyz}, [2]interface{}{name + "TRC", curve})
+	}
+
+	header := make([]byte, headerLength)
+	copy(header[16:], "RGB XYZ ")
+	copy(header[36:], "acsp")
+	table := new(bytes.Buffer)
+	data := new(bytes.Buffer)
+	binary.Write(table, binary.BigEndian, uint32(len(tags)))
+	offset := headerLength + 4 + 12*len(tags)
+	for _, tag := range tags {
+		value := tag[1].([]byte)
+		table.WriteString(tag[0].(string))
+		binary.Write(table, binary.BigEndian, uint32(offset+data.Len()))
+		binary.Write(table, binary.BigEndian, uint32(len(value)))
+		data.Write(value)
+	}
+	return append(append(header, table.Bytes()...), data.Bytes()...)
+}
+
+func TestProfileToSRGB(t *testing.T) {
+	assert := assert.New(t)
+
+	// an srgb profile, with the parametric srgb tone curve, converts colors as is.
+	srgbCurve := []byte("para\x00\x00\x00\x00\x00\x03\x00\x00")
+	for _, parameter := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		srgbCurve = append(srgbCurve, fixed(parameter)...)
+	}
+	srgb, err := Parse(testProfile([3][3]float64{{0.4361, 0.2225, 0.0139}, {0.3851, 0.7169, 0.0971}, {0.1431, 0.0606, 0.7141}}, srgbCurve))
+	assert.Nil(err)
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{R: 20, G: 180, B: 240, A: 255})
+	converted := srgb.ToSRGB(img)
+	for x := 0; x < 2; x++ {
+		expected, actual := img.NRGBAAt(x, 0), converted.NRGBAAt(x, 0)
+		for _, difference := range []int{int(expected.R) - int(actual.R), int(expected.G) - int(actual.G), int(expected.B) - int(actual.B)} {
+			assert.True(difference >= -2 && difference <= 2, expected, actual)
+		}
+	}
+
+	// an adobe rgb profile has a wider gamut, so a saturated green is more saturated in srgb, and grays stay gray.
+	adobe, err := Parse(testProfile([3][3]float64{{0.6097, 0.3111, 0.0195}, {0.2053, 0.6257, 0.0609}, {0.1492, 0.0632, 0.7446}}, []byte("curv\x00\x00\x00\x00\x00\x00\x00\x01\x02\x33")))
+	assert.Nil(err)
+	img.SetNRGBA(0, 0, color.NRGBA{R: 100, G: 200, B: 100, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+	converted = adobe.ToSRGB(img)
+	green := converted.NRGBAAt(0, 0)
+	assert.True(green.R < 100 && green.G > 200, green)
+	gray := converted.NRGBAAt(1, 0)
+	assert.True(gray.R == gray.B && gray.G >= gray.R-1 && gray.G <= gray.R+1, gray)
+
+	_, err = Parse([]byte("not a profile"))
+	assert.NotNil(err)
+}
@@ -0,0 +1,238 @@
+package jpegmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// Markers are the jpeg markers relevant to the metadata segments.
+const (
+	MarkerSOI   byte = 0xD8
+	MarkerSOS   byte = 0xDA
+	MarkerAPP0  byte = 0xE0
+	MarkerAPP1  byte = 0xE1
+	MarkerAPP2  byte = 0xE2
+	MarkerAPP13 byte = 0xED
+	MarkerCOM   byte = 0xFE
+)
+
+// Segment headers identify the contents of application segments.
+const (
+	HeaderExif         = "Exif\x00\x00"
+	HeaderXMP          = "http://ns.adobe.com/xap/1.0/\x00"
+	HeaderXMPExtension = "http://ns.adobe.com/xmp/extension/\x00"
+	HeaderICC          = "ICC_PROFILE\x00"
+)
+
+// maxICCChunkLength is the maximum length of a chunk of an icc profile, which is
+// split across segments with the header, a sequence number and the chunk count.
+const maxICCChunkLength = MaxSegmentLength - len(HeaderICC) - 2
+
+// MaxSegmentLength is the maximum length of the data of a segment.
+const MaxSegmentLength = 1<<16 - 1 - 2
+
+// Errors
+const (
+	ErrNotJPEG          ex.Class = "jpegmeta; not a jpeg"
+	ErrTruncated        ex.Class = "jpegmeta; truncated segment"
+	ErrSegmentTooLarge  ex.Class = "jpegmeta; segment too large"
+	ErrMissingImageData ex.Class = "jpegmeta; missing image data"
+)
+
+// Segment is a marker segment that precedes the image data of a jpeg.
+type Segment struct {
+	// Marker is the segment marker, i.e. the byte following 0xFF.
+	Marker byte
+	// Data is the segment data, not including the marker or the length.
+	Data []byte
+}
+
+// IsExif returns if the segment holds exif data.
+func (s Segment) IsExif() bool {
+	return s.Marker == MarkerAPP1 && bytes.HasPrefix(s.Data, []byte(HeaderExif))
+}
+
+// IsXMP returns if the segment holds xmp data.
+func (s Segment) IsXMP() bool {
+	return s.Marker == MarkerAPP1 && (bytes.HasPrefix(s.Data, []byte(HeaderXMP)) || bytes.HasPrefix(s.Data, []byte(HeaderXMPExtension)))
+}
+
+// IsIPTC returns if the segment holds photoshop iptc data.
+func (s Segment) IsIPTC() bool {
+	return s.Marker == MarkerAPP13
+}
+
+// IsICC returns if the segment holds a chunk of an icc color profile.
+func (s Segment) IsICC() bool {
+	return s.Marker == MarkerAPP2 && bytes.HasPrefix(s.Data, []byte(HeaderICC)) && len(s.Data) >= len(HeaderICC)+2
+}
+
+// IsComment returns if the segment is a comment.
+func (s Segment) IsComment() bool {
+	return s.Marker == MarkerCOM
+}
+
+// Split splits a jpeg into the segments that precede the image data, and the
+// image data itself, i.e. everything from the start of scan marker on.
+func Split(contents []byte) (segments []Segment, scan []byte, err error) {
+	if len(contents) < 2 || contents[0] != 0xFF || contents[1] != MarkerSOI {
+		err = ex.New(ErrNotJPEG)
+		return
+	}
+	offset := 2
+	for offset < len(contents) {
+		if contents[offset] != 0xFF {
+			err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", offset))
+			return
+		}
+		// markers can be preceded by any number of fill bytes.
+		for offset < len(contents) && contents[offset] == 0xFF {
+			offset++
+		}
+		if offset >= len(contents) {
+			break
+		}
+		marker := contents[offset]
+		if marker == MarkerSOS {
+			scan = contents[offset-1:]
+			return
+		}
+		offset++
+		if offset+2 > len(contents) {
+			err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", offset))
+			return
+		}
+		length := int(binary.BigEndian.Uint16(contents[offset:]))
+		if length < 2 || offset+length > len(contents) {
+			err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", offset))
+			return
+		}
+		segments = append(segments, Segment{
+			Marker: marker,
+			Data:   contents[offset+2 : offset+length],
+		})
+		offset += length
+	}
+	err = ex.New(ErrMissingImageData)
+	return
+}
+
+// Join joins segments and image data back into a jpeg.
+func Join(segments []Segment, scan []byte) ([]byte, error) {
+	output := new(bytes.Buffer)
+	output.Write([]byte{0xFF, MarkerSOI})
+	for _, segment := range segments {
+		if len(segment.Data) > MaxSegmentLength {
+			return nil, ex.New(ErrSegmentTooLarge, ex.OptMessagef("marker: %#x, length: %d", segment.Marker, len(segment.Data)))
+		}
+		output.Write([]byte{0xFF, segment.Marker})
+		binary.Write(output, binary.BigEndian, uint16(len(segment.Data)+2))
+		output.Write(segment.Data)
+	}
+	output.Write(scan)
+	return output.Bytes(), nil
+}
+
+// Rewrite rewrites the metadata of a jpeg per a given filter.
+//
+// Only the metadata segments are changed; the image data is copied as is.
+// Exif segments that can't be decoded are dropped unless the filter keeps everything.
+func Rewrite(contents []byte, filter Filter) ([]byte, error) {
+	segments, scan, err := Split(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var output []Segment
+	for _, segment := range segments {
+		switch {
+		case segment.IsExif():
+			rewritten, err := RewriteTIFF(segment.Data[len(HeaderExif):], filter)
+			if err != nil {
+				if filter.IsKeepAll() {
+					output = append(output, segment)
+				}
+				continue
+			}
+			if len(rewritten) == 0 {
+				continue
+			}
+			output = append(output, Segment{
+				Marker: MarkerAPP1,
+				Data:   append([]byte(HeaderExif), rewritten...),
+			})
+		case segment.IsXMP(), segment.IsIPTC(), segment.IsComment():
+			if !filter.StripExtended {
+				output = append(output, segment)
+			}
+		default:
+			output = append(output, segment)
+		}
+	}
+	return Join(output, scan)
+}
+
+// Insert inserts segments into a jpeg, after its jfif segment if it has one.
+func Insert(contents []byte, segments ...Segment) ([]byte, error) {
+	existing, scan, err := Split(contents)
+	if err != nil {
+		return nil, err
+	}
+	var index int
+	for index < len(existing) && existing[index].Marker == MarkerAPP0 {
+		index++
+	}
+	output := append(append(append([]Segment(nil), existing[:index]...), segments...), existing[index:]...)
+	return Join(output, scan)
+}
+
+// ICCProfile returns the icc color profile of a jpeg from its segments, joining
+// its chunks in order. It returns nil if there is no profile.
+func ICCProfile(segments []Segment) []byte {
+	chunks := make(map[byte][]byte)
+	var count byte
+	for _, segment := range segments {
+		if !segment.IsICC() {
+			continue
+		}
+		sequence, chunkCount := segment.Data[len(HeaderICC)], segment.Data[len(HeaderICC)+1]
+		chunks[sequence] = segment.Data[len(HeaderICC)+2:]
+		count = chunkCount
+	}
+	if count == 0 {
+		return nil
+	}
+	var output []byte
+	// the sequence numbers start at 1.
+	for sequence := byte(1); sequence <= count && sequence > 0; sequence++ {
+		chunk, ok := chunks[sequence]
+		if !ok {
+			return nil
+		}
+		output = append(output, chunk...)
+	}
+	return output
+}
+
+// ICCSegments splits an icc color profile into the segments that hold it.
+func ICCSegments(profile []byte) []Segment {
+	var chunks [][]byte
+	for len(profile) > maxICCChunkLength {
+		chunks = append(chunks, profile[:maxICCChunkLength])
+		profile = profile[maxICCChunkLength:]
+	}
+	if len(profile) > 0 {
+		chunks = append(chunks, profile)
+	}
+	var output []Segment
+	for index, chunk := range chunks {
+		data := append([]byte(HeaderICC), byte(index+1), byte(len(chunks)))
+		output = append(output, Segment{
+			Marker: MarkerAPP2,
+			Data:   append(data, chunk...),
+		})
+	}
+	return output
+}
@@ -0,0 +1,178 @@
+package jpegmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"math"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/exif"
+	"github.com/wcharczuk/blogctl/pkg/tiff"
+)
+
+func rationals(values ...uint32) []byte {
+	output := make([]byte, 4*len(values))
+	for index, value := range values {
+		binary.LittleEndian.PutUint32(output[4*index:], value)
+	}
+	return output
+}
+
+// testJPEG returns a small jpeg with exif data that includes a gps location
+// of roughly 37.7749, -122.4194, and a comment.
+func testJPEG(t *testing.T) []byte {
+	buffer := new(bytes.Buffer)
+	if err := jpeg.Encode(buffer, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	orientation := make([]byte, 2)
+	binary.LittleEndian.PutUint16(orientation, 6)
+	ifd0 := []*tiff.Tag{
+		{Id: 0x010F, Type: tiff.DTAscii, Count: 6, Val: []byte("Canon\x00")},
+		{Id: 0x0112, Type: tiff.DTShort, Count: 1, Val: orientation},
+		{Id: 0x013B, Type: tiff.DTAscii, Count: 3, Val: []byte("Me\x00")},
+	}
+	gps := []*tiff.Tag{
+		{Id: TagGPSLatitudeRef, Type: tiff.DTAscii, Count: 2, Val: []byte("N\x00")},
+		{Id: TagGPSLatitude, Type: tiff.DTRational, Count: 3, Val: rationals(37, 1, 46, 1, 2964, 100)},
+		{Id: TagGPSLongitudeRef, Type: tiff.DTAscii, Count: 2, Val: []byte("W\x00")},
+		{Id: TagGPSLongitude, Type: tiff.DTRational, Count: 3, Val: rationals(122, 1, 25, 1, 984, 100)},
+		{Id: 0x6, Type: tiff.DTRational, Count: 1, Val: rationals(52, 1)},
+	}
+	raw := encodeTIFF(binary.LittleEndian, ifd0, nil, gps)
+
+	segments, scan, err := Split(buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments = append([]Segment{
+		{Marker: MarkerAPP1, Data: append([]byte(HeaderExif), raw...)},
+		{Marker: MarkerCOM, Data: []byte("a comment")},
+	}, segments...)
+	contents, err := Join(segments, scan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return contents
+}
+
+func TestSplitJoin(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := testJPEG(t)
+	segments, scan, err := Split(contents)
+	assert.Nil(err)
+	assert.True(segments[0].IsExif())
+	assert.True(segments[1].IsComment())
+	joined, err := Join(segments, scan)
+	assert.Nil(err)
+	assert.Equal(contents, joined)
+
+	_, _, err = Split([]byte("not a jpeg"))
+	assert.NotNil(err)
+}
+
+func TestRewriteKeepAll(t *testing.T) {
+	assert := assert.New(t)
+
+	rewritten, err := Rewrite(testJPEG(t), Filter{})
+	assert.Nil(err)
+	x, err := exif.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+	_, err = x.Get(exif.Make)
+	assert.Nil(err)
+	_, err = x.Get(exif.GPSAltitude)
+	assert.Nil(err)
+	segments, _, err := Split(rewritten)
+	assert.Nil(err)
+	assert.True(segments[1].IsComment())
+}
+
+func TestRewriteStrip(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := testJPEG(t)
+	rewritten, err := Rewrite(contents, Filter{
+		Tag:           func(name exif.FieldName) bool { return name == exif.Orientation },
+		StripExtended: true,
+	})
+	assert.Nil(err)
+
+	x, err := exif.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+	orientation, err := x.Get(exif.Orientation)
+	assert.Nil(err)
+	value, err := orientation.Int(0)
+	assert.Nil(err)
+	assert.Equal(6, value)
+	_, err = x.Get(exif.Make)
+	assert.NotNil(err)
+	_, _, err = x.LatLong()
+	assert.NotNil(err)
+
+	segments, scan, err := Split(rewritten)
+	assert.Nil(err)
+	for _, segment := range segments {
+		assert.False(segment.IsComment())
+	}
+	_, originalScan, err := Split(contents)
+	assert.Nil(err)
+	assert.Equal(originalScan, scan)
+
+	_, err = jpeg.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+}
+
+func TestRewriteGPS(t *testing.T) {
+	assert := assert.New(t)
+
+	rewritten, err := Rewrite(testJPEG(t), Filter{GPS: GPSStrip})
+	assert.Nil(err)
+	x, err := exif.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+	_, err = x.Get(exif.Make)
+	assert.Nil(err)
+	_, _, err = x.LatLong()
+	assert.NotNil(err)
+
+	rewritten, err = Rewrite(testJPEG(t), Filter{GPS: GPSRound, Precision: 2})
+	assert.Nil(err)
+	x, err = exif.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+	latitude, longitude, err := x.LatLong()
+	assert.Nil(err)
+	assert.True(math.Abs(latitude-37.77) < 0.000001, latitude)
+	assert.True(math.Abs(longitude+122.42) < 0.000001, longitude)
+	_, err = x.Get(exif.GPSAltitude)
+	assert.NotNil(err)
+}
+
+func TestICCProfile(t *testing.T) {
+	assert := assert.New(t)
+
+	// profiles larger than a segment are split across segments.
+	profile := bytes.Repeat([]byte("profile"), 20000)
+	segments := ICCSegments(profile)
+	assert.Len(segments, 3)
+	for _, segment := range segments {
+		assert.True(segment.IsICC())
+	}
+	// the chunks can be in any order.
+	segments[0], segments[2] = segments[2], segments[0]
+	assert.Equal(profile, ICCProfile(segments))
+	assert.Nil(ICCProfile(segments[:2]))
+	assert.Nil(ICCProfile(nil))
+
+	contents, err := Insert(testJPEG(t), ICCSegments([]byte("profile"))...)
+	assert.Nil(err)
+	inserted, _, err := Split(contents)
+	assert.Nil(err)
+	assert.True(inserted[0].IsICC())
+	assert.True(inserted[1].IsExif())
+	assert.Equal([]byte("profile"), ICCProfile(inserted))
+}
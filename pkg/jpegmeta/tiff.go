@@ -0,0 +1,294 @@
+package jpegmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/exif"
+	"github.com/wcharczuk/blogctl/pkg/tiff"
+)
+
+// Tag ids of the sub-IFD pointers and the gps location.
+const (
+	TagExifPointer    uint16 = 0x8769
+	TagGPSPointer     uint16 = 0x8825
+	TagInteropPointer uint16 = 0xA005
+
+	TagGPSVersionID    uint16 = 0x0
+	TagGPSLatitudeRef  uint16 = 0x1
+	TagGPSLatitude     uint16 = 0x2
+	TagGPSLongitudeRef uint16 = 0x3
+	TagGPSLongitude    uint16 = 0x4
+)
+
+// GPSAction is what to do with the gps data of an image.
+type GPSAction int
+
+// GPSActions
+const (
+	// GPSKeep keeps the gps tags allowed by the filter.
+	GPSKeep GPSAction = iota
+	// GPSStrip removes every gps tag.
+	GPSStrip
+	// GPSRound keeps only the latitude and longitude, rounded to a given precision.
+	GPSRound
+)
+
+// MaxPrecision is the maximum number of decimal places gps coordinates can be rounded to.
+const MaxPrecision = 7
+
+// Filter determines which metadata is kept when rewriting a jpeg.
+type Filter struct {
+	// Tag returns if an exif or gps tag should be kept.
+	// If it is unset every tag is kept.
+	Tag func(exif.FieldName) bool
+	// GPS is what to do with the gps data.
+	GPS GPSAction
+	// Precision is the number of decimal places the gps coordinates are rounded to
+	// if the gps action is to round them.
+	Precision int
+	// StripExtended removes the xmp, iptc and comment segments, which can't be filtered by tag.
+	StripExtended bool
+}
+
+// IsKeepAll returns if the filter keeps all the metadata.
+func (f Filter) IsKeepAll() bool {
+	return f.Tag == nil && f.GPS == GPSKeep && !f.StripExtended
+}
+
+// RewriteTIFF rewrites the tiff structure of exif data per a filter.
+//
+// The rewritten structure only has IFD0, and the exif and gps sub-IFDs;
+// the thumbnail IFD and the interoperability sub-IFD are dropped.
+// It returns an empty slice if no tags are kept.
+func RewriteTIFF(raw []byte, filter Filter) ([]byte, error) {
+	t, err := tiff.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	if len(t.Dirs) == 0 {
+		return nil, nil
+	}
+
+	ifd0, exifPointer, gpsPointer := filterDir(t.Dirs[0], exif.TagFieldName, filter)
+	var exifIFD, gpsIFD []*tiff.Tag
+	if exifPointer != nil {
+		exifDir, err := decodeSubDir(raw, t.Order, exifPointer)
+		if err != nil {
+			return nil, err
+		}
+		exifIFD, _, _ = filterDir(exifDir, exif.TagFieldName, filter)
+	}
+	if gpsPointer != nil && filter.GPS != GPSStrip {
+		gpsDir, err := decodeSubDir(raw, t.Order, gpsPointer)
+		if err != nil {
+			return nil, err
+		}
+		gpsIFD, _, _ = filterDir(gpsDir, exif.GPSTagFieldName, filter)
+		if filter.GPS == GPSRound {
+			gpsIFD, err = roundGPS(gpsIFD, t.Order, filter.Precision)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(ifd0) == 0 && len(exifIFD) == 0 && len(gpsIFD) == 0 {
+		return nil, nil
+	}
+	return encodeTIFF(t.Order, ifd0, exifIFD, gpsIFD), nil
+}
+
+// filterDir returns the tags of a directory kept by the filter, and the sub-IFD pointers.
+func filterDir(dir *tiff.Dir, fieldName func(uint16) (exif.FieldName, bool), filter Filter) (kept []*tiff.Tag, exifPointer, gpsPointer *tiff.Tag) {
+	for _, tag := range dir.Tags {
+		switch tag.Id {
+		case TagExifPointer:
+			exifPointer = tag
+			continue
+		case TagGPSPointer:
+			gpsPointer = tag
+			continue
+		case TagInteropPointer:
+			continue
+		}
+		if filter.Tag != nil {
+			name, ok := fieldName(tag.Id)
+			if !ok || !filter.Tag(name) {
+				continue
+			}
+		}
+		kept = append(kept, tag)
+	}
+	return
+}
+
+// decodeSubDir decodes the sub-IFD a pointer tag points to.
+func decodeSubDir(raw []byte, order binary.ByteOrder, pointer *tiff.Tag) (*tiff.Dir, error) {
+	offset, err := pointer.Int64(0)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	if offset <= 0 || offset >= int64(len(raw)) {
+		return nil, ex.New(ErrTruncated, ex.OptMessagef("sub-IFD offset: %d", offset))
+	}
+	// the sub-IFD value offsets are relative to the start of the tiff structure.
+	r := bytes.NewReader(raw)
+	if _, err := r.Seek(offset, 0); err != nil {
+		return nil, ex.New(err)
+	}
+	dir, _, err := tiff.DecodeDir(r, order)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	return dir, nil
+}
+
+// roundGPS returns the gps version, and the latitude and longitude rounded to a given precision.
+func roundGPS(tags []*tiff.Tag, order binary.ByteOrder, precision int) ([]*tiff.Tag, error) {
+	if precision < 0 {
+		precision = 0
+	}
+	if precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+	var output []*tiff.Tag
+	for _, tag := range tags {
+		switch tag.Id {
+		case TagGPSVersionID, TagGPSLatitudeRef, TagGPSLongitudeRef:
+			output = append(output, tag)
+		case TagGPSLatitude, TagGPSLongitude:
+			degrees, err := Degrees(tag)
+			if err != nil {
+				return nil, err
+			}
+			output = append(output, RationalDegreesTag(tag.Id, order, degrees, precision))
+		}
+	}
+	return output, nil
+}
+
+// Degrees returns the decimal degrees of a gps latitude or longitude tag,
+// that is three rationals for the degrees, minutes and seconds.
+func Degrees(tag *tiff.Tag) (float64, error) {
+	var output float64
+	for index, scale := range []float64{1, 60, 3600} {
+		if int(tag.Count) <= index {
+			break
+		}
+		numerator, denominator, err := tag.Rat2(index)
+		if err != nil {
+			return 0, ex.New(err)
+		}
+		if denominator == 0 {
+			continue
+		}
+		output += float64(numerator) / float64(denominator) / scale
+	}
+	return output, nil
+}
+
+// RationalDegreesTag returns a gps latitude or longitude tag for given (unsigned)
+// decimal degrees, rounded to a given number of decimal places.
+func RationalDegreesTag(id uint16, order binary.ByteOrder, degrees float64, precision int) *tiff.Tag {
+	scale := math.Pow10(precision)
+	value := make([]byte, 24)
+	order.PutUint32(value[0:], uint32(math.Round(math.Abs(degrees)*scale)))
+	order.PutUint32(value[4:], uint32(scale))
+	// the minutes and seconds are zero.
+	order.PutUint32(value[12:], 1)
+	order.PutUint32(value[20:], 1)
+	return &tiff.Tag{
+		Id:    id,
+		Type:  tiff.DTRational,
+		Count: 3,
+		Val:   value,
+	}
+}
+
+// encodeTIFF encodes a tiff structure with IFD0 and optional exif and gps sub-IFDs.
+func encodeTIFF(order binary.ByteOrder, ifd0, exifIFD, gpsIFD []*tiff.Tag) []byte {
+	ifd0 = append([]*tiff.Tag(nil), ifd0...)
+	var exifPointer, gpsPointer *tiff.Tag
+	if len(exifIFD) > 0 {
+		exifPointer = &tiff.Tag{Id: TagExifPointer, Type: tiff.DTLong, Count: 1, Val: make([]byte, 4)}
+		ifd0 = append(ifd0, exifPointer)
+	}
+	if len(gpsIFD) > 0 {
+		gpsPointer = &tiff.Tag{Id: TagGPSPointer, Type: tiff.DTLong, Count: 1, Val: make([]byte, 4)}
+		ifd0 = append(ifd0, gpsPointer)
+	}
+
+	// the directories are written in order following the 8 byte header.
+	ifd0Offset := 8
+	exifOffset := ifd0Offset + dirSize(ifd0)
+	gpsOffset := exifOffset + dirSize(exifIFD)
+	if exifPointer != nil {
+		order.PutUint32(exifPointer.Val, uint32(exifOffset))
+	}
+	if gpsPointer != nil {
+		order.PutUint32(gpsPointer.Val, uint32(gpsOffset))
+	}
+
+	output := new(bytes.Buffer)
+	if order == binary.LittleEndian {
+		output.WriteString("II")
+	} else {
+		output.WriteString("MM")
+	}
+	binary.Write(output, order, uint16(42))
+	binary.Write(output, order, uint32(ifd0Offset))
+	writeDir(output, order, ifd0, ifd0Offset)
+	if len(exifIFD) > 0 {
+		writeDir(output, order, exifIFD, exifOffset)
+	}
+	if len(gpsIFD) > 0 {
+		writeDir(output, order, gpsIFD, gpsOffset)
+	}
+	return output.Bytes()
+}
+
+// dirSize returns the encoded size of a directory including its values.
+func dirSize(tags []*tiff.Tag) int {
+	if len(tags) == 0 {
+		return 0
+	}
+	size := 2 + 12*len(tags) + 4
+	for _, tag := range tags {
+		if len(tag.Val) > 4 {
+			size += len(tag.Val) + len(tag.Val)%2
+		}
+	}
+	return size
+}
+
+// writeDir writes a directory, followed by the values that don't fit in the entries.
+func writeDir(output *bytes.Buffer, order binary.ByteOrder, tags []*tiff.Tag, offset int) {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Id < tags[j].Id })
+
+	values := new(bytes.Buffer)
+	valuesOffset := offset + 2 + 12*len(tags) + 4
+	binary.Write(output, order, uint16(len(tags)))
+	for _, tag := range tags {
+		binary.Write(output, order, tag.Id)
+		binary.Write(output, order, uint16(tag.Type))
+		binary.Write(output, order, tag.Count)
+		if len(tag.Val) <= 4 {
+			inline := make([]byte, 4)
+			copy(inline, tag.Val)
+			output.Write(inline)
+			continue
+		}
+		binary.Write(output, order, uint32(valuesOffset+values.Len()))
+		values.Write(tag.Val)
+		if len(tag.Val)%2 == 1 {
+			values.WriteByte(0)
+		}
+	}
+	// there is no next directory.
+	binary.Write(output, order, uint32(0))
+	output.Write(values.Bytes())
+}
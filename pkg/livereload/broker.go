@@ -0,0 +1,161 @@
+package livereload
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// New returns a new broker.
+func New() *Broker {
+	return &Broker{
+		clients: make(map[chan Event]struct{}),
+	}
+}
+
+// Broker tracks the open browser tabs and pushes build events to them over server sent events.
+type Broker struct {
+	sync.Mutex
+	Log          logger.Log
+	PingInterval time.Duration
+
+	clients   map[chan Event]struct{}
+	lastError string
+}
+
+// Event is a server sent event.
+type Event struct {
+	Name string
+	Data string
+}
+
+// PingIntervalOrDefault returns the ping interval or a default.
+func (b *Broker) PingIntervalOrDefault() time.Duration {
+	if b.PingInterval > 0 {
+		return b.PingInterval
+	}
+	return DefaultPingInterval
+}
+
+// Reload clears any build error and tells every open tab to reload.
+func (b *Broker) Reload() {
+	b.Lock()
+	b.lastError = ""
+	b.Unlock()
+	b.publish(Event{Name: EventReload})
+}
+
+// Error records a build error and tells every open tab to show it.
+// Tabs opened before the next successful build are also shown the error.
+func (b *Broker) Error(err error) {
+	message := fmt.Sprintf("%v", err)
+	b.Lock()
+	b.lastError = message
+	b.Unlock()
+	b.publish(Event{Name: EventError, Data: message})
+}
+
+// Subscribe adds a client and returns the channel it receives events on.
+func (b *Broker) Subscribe() chan Event {
+	b.Lock()
+	defer b.Unlock()
+	client := make(chan Event, 1)
+	b.clients[client] = struct{}{}
+	return client
+}
+
+// Unsubscribe removes a client.
+func (b *Broker) Unsubscribe(client chan Event) {
+	b.Lock()
+	defer b.Unlock()
+	delete(b.clients, client)
+}
+
+// LastError returns the last build error if the last build failed.
+func (b *Broker) LastError() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.lastError
+}
+
+// Middleware serves the event stream and the client script, and adds the client script
+// to every html response of the wrapped action.
+//
+// It is meant to wrap the static file server action; the static file server's catch-all
+// route would conflict with separate routes for the event stream and script.
+func (b *Broker) Middleware(action web.Action) web.Action {
+	injected := Inject(action)
+	return func(r *web.Ctx) web.Result {
+		switch r.Request.URL.Path {
+		case RouteEvents:
+			return b.Events(r)
+		case RouteScript:
+			return b.Script(r)
+		default:
+			return injected(r)
+		}
+	}
+}
+
+// Events is the server sent events action that open tabs connect to.
+func (b *Broker) Events(r *web.Ctx) web.Result {
+	es := webutil.NewEventSource(r.Response)
+	if err := es.StartSession(); err != nil {
+		logger.MaybeError(b.Log, err)
+		return nil
+	}
+	if lastError := b.LastError(); lastError != "" {
+		if err := es.EventData(EventError, lastError); err != nil {
+			return nil
+		}
+	}
+
+	client := b.Subscribe()
+	defer b.Unsubscribe(client)
+
+	ping := time.NewTicker(b.PingIntervalOrDefault())
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-ping.C:
+			if err := es.Ping(); err != nil {
+				return nil
+			}
+		case event := <-client:
+			var err error
+			if event.Data != "" {
+				err = es.EventData(event.Name, event.Data)
+			} else {
+				err = es.Event(event.Name)
+			}
+			if err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// Script serves the client script that listens for events.
+func (b *Broker) Script(r *web.Ctx) web.Result {
+	return web.RawWithContentType("application/javascript", []byte(clientJS))
+}
+
+// publish sends an event to every client without blocking on slow clients;
+// a client that already has a pending event only needs the newest one.
+func (b *Broker) publish(event Event) {
+	b.Lock()
+	defer b.Unlock()
+	for client := range b.clients {
+		select {
+		case <-client:
+		default:
+		}
+		client <- event
+	}
+}
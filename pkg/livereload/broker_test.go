@@ -0,0 +1,30 @@
+package livereload
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestBroker(t *testing.T) {
+	assert := assert.New(t)
+
+	broker := New()
+	client := broker.Subscribe()
+
+	broker.Error(fmt.Errorf("template error"))
+	assert.Equal("template error", broker.LastError())
+	assert.Equal(Event{Name: EventError, Data: "template error"}, <-client)
+
+	// a client that hasn't read its pending event only gets the newest one.
+	broker.Error(fmt.Errorf("template error"))
+	broker.Reload()
+	assert.Empty(broker.LastError())
+	assert.Equal(Event{Name: EventReload}, <-client)
+	assert.Empty(client)
+
+	broker.Unsubscribe(client)
+	broker.Reload()
+	assert.Empty(client)
+}
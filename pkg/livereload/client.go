@@ -0,0 +1,30 @@
+package livereload
+
+// clientJS listens for build events; it reloads the page after a successful build
+// and shows an overlay with the error after a failed build.
+const clientJS = `(function() {
+	var overlayID = "blogctl-livereload-error";
+	function showError(message) {
+		var overlay = document.getElementById(overlayID);
+		if (!overlay) {
+			overlay = document.createElement("div");
+			overlay.id = overlayID;
+			overlay.style.cssText = "position:fixed;top:0;left:0;right:0;bottom:0;z-index:2147483647;overflow:auto;padding:2em;background:rgba(20,20,20,0.92);color:#ff7b72;font:14px/1.5 monospace;";
+			var title = document.createElement("h2");
+			title.textContent = "blogctl: build failed";
+			title.style.cssText = "margin:0 0 1em 0;color:#fff;font:bold 18px sans-serif;";
+			overlay.appendChild(title);
+			overlay.appendChild(document.createElement("pre"));
+			document.body.appendChild(overlay);
+		}
+		overlay.getElementsByTagName("pre")[0].textContent = message;
+	}
+	var source = new EventSource("` + RouteEvents + `");
+	source.addEventListener("` + EventReload + `", function() {
+		window.location.reload();
+	});
+	source.addEventListener("` + EventError + `", function(e) {
+		showError(e.data);
+	});
+})();
+`
@@ -0,0 +1,20 @@
+package livereload
+
+import "time"
+
+// Routes are the routes the broker registers on the web app.
+const (
+	RouteEvents = "/_blogctl/livereload"
+	RouteScript = "/_blogctl/livereload.js"
+)
+
+// Events are the names of the events sent to open tabs.
+const (
+	EventReload = "reload"
+	EventError  = "error"
+)
+
+// DefaultPingInterval is the default interval to ping open tabs to keep the connection alive.
+const (
+	DefaultPingInterval = 15 * time.Second
+)
@@ -0,0 +1,100 @@
+package livereload
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/blend/go-sdk/web"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// Inject is a middleware that adds the client script tag to html responses.
+// Non-html responses are passed through unchanged.
+func Inject(action web.Action) web.Action {
+	return func(r *web.Ctx) web.Result {
+		inner := r.Response
+		buffer := newBufferedResponse(inner)
+		r.Response = buffer
+		defer func() { r.Response = inner }()
+
+		if result := action(r); result != nil {
+			if err := result.Render(r); err != nil {
+				r.Response = inner
+				return web.Text.InternalError(err)
+			}
+		}
+
+		body := buffer.body.Bytes()
+		if strings.HasPrefix(buffer.Header().Get(webutil.HeaderContentType), "text/html") {
+			body = InjectScript(body)
+			buffer.Header().Set(webutil.HeaderContentLength, strconv.Itoa(len(body)))
+		}
+		inner.WriteHeader(buffer.StatusCode())
+		_, _ = inner.Write(body)
+		return nil
+	}
+}
+
+// InjectScript adds the client script tag to an html document,
+// before the closing body tag if there is one, otherwise at the end.
+func InjectScript(contents []byte) []byte {
+	tag := []byte(`<script src="` + RouteScript + `"></script>`)
+	if index := bytes.LastIndex(bytes.ToLower(contents), []byte("</body>")); index >= 0 {
+		output := make([]byte, 0, len(contents)+len(tag))
+		output = append(output, contents[:index]...)
+		output = append(output, tag...)
+		return append(output, contents[index:]...)
+	}
+	return append(contents, tag...)
+}
+
+var (
+	_ web.ResponseWriter = (*bufferedResponse)(nil)
+)
+
+func newBufferedResponse(inner web.ResponseWriter) *bufferedResponse {
+	return &bufferedResponse{
+		inner:      inner,
+		body:       new(bytes.Buffer),
+		statusCode: http.StatusOK,
+	}
+}
+
+// bufferedResponse holds a response in memory so it can be rewritten before it is sent.
+type bufferedResponse struct {
+	inner      web.ResponseWriter
+	body       *bytes.Buffer
+	statusCode int
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.inner.Header()
+}
+
+func (br *bufferedResponse) Write(contents []byte) (int, error) {
+	return br.body.Write(contents)
+}
+
+func (br *bufferedResponse) WriteHeader(statusCode int) {
+	br.statusCode = statusCode
+}
+
+func (br *bufferedResponse) Flush() {}
+
+func (br *bufferedResponse) Close() error {
+	return nil
+}
+
+func (br *bufferedResponse) StatusCode() int {
+	return br.statusCode
+}
+
+func (br *bufferedResponse) ContentLength() int {
+	return br.body.Len()
+}
+
+func (br *bufferedResponse) InnerResponse() http.ResponseWriter {
+	return br.inner
+}
@@ -0,0 +1,16 @@
+package livereload
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestInjectScript(t *testing.T) {
+	assert := assert.New(t)
+
+	tag := `<script src="` + RouteScript + `"></script>`
+	assert.Equal("<html><body><p>hi</p>"+tag+"</body></html>", string(InjectScript([]byte("<html><body><p>hi</p></body></html>"))))
+	assert.Equal("<html><BODY>"+tag+"</BODY></html>", string(InjectScript([]byte("<html><BODY></BODY></html>"))))
+	assert.Equal("<p>partial</p>"+tag, string(InjectScript([]byte("<p>partial</p>"))))
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// BuildStats are stats about a build.
+type BuildStats struct {
+	// Phases are how long each phase of the build took, in order.
+	Phases []Timing
+	// Work is how long each step of processing images took, summed across the images
+	// processed in parallel, so it can be more than the time the build took.
+	Work []Timing
+
+	// PeakHeapBytes is the most memory the heap took, sampled while building.
+	PeakHeapBytes uint64
+	// PeakImageBytes is the most memory reserved for the images being processed at once.
+	PeakImageBytes int64
+}
+
+// Timing is how long something named took.
+type Timing struct {
+	Name    string
+	Elapsed time.Duration
+}
+
+// TableData returns the stats as ansi table data.
+func (bs BuildStats) TableData() (columns []string, rows [][]string) {
+	var row []string
+	for _, phase := range bs.Phases {
+		columns = append(columns, fmt.Sprintf("%s phase", phase.Name))
+		row = append(row, phase.Elapsed.Round(time.Millisecond).String())
+	}
+	for _, work := range bs.Work {
+		columns = append(columns, fmt.Sprintf("%s time", work.Name))
+		row = append(row, work.Elapsed.Round(time.Millisecond).String())
+	}
+	columns = append(columns, "peak heap", "peak image memory")
+	row = append(row, formatBytes(int64(bs.PeakHeapBytes)), formatBytes(bs.PeakImageBytes))
+	rows = [][]string{row}
+	return
+}
+
+func formatBytes(bytes int64) string {
+	return fmt.Sprintf("%.1fMB", float64(bytes)/(1<<20))
+}
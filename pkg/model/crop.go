@@ -0,0 +1,33 @@
+package model
+
+import "image"
+
+// Focus is a point of an image as the fractions of the width and the height from the top left,
+// e.g. `{x: 0.3, y: 0.6}`.
+type Focus struct {
+	X float64 `json:"x" yaml:"x"`
+	Y float64 `json:"y" yaml:"y"`
+}
+
+// IsValid returns if the point is within the image.
+func (f Focus) IsValid() bool {
+	return f.X >= 0 && f.X <= 1 && f.Y >= 0 && f.Y <= 1
+}
+
+// Crop is the region of an image, as it's displayed, kept for an image variant.
+type Crop struct {
+	X      int `json:"x" yaml:"x"`
+	Y      int `json:"y" yaml:"y"`
+	Width  int `json:"width" yaml:"width"`
+	Height int `json:"height" yaml:"height"`
+}
+
+// IsZero returns if the crop is unset.
+func (c Crop) IsZero() bool {
+	return c.Width == 0 || c.Height == 0
+}
+
+// Rectangle returns the crop as a rectangle.
+func (c Crop) Rectangle() image.Rectangle {
+	return image.Rect(c.X, c.Y, c.X+c.Width, c.Y+c.Height)
+}
@@ -9,6 +9,9 @@ type Data struct {
 	BaseURL string  `json:"baseURl,omitempty" yaml:"baseURL,omitempty"`
 	Posts   []*Post `json:"posts,omitempty" yaml:"posts,omitempty"`
 	Tags    []Tag   `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Unlisted are the unlisted posts; they are rendered but not listed anywhere.
+	Unlisted []*Post `json:"-" yaml:"-"`
 }
 
 // IsZero returns if the object is set.
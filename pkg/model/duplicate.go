@@ -0,0 +1,36 @@
+package model
+
+// Duplicate is a set of near identical images from different posts.
+type Duplicate struct {
+	Images []DuplicateImage `json:"images" yaml:"images"`
+}
+
+// DuplicateImage is an image of a set of duplicates.
+type DuplicateImage struct {
+	Title          string `json:"title" yaml:"title"`
+	Slug           string `json:"slug" yaml:"slug"`
+	SourcePath     string `json:"sourcePath" yaml:"sourcePath"`
+	PerceptualHash string `json:"perceptualHash" yaml:"perceptualHash"`
+	// Distance is the hamming distance of the perceptual hash to the first image of the set.
+	Distance int `json:"distance" yaml:"distance"`
+}
+
+// Duplicates are sets of near identical images.
+type Duplicates []Duplicate
+
+// TableRows returns the table rows for the images of every set of duplicates.
+func (d Duplicates) TableRows() []DuplicateTableRow {
+	var output []DuplicateTableRow
+	for index, duplicate := range d {
+		for _, image := range duplicate.Images {
+			output = append(output, DuplicateTableRow{
+				Set:        index + 1,
+				Title:      image.Title,
+				Slug:       image.Slug,
+				SourcePath: image.SourcePath,
+				Distance:   image.Distance,
+			})
+		}
+	}
+	return output
+}
@@ -0,0 +1,10 @@
+package model
+
+// DuplicateTableRow is a row for table output.
+type DuplicateTableRow struct {
+	Set        int
+	Title      string
+	Slug       string
+	SourcePath string
+	Distance   int
+}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
 
 // Exif are known values for a subset of the full image exif data.
 type Exif struct {
@@ -12,4 +16,23 @@ type Exif struct {
 	ExposureTime    string    `json:"exposureTime" yaml:"exposureTime"`
 	FocalLength     string    `json:"focalLength" yaml:"focalLength"`
 	ISOSpeedRatings string    `json:"isoSpeedRatings" yaml:"isoSpeedRatings"`
+	Orientation     int       `json:"orientation,omitempty" yaml:"orientation,omitempty"`
+	Latitude        float64   `json:"latitude,omitempty" yaml:"latitude,omitempty"`
+	Longitude       float64   `json:"longitude,omitempty" yaml:"longitude,omitempty"`
+	Altitude        float64   `json:"altitude,omitempty" yaml:"altitude,omitempty"`
+}
+
+// HasLocation returns if the image has a gps location.
+func (e Exif) HasLocation() bool {
+	return e.Latitude != 0 || e.Longitude != 0
+}
+
+// IsOriented returns if the image must be rotated or flipped to be displayed upright.
+func (e Exif) IsOriented() bool {
+	return e.Orientation > constants.OrientationNormal && e.Orientation <= constants.OrientationRotate270
+}
+
+// IsTransposed returns if the width and height of the image are swapped when it is displayed upright.
+func (e Exif) IsTransposed() bool {
+	return e.Orientation >= constants.OrientationTranspose && e.Orientation <= constants.OrientationRotate270
 }
@@ -1,14 +1,118 @@
 package model
 
-import "image"
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
 
 // Image represents a posted image.
 type Image struct {
 	SourcePath string            `json:"sourcePath" yaml:"sourcePath"`
+	OutputPath string            `json:"outputPath,omitempty" yaml:"outputPath,omitempty"`
+	Format     string            `json:"format,omitempty" yaml:"format,omitempty"`
+	HasAlpha   bool              `json:"hasAlpha,omitempty" yaml:"hasAlpha,omitempty"`
 	Width      int               `json:"width" yaml:"width"`
 	Height     int               `json:"height" yaml:"height"`
 	Exif       Exif              `json:"exif" yaml:"exif"`
 	Sizes      map[string]string `json:"sizes,omitempty" yaml:"sizes,omitempty"`
+	// FileSize is the size of the image file in bytes.
+	FileSize int64 `json:"fileSize,omitempty" yaml:"fileSize,omitempty"`
+	// Frames is the number of frames of a gif.
+	Frames int `json:"frames,omitempty" yaml:"frames,omitempty"`
+	// Focus is the point of the image that is kept when it's cropped, from the meta.
+	Focus *Focus `json:"focus,omitempty" yaml:"focus,omitempty"`
+	// Crops are the regions of the image, as it's displayed, kept for each image variant that is cropped.
+	Crops map[string]Crop `json:"crops,omitempty" yaml:"crops,omitempty"`
+	// BlurHash is the blurhash of the image, to draw a placeholder while it loads.
+	BlurHash string `json:"blurHash,omitempty" yaml:"blurHash,omitempty"`
+	// Placeholder is a tiny preview of the image as a data uri, to show while it loads.
+	Placeholder string `json:"placeholder,omitempty" yaml:"placeholder,omitempty"`
+	// Color is the dominant color of the image as a hex string, e.g. `#1e90ff`.
+	Color string `json:"color,omitempty" yaml:"color,omitempty"`
+	// ColorName is the name of the basic color nearest to the dominant color, e.g. `blue`.
+	ColorName string `json:"colorName,omitempty" yaml:"colorName,omitempty"`
+	// Palette are the dominant colors of the image as hex strings, most dominant first.
+	Palette []string `json:"palette,omitempty" yaml:"palette,omitempty"`
+	// PerceptualHash is the difference hash of the image, to find near identical images.
+	PerceptualHash string `json:"perceptualHash,omitempty" yaml:"perceptualHash,omitempty"`
+	Caption        string `json:"caption,omitempty" yaml:"caption,omitempty"`
+	Alt            string `json:"alt,omitempty" yaml:"alt,omitempty"`
+}
+
+// AltOrDefault returns the alt text, or the caption if the alt text isn't set.
+func (i Image) AltOrDefault() string {
+	if i.Alt != "" {
+		return i.Alt
+	}
+	return i.Caption
+}
+
+// OriginalExtension returns the file extension of the original image.
+func (i Image) OriginalExtension() string {
+	extension := strings.ToLower(filepath.Ext(i.SourcePath))
+	if extension == "" || extension == constants.ExtensionJPEG {
+		return constants.ExtensionJPG
+	}
+	return extension
+}
+
+// ThumbnailExtension returns the file extension of the thumbnails of the image.
+//
+// Png and gif thumbnails keep their format so they keep their transparency (and for gifs,
+// their animation). Webp images are only decoded, so their thumbnails are jpegs, or pngs
+// if they have transparency. Everything else is a jpeg.
+func (i Image) ThumbnailExtension() string {
+	switch i.Format {
+	case constants.ImageFormatPNG:
+		return constants.ExtensionPNG
+	case constants.ImageFormatGIF:
+		return constants.ExtensionGIF
+	case constants.ImageFormatWebP:
+		if i.HasAlpha {
+			return constants.ExtensionPNG
+		}
+	}
+	return constants.ExtensionJPG
+}
+
+// PathOriginal returns the path of the original image, relative to the output path.
+func (i Image) PathOriginal() string {
+	return filepath.Join(i.OutputPath, fmt.Sprintf(constants.ImageOriginalFormat, i.OriginalExtension()))
+}
+
+// PathForSize returns the path of the image for a given size in pixels, relative to the output path.
+func (i Image) PathForSize(size int) string {
+	return filepath.Join(i.OutputPath, fmt.Sprintf(constants.ImageSizeFormat, size, i.ThumbnailExtension()))
+}
+
+// Path returns the path of the image for a given size (e.g. `1024`), image variant
+// (e.g. `square`) or `original`, relative to the output path.
+func (i Image) Path(name string) (string, error) {
+	if path, ok := i.Sizes[name]; ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("image has no size or variant %q; image: %s", name, i.SourcePath)
+}
+
+// PathLarge returns the path of the large image.
+func (i Image) PathLarge() string {
+	return i.PathForSize(constants.SizeLarge)
+}
+
+// PathMedium returns the path of the medium image.
+func (i Image) PathMedium() string {
+	return i.PathForSize(constants.SizeMedium)
+}
+
+// PathSmall returns the path of the small image.
+func (i Image) PathSmall() string {
+	return i.PathForSize(constants.SizeSmall)
 }
 
 // IsZero returns if the image has been processed or not.
@@ -32,13 +136,35 @@ func (i Image) Ratio() float64 {
 
 // Scale returns the image dimensions scaled to a given long dimension.
 func (i Image) Scale(longDimension int) image.Rectangle {
-	scaled := int(float64(longDimension) / i.Ratio())
 	if i.Width > i.Height {
 		return image.Rectangle{
-			Max: image.Point{X: longDimension, Y: scaled},
+			Max: image.Point{X: longDimension, Y: int(float64(longDimension) / i.Ratio())},
 		}
 	}
 	return image.Rectangle{
-		Max: image.Point{X: scaled, Y: longDimension},
+		Max: image.Point{X: int(float64(longDimension) * i.Ratio()), Y: longDimension},
+	}
+}
+
+// ScaleThumbnail returns the dimensions of the thumbnail of the image for a given size.
+// Thumbnails are never larger than the image itself.
+func (i Image) ScaleThumbnail(size int) image.Rectangle {
+	if size >= i.LongDimension() {
+		return image.Rectangle{
+			Max: image.Point{X: i.Width, Y: i.Height},
+		}
+	}
+	return i.Scale(size)
+}
+
+// ThumbnailSizes returns the sizes of the thumbnails of the image, smallest first.
+func (i Image) ThumbnailSizes() []int {
+	var output []int
+	for key := range i.Sizes {
+		if size, err := strconv.Atoi(key); err == nil {
+			output = append(output, size)
+		}
 	}
+	sort.Ints(output)
+	return output
 }
@@ -0,0 +1,11 @@
+package model
+
+// ImageMeta is extra data for one of the images of a post.
+type ImageMeta struct {
+	// File is the file name of the image within the post directory.
+	File    string `json:"file" yaml:"file"`
+	Caption string `json:"caption,omitempty" yaml:"caption,omitempty"`
+	Alt     string `json:"alt,omitempty" yaml:"alt,omitempty"`
+	// Focus is the point of the image that is kept when it's cropped, overriding the focus of the post.
+	Focus *Focus `json:"focus,omitempty" yaml:"focus,omitempty"`
+}
@@ -28,3 +28,17 @@ func TestImageScale(t *testing.T) {
 	assert.Equal(1024, i.Scale(1024).Dx())
 	assert.Equal(682, i.Scale(1024).Dy())
 }
+
+func TestImageScalePortrait(t *testing.T) {
+	assert := assert.New(t)
+
+	i := Image{
+		Width:  3840,
+		Height: 5760,
+	}
+
+	assert.Equal(682, i.Scale(1024).Dx())
+	assert.Equal(1024, i.Scale(1024).Dy())
+	assert.Equal(3840, i.ScaleThumbnail(8192).Dx())
+	assert.Equal(5760, i.ScaleThumbnail(8192).Dy())
+}
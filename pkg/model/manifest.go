@@ -0,0 +1,14 @@
+package model
+
+// Manifest records the inputs that produced each output of a build.
+// It is persisted between builds so unchanged outputs can be skipped.
+type Manifest struct {
+	Version string                   `json:"version"`
+	Outputs map[string]ManifestEntry `json:"outputs"`
+}
+
+// ManifestEntry is the record for a single output file.
+type ManifestEntry struct {
+	Hash   string   `json:"hash"`
+	Inputs []string `json:"inputs,omitempty"`
+}
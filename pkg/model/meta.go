@@ -10,4 +10,31 @@ type Meta struct {
 	Comments string            `json:"comments,omitempty" yaml:"comments,omitempty"`
 	Tags     []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
 	Extra    map[string]string `json:"extra,omitempty" yaml:"extra,omitempty"`
+
+	// Latitude, Longitude and Altitude are the location of the post, e.g. from `blogctl fix geotag`.
+	// They take precedence over the gps location in the exif data of the images.
+	Latitude  float64 `json:"latitude,omitempty" yaml:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty" yaml:"longitude,omitempty"`
+	Altitude  float64 `json:"altitude,omitempty" yaml:"altitude,omitempty"`
+
+	// Focus is the point of the images of the post that is kept when they're cropped
+	// for image variants, overriding the smart crop. Images can set their own focus.
+	Focus *Focus `json:"focus,omitempty" yaml:"focus,omitempty"`
+
+	// Images are the captions and alt text for the images of a post, in the order they're shown.
+	// Images not listed here are shown after the listed images, in filename order.
+	Images []ImageMeta `json:"images,omitempty" yaml:"images,omitempty"`
+
+	// Draft posts are only rendered when drafts are included in the build.
+	Draft bool `json:"draft,omitempty" yaml:"draft,omitempty"`
+	// PublishAt is the time a scheduled post is published; the post is hidden until then.
+	PublishAt time.Time `json:"publishAt,omitempty" yaml:"publishAt,omitempty"`
+	// Unlisted posts are rendered at their slug but left out of the
+	// index, tags, feeds, previous and next links and data.json.
+	Unlisted bool `json:"unlisted,omitempty" yaml:"unlisted,omitempty"`
+}
+
+// HasLocation returns if the meta sets the location of the post.
+func (m Meta) HasLocation() bool {
+	return m.Latitude != 0 || m.Longitude != 0
 }
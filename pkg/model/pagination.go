@@ -0,0 +1,52 @@
+package model
+
+// Pagination is a single page of a paginated list of posts.
+type Pagination struct {
+	// Page is the current page number, starting at 1.
+	Page int
+	// TotalPages is the total number of pages.
+	TotalPages int
+	// Posts are the posts on the current page.
+	Posts []*Post
+	// URLs are the urls of every page, in order, relative to the base url.
+	URLs []string
+}
+
+// HasPrevious returns if there is a previous page.
+func (p Pagination) HasPrevious() bool {
+	return p.Page > 1
+}
+
+// HasNext returns if there is a next page.
+func (p Pagination) HasNext() bool {
+	return p.Page < p.TotalPages
+}
+
+// URL returns the url of the current page.
+func (p Pagination) URL() string {
+	return p.URLForPage(p.Page)
+}
+
+// PreviousURL returns the url of the previous page, or an empty string if there isn't one.
+func (p Pagination) PreviousURL() string {
+	if !p.HasPrevious() {
+		return ""
+	}
+	return p.URLForPage(p.Page - 1)
+}
+
+// NextURL returns the url of the next page, or an empty string if there isn't one.
+func (p Pagination) NextURL() string {
+	if !p.HasNext() {
+		return ""
+	}
+	return p.URLForPage(p.Page + 1)
+}
+
+// URLForPage returns the url of a given page number, or an empty string if it is out of range.
+func (p Pagination) URLForPage(page int) string {
+	if page < 1 || page > len(p.URLs) {
+		return ""
+	}
+	return p.URLs[page-1]
+}
@@ -1,9 +1,9 @@
 package model
 
 import (
-	"fmt"
 	"html/template"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +21,8 @@ type Post struct {
 	Meta  Meta  `json:"meta" yaml:"meta"`
 	Text  Text  `json:"text,omitempty" yaml:"text,omitempty"`
 	Image Image `json:"image,omitempty" yaml:"image,omitempty"`
+	// Images are every image of the post in order; the first image is also the post's `Image`.
+	Images []Image `json:"images,omitempty" yaml:"images,omitempty"`
 
 	Template *template.Template `json:"-" yaml:"-"`
 	Previous *Post              `json:"-" yaml:"-"`
@@ -34,6 +36,17 @@ func (p Post) Labels() map[string]string {
 		"location": p.Meta.Location,
 		"slug":     p.Slug,
 		"postType": p.PostType(),
+		"state":    p.PublicationState(time.Now().UTC()),
+	}
+	if image, ok := p.GeotaggedImage(); ok {
+		output["geotagged"] = "true"
+		output["latitude"] = strconv.FormatFloat(image.Exif.Latitude, 'f', -1, 64)
+		output["longitude"] = strconv.FormatFloat(image.Exif.Longitude, 'f', -1, 64)
+	} else {
+		output["geotagged"] = "false"
+	}
+	if p.Image.ColorName != "" {
+		output["color"] = p.Image.ColorName
 	}
 	for _, tag := range p.Meta.Tags {
 		output[tag] = "tagged"
@@ -41,12 +54,48 @@ func (p Post) Labels() map[string]string {
 	return output
 }
 
+// ImagePath returns the path of the cover image of the post for a given size (e.g. `1024`),
+// image variant (e.g. `square`) or `original`, relative to the output path.
+func (p Post) ImagePath(name string) (string, error) {
+	return p.Image.Path(name)
+}
+
+// GeotaggedImage returns the first image of the post with a gps location, if any.
+func (p Post) GeotaggedImage() (Image, bool) {
+	for _, image := range p.Images {
+		if image.Exif.HasLocation() {
+			return image, true
+		}
+	}
+	return Image{}, false
+}
+
 // PostType returns a string version of the post type.
 func (p Post) PostType() string {
 	if p.IsText() {
-		return "text"
+		return constants.PostTypeText
+	}
+	return constants.PostTypeImage
+}
+
+// PublicationState returns the publication state of the post at a given time;
+// one of `draft`, `scheduled`, `unlisted` or `published`.
+func (p Post) PublicationState(now time.Time) string {
+	if p.Meta.Draft {
+		return constants.PublicationStateDraft
+	}
+	if p.IsScheduled(now) {
+		return constants.PublicationStateScheduled
+	}
+	if p.Meta.Unlisted {
+		return constants.PublicationStateUnlisted
 	}
-	return "image"
+	return constants.PublicationStatePublished
+}
+
+// IsScheduled returns if the post is scheduled to be published after a given time.
+func (p Post) IsScheduled(now time.Time) bool {
+	return !p.Meta.PublishAt.IsZero() && p.Meta.PublishAt.After(now)
 }
 
 // IsZero returns if the post is set.
@@ -59,6 +108,11 @@ func (p Post) IsImage() bool {
 	return !p.Image.IsZero()
 }
 
+// IsGallery returns if the post has more than one image.
+func (p Post) IsGallery() bool {
+	return len(p.Images) > 1
+}
+
 // IsText returns if the post is an text post.
 func (p Post) IsText() bool {
 	return !p.Text.IsZero()
@@ -87,12 +141,12 @@ func (p Post) IndexPath() string {
 
 // ImagePathOriginal returns the fully qualified image source path.
 func (p Post) ImagePathOriginal() string {
-	return filepath.Join(p.Slug, constants.FileImageOriginal)
+	return p.slugImage().PathOriginal()
 }
 
 // ImagePathForSize returns the image source for a given image size in pixels.
 func (p Post) ImagePathForSize(size int) string {
-	return filepath.Join(p.Slug, fmt.Sprintf(constants.ImageSizeFormat, size))
+	return p.slugImage().PathForSize(size)
 }
 
 // ImagePathLarge returns the fully qualified image source path.
@@ -110,6 +164,13 @@ func (p Post) ImagePathSmall() string {
 	return p.ImagePathForSize(constants.SizeSmall)
 }
 
+// slugImage returns the post image as written to the post's slug.
+func (p Post) slugImage() Image {
+	image := p.Image
+	image.OutputPath = p.Slug
+	return image
+}
+
 // TableRow returns a post as an ansi table row.
 func (p Post) TableRow() PostTableRow {
 	return PostTableRow{
@@ -119,5 +180,6 @@ func (p Post) TableRow() PostTableRow {
 		Slug:     p.Slug,
 		Tags:     strings.Join(p.Meta.Tags, ", "),
 		PostType: p.PostType(),
+		State:    p.PublicationState(time.Now().UTC()),
 	}
 }
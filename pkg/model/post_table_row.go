@@ -10,4 +10,5 @@ type PostTableRow struct {
 	Tags     string
 	Slug     string
 	PostType string
+	State    string
 }
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
+func TestPostPublicationState(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2020, 01, 02, 03, 04, 05, 0, time.UTC)
+	assert.Equal(constants.PublicationStatePublished, Post{}.PublicationState(now))
+	assert.Equal(constants.PublicationStateDraft, Post{Meta: Meta{Draft: true, Unlisted: true}}.PublicationState(now))
+	assert.Equal(constants.PublicationStateScheduled, Post{Meta: Meta{PublishAt: now.Add(time.Hour), Unlisted: true}}.PublicationState(now))
+	assert.Equal(constants.PublicationStateUnlisted, Post{Meta: Meta{PublishAt: now.Add(-time.Hour), Unlisted: true}}.PublicationState(now))
+	assert.Equal(constants.PublicationStatePublished, Post{Meta: Meta{PublishAt: now}}.PublicationState(now))
+}
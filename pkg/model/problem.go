@@ -0,0 +1,41 @@
+package model
+
+import "fmt"
+
+// Problem is a mistake found validating the config, posts or templates of a blog.
+type Problem struct {
+	// Path is the file or directory with the mistake.
+	Path string `json:"path" yaml:"path"`
+	// Line is the line of the file with the mistake, if it's known.
+	Line    int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// String returns the problem in the form `path:line: message`.
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.Path, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// Problems orders problems by path and line.
+type Problems []Problem
+
+// Len implements sorter.
+func (p Problems) Len() int {
+	return len(p)
+}
+
+// Swap implements sorter.
+func (p Problems) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// Less implements sorter.
+func (p Problems) Less(i, j int) bool {
+	if p[i].Path != p[j].Path {
+		return p[i].Path < p[j].Path
+	}
+	return p[i].Line < p[j].Line
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"os"
+	"time"
+)
+
+// SourceIndex records the etag and metadata of the images of the posts by their path,
+// so images that haven't changed don't have to be read again.
+// It is persisted in the thumbnail cache between builds.
+type SourceIndex struct {
+	Version string                      `json:"version"`
+	Entries map[string]SourceIndexEntry `json:"entries"`
+}
+
+// SourceIndexEntry is the record for a single image.
+type SourceIndexEntry struct {
+	// Size and ModTime are of the file when it was indexed.
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+
+	ETag     string `json:"etag"`
+	Format   string `json:"format"`
+	HasAlpha bool   `json:"hasAlpha,omitempty"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Frames   int    `json:"frames,omitempty"`
+	Exif     Exif   `json:"exif"`
+}
+
+// IsCurrent returns if the entry is for a file with the size and modification time of given file info.
+func (sie SourceIndexEntry) IsCurrent(info os.FileInfo) bool {
+	return sie.Size == info.Size() && sie.ModTime.Equal(info.ModTime())
+}
+
+// Image returns the image for the entry.
+func (sie SourceIndexEntry) Image(sourcePath string) Image {
+	return Image{
+		SourcePath: sourcePath,
+		Format:     sie.Format,
+		HasAlpha:   sie.HasAlpha,
+		Width:      sie.Width,
+		Height:     sie.Height,
+		Exif:       sie.Exif,
+		FileSize:   sie.Size,
+		Frames:     sie.Frames,
+	}
+}
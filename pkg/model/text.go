@@ -1,6 +1,15 @@
 package model
 
+import (
+	"strings"
+
+	"github.com/wcharczuk/blogctl/pkg/constants"
+)
+
 // Text is a text post.
+//
+// For html posts, the template is the html template source of the post.
+// For markdown posts, the template is the html rendered from the markdown.
 type Text struct {
 	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
 	Template   string `json:"template,omitempty" yaml:"template,omitempty"`
@@ -11,3 +20,13 @@ type Text struct {
 func (t Text) IsZero() bool {
 	return t.Template == ""
 }
+
+// IsMarkdown returns if the post source is markdown.
+func (t Text) IsMarkdown() bool {
+	for _, ext := range constants.MarkdownExtensions {
+		if strings.HasSuffix(strings.ToLower(t.SourcePath), ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -14,6 +14,9 @@ type ViewModel struct {
 
 	Post Post
 	Tag  Tag
+
+	// Pagination is the current page of the posts for the index page and tag pages.
+	Pagination Pagination
 }
 
 // TitleOrDefault returns the title.
@@ -0,0 +1,212 @@
+package palette
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Color names.
+const (
+	Black  = "black"
+	White  = "white"
+	Gray   = "gray"
+	Red    = "red"
+	Orange = "orange"
+	Brown  = "brown"
+	Yellow = "yellow"
+	Green  = "green"
+	Cyan   = "cyan"
+	Blue   = "blue"
+	Purple = "purple"
+	Pink   = "pink"
+)
+
+// MinAlpha is the minimum alpha of the pixels that are included in a palette,
+// so mostly transparent pixels don't count.
+const MinAlpha = 128
+
+// Extract returns up to a given number of the dominant colors of an image,
+// ordered by how many pixels they represent, using median cut.
+//
+// Every pixel is sampled, so the image should be downscaled first.
+func Extract(img image.Image, count int) []color.RGBA {
+	if count < 1 {
+		return nil
+	}
+	bounds := img.Bounds()
+	pixels := make([][3]uint8, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < MinAlpha {
+				continue
+			}
+			pixels = append(pixels, [3]uint8{c.R, c.G, c.B})
+		}
+	}
+	if len(pixels) == 0 {
+		return nil
+	}
+
+	boxes := []box{{pixels: pixels}}
+	for len(boxes) < count {
+		// split the box with the widest range of any channel.
+		widest, widestRange := -1, 0
+		for index, b := range boxes {
+			if len(b.pixels) < 2 {
+				continue
+			}
+			if _, channelRange := b.widestChannel(); channelRange > widestRange {
+				widest, widestRange = index, channelRange
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		lower, upper := boxes[widest].split()
+		boxes[widest] = lower
+		boxes = append(boxes, upper)
+	}
+
+	sort.SliceStable(boxes, func(i, j int) bool { return len(boxes[i].pixels) > len(boxes[j].pixels) })
+	output := make([]color.RGBA, len(boxes))
+	for index, b := range boxes {
+		output[index] = b.average()
+	}
+	return output
+}
+
+// Hex returns a color as a hex string, e.g. `#1e90ff`.
+func Hex(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+// Name returns the name of the basic color nearest to a color, e.g. `blue`.
+func Name(c color.Color) string {
+	rgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	hue, saturation, lightness := hsl(rgba)
+	switch {
+	case lightness < 0.12:
+		return Black
+	case lightness > 0.92:
+		return White
+	case saturation < 0.15:
+		return Gray
+	}
+	switch {
+	case hue < 15 || hue >= 340:
+		return Red
+	case hue < 45:
+		if lightness < 0.4 {
+			return Brown
+		}
+		return Orange
+	case hue < 70:
+		return Yellow
+	case hue < 165:
+		return Green
+	case hue < 195:
+		return Cyan
+	case hue < 255:
+		return Blue
+	case hue < 290:
+		return Purple
+	default:
+		return Pink
+	}
+}
+
+// hsl returns the hue in degrees, and the saturation and lightness between 0 and 1 of a color.
+func hsl(c color.NRGBA) (hue, saturation, lightness float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	lightness = (max + min) / 2
+	if max == min {
+		return
+	}
+	delta := max - min
+	if lightness > 0.5 {
+		saturation = delta / (2 - max - min)
+	} else {
+		saturation = delta / (max + min)
+	}
+	switch max {
+	case r:
+		hue = math.Mod((g-b)/delta, 6)
+	case g:
+		hue = (b-r)/delta + 2
+	default:
+		hue = (r-g)/delta + 4
+	}
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+	return
+}
+
+// box is a set of pixels in color space.
+type box struct {
+	pixels [][3]uint8
+}
+
+// widestChannel returns the channel with the widest range of values, and the range.
+func (b box) widestChannel() (channel, channelRange int) {
+	for index := 0; index < 3; index++ {
+		min, max := 255, 0
+		for _, pixel := range b.pixels {
+			value := int(pixel[index])
+			if value < min {
+				min = value
+			}
+			if value > max {
+				max = value
+			}
+		}
+		if max-min > channelRange {
+			channel, channelRange = index, max-min
+		}
+	}
+	return
+}
+
+// split splits a box at the median of its widest channel.
+//
+// The split is moved to the nearest change in value, so pixels with the same
+// value end up in the same box.
+func (b box) split() (lower, upper box) {
+	channel, _ := b.widestChannel()
+	sort.Slice(b.pixels, func(i, j int) bool { return b.pixels[i][channel] < b.pixels[j][channel] })
+	isBoundary := func(index int) bool {
+		return index > 0 && index < len(b.pixels) && b.pixels[index-1][channel] != b.pixels[index][channel]
+	}
+	median := len(b.pixels) / 2
+	for offset := 0; offset < len(b.pixels); offset++ {
+		if isBoundary(median - offset) {
+			median -= offset
+			break
+		}
+		if isBoundary(median + offset) {
+			median += offset
+			break
+		}
+	}
+	return box{pixels: b.pixels[:median]}, box{pixels: b.pixels[median:]}
+}
+
+// average returns the average color of the pixels of the box.
+func (b box) average() color.RGBA {
+	var r, g, bl int
+	for _, pixel := range b.pixels {
+		r += int(pixel[0])
+		g += int(pixel[1])
+		bl += int(pixel[2])
+	}
+	count := len(b.pixels)
+	return color.RGBA{R: uint8(r / count), G: uint8(g / count), B: uint8(bl / count), A: 0xff}
+}
@@ -0,0 +1,50 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestExtract(t *testing.T) {
+	assert := assert.New(t)
+
+	// three quarters blue, one quarter red, and a transparent strip that is ignored.
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 10))
+	draw.Draw(img, image.Rect(0, 0, 8, 6), &image.Uniform{C: color.NRGBA{B: 255, A: 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 6, 8, 8), &image.Uniform{C: color.NRGBA{R: 255, A: 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 8, 8, 10), &image.Uniform{C: color.NRGBA{G: 255, A: 10}}, image.Point{}, draw.Src)
+
+	// there are only two opaque colors, so only two are returned.
+	colors := Extract(img, 4)
+	assert.Len(colors, 2)
+	assert.Equal("#0000ff", Hex(colors[0]))
+	assert.Equal(Blue, Name(colors[0]))
+	assert.Equal("#ff0000", Hex(colors[1]))
+
+	colors = Extract(img, 1)
+	assert.Len(colors, 1)
+	assert.Equal(Purple, Name(colors[0]))
+
+	assert.Empty(Extract(image.NewNRGBA(image.Rect(0, 0, 4, 4)), 4))
+}
+
+func TestName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(Black, Name(color.RGBA{R: 10, G: 10, B: 10, A: 255}))
+	assert.Equal(White, Name(color.RGBA{R: 250, G: 250, B: 250, A: 255}))
+	assert.Equal(Gray, Name(color.RGBA{R: 128, G: 128, B: 130, A: 255}))
+	assert.Equal(Red, Name(color.RGBA{R: 220, G: 20, B: 30, A: 255}))
+	assert.Equal(Orange, Name(color.RGBA{R: 255, G: 140, B: 0, A: 255}))
+	assert.Equal(Brown, Name(color.RGBA{R: 110, G: 60, B: 20, A: 255}))
+	assert.Equal(Yellow, Name(color.RGBA{R: 240, G: 220, B: 30, A: 255}))
+	assert.Equal(Green, Name(color.RGBA{R: 30, G: 160, B: 60, A: 255}))
+	assert.Equal(Cyan, Name(color.RGBA{R: 0, G: 200, B: 210, A: 255}))
+	assert.Equal(Blue, Name(color.RGBA{R: 30, G: 144, B: 255, A: 255}))
+	assert.Equal(Purple, Name(color.RGBA{R: 128, G: 0, B: 200, A: 255}))
+	assert.Equal(Pink, Name(color.RGBA{R: 255, G: 105, B: 180, A: 255}))
+}
@@ -0,0 +1,62 @@
+package phash
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+	"strconv"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// Hash is a 64 bit perceptual hash of an image.
+type Hash uint64
+
+// Distance returns the hamming distance between two hashes, that is the number
+// of bits that differ; near identical images have a small distance.
+func (h Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(h) ^ uint64(other))
+}
+
+// String returns the hash as 16 hex digits.
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// Parse parses a hash from its hex string.
+func Parse(value string) (Hash, error) {
+	parsed, err := strconv.ParseUint(value, 16, 64)
+	if err != nil {
+		return 0, ex.New(err)
+	}
+	return Hash(parsed), nil
+}
+
+// DHash returns the difference hash of an image.
+//
+// The image is downscaled to 9x8 grayscale pixels, and each bit is whether a
+// pixel is brighter than its neighbor to the right, so the hash is unaffected by
+// the size, the compression and small changes to the brightness of an image.
+// See: http://www.hackerfactor.com/blog/index.php?/archives/529-Kind-of-Like-That.html
+func DHash(img image.Image) Hash {
+	sample := resize.Resize(9, 8, img, resize.Bilinear)
+	bounds := sample.Bounds()
+
+	var output Hash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			output <<= 1
+			if luminance(sample.At(bounds.Min.X+x, bounds.Min.Y+y)) > luminance(sample.At(bounds.Min.X+x+1, bounds.Min.Y+y)) {
+				output |= 1
+			}
+		}
+	}
+	return output
+}
+
+func luminance(c color.Color) uint8 {
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
@@ -0,0 +1,58 @@
+package phash
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/resize"
+)
+
+// gradient returns an image that gets brighter to the right, with a dark band.
+func gradient(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := uint8(255 * x / width)
+			if x > width/3 && x < width/2 {
+				value = 0
+			}
+			img.Set(x, y, color.RGBA{R: value, G: value, B: value, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDHash(t *testing.T) {
+	assert := assert.New(t)
+
+	original := DHash(gradient(300, 200))
+	resized := DHash(resize.Resize(150, 100, gradient(300, 200), resize.Bicubic))
+	assert.True(original.Distance(resized) <= 2, original.Distance(resized))
+
+	flipped := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	source := gradient(300, 200)
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			flipped.Set(299-x, y, source.At(x, y))
+		}
+	}
+	assert.True(original.Distance(DHash(flipped)) > 10, original.Distance(DHash(flipped)))
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	hash := Hash(0x0123456789abcdef)
+	assert.Equal("0123456789abcdef", hash.String())
+	parsed, err := Parse(hash.String())
+	assert.Nil(err)
+	assert.Equal(hash, parsed)
+	assert.Zero(hash.Distance(parsed))
+	assert.Equal(64, Hash(0).Distance(^Hash(0)))
+
+	_, err = Parse("not a hash")
+	assert.NotNil(err)
+}
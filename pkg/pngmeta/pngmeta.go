@@ -0,0 +1,145 @@
+package pngmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+)
+
+// Signature is the signature every png starts with.
+const Signature = "\x89PNG\r\n\x1a\n"
+
+// Chunks are the png chunk types relevant to the metadata.
+const (
+	ChunkExif = "eXIf"
+	ChunkText = "tEXt"
+	// ChunkCompressedText is also where some tools put exif, as a "Raw profile type exif".
+	ChunkCompressedText    = "zTXt"
+	ChunkInternationalText = "iTXt"
+	ChunkTime              = "tIME"
+	ChunkEnd               = "IEND"
+)
+
+// Errors
+const (
+	ErrNotPNG     ex.Class = "pngmeta; not a png"
+	ErrTruncated  ex.Class = "pngmeta; truncated chunk"
+	ErrMissingEnd ex.Class = "pngmeta; missing end chunk"
+)
+
+// Chunk is a chunk of a png.
+type Chunk struct {
+	// Type is the four letter chunk type, e.g. `IDAT`.
+	Type string
+	// Data is the chunk data, not including the length, the type or the crc.
+	Data []byte
+}
+
+// IsExif returns if the chunk holds exif data.
+func (c Chunk) IsExif() bool {
+	return c.Type == ChunkExif
+}
+
+// IsText returns if the chunk holds text, which includes xmp and comments.
+func (c Chunk) IsText() bool {
+	return c.Type == ChunkText || c.Type == ChunkCompressedText || c.Type == ChunkInternationalText
+}
+
+// Split splits a png into its chunks, up to and including the end chunk.
+func Split(contents []byte) (chunks []Chunk, err error) {
+	if !bytes.HasPrefix(contents, []byte(Signature)) {
+		err = ex.New(ErrNotPNG)
+		return
+	}
+	offset := len(Signature)
+	for offset < len(contents) {
+		if offset+8 > len(contents) {
+			err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", offset))
+			return
+		}
+		length := int(binary.BigEndian.Uint32(contents[offset:]))
+		chunkType := string(contents[offset+4 : offset+8])
+		// the length, the type, the data and the crc.
+		if length < 0 || offset+12+length > len(contents) {
+			err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", offset))
+			return
+		}
+		chunks = append(chunks, Chunk{
+			Type: chunkType,
+			Data: contents[offset+8 : offset+8+length],
+		})
+		offset += 12 + length
+		if chunkType == ChunkEnd {
+			return
+		}
+	}
+	err = ex.New(ErrMissingEnd)
+	return
+}
+
+// Join joins chunks back into a png, computing the crc of each chunk.
+func Join(chunks []Chunk) []byte {
+	output := new(bytes.Buffer)
+	output.WriteString(Signature)
+	for _, chunk := range chunks {
+		binary.Write(output, binary.BigEndian, uint32(len(chunk.Data)))
+		output.WriteString(chunk.Type)
+		output.Write(chunk.Data)
+		crc := crc32.NewIEEE()
+		crc.Write([]byte(chunk.Type))
+		crc.Write(chunk.Data)
+		binary.Write(output, binary.BigEndian, crc.Sum32())
+	}
+	return output.Bytes()
+}
+
+// Exif returns the exif data of a png from its chunks, i.e. a tiff structure, or nil if it has none.
+func Exif(chunks []Chunk) []byte {
+	for _, chunk := range chunks {
+		if chunk.IsExif() {
+			return chunk.Data
+		}
+	}
+	return nil
+}
+
+// Rewrite rewrites the metadata of a png per a given filter.
+//
+// The exif chunk is filtered like the exif segment of a jpeg, and the text and time chunks are removed
+// where the xmp, iptc and comment segments of a jpeg would be; the other chunks are copied as is.
+// Exif chunks that can't be decoded are dropped unless the filter keeps everything.
+func Rewrite(contents []byte, filter jpegmeta.Filter) ([]byte, error) {
+	chunks, err := Split(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var output []Chunk
+	for _, chunk := range chunks {
+		switch {
+		case chunk.IsExif():
+			rewritten, err := jpegmeta.RewriteTIFF(chunk.Data, filter)
+			if err != nil {
+				if filter.IsKeepAll() {
+					output = append(output, chunk)
+				}
+				continue
+			}
+			if len(rewritten) == 0 {
+				continue
+			}
+			output = append(output, Chunk{Type: ChunkExif, Data: rewritten})
+		case chunk.IsText(), chunk.Type == ChunkTime:
+			if !filter.StripExtended {
+				output = append(output, chunk)
+			}
+		default:
+			output = append(output, chunk)
+		}
+	}
+	return Join(output), nil
+}
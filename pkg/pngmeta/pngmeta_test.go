@@ -0,0 +1,70 @@
+package pngmeta
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+)
+
+// testPNG returns a small png with a text chunk and an exif chunk that can't be decoded.
+func testPNG(t *testing.T) []byte {
+	buffer := new(bytes.Buffer)
+	if err := png.Encode(buffer, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := Split(buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks = append(chunks[:1], append([]Chunk{
+		{Type: ChunkText, Data: []byte("Comment\x00a comment")},
+		{Type: ChunkExif, Data: []byte("not exif")},
+	}, chunks[1:]...)...)
+	return Join(chunks)
+}
+
+func TestSplitJoin(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := testPNG(t)
+	chunks, err := Split(contents)
+	assert.Nil(err)
+	assert.Equal("IHDR", chunks[0].Type)
+	assert.True(chunks[1].IsText())
+	assert.True(chunks[2].IsExif())
+	assert.Equal(ChunkEnd, chunks[len(chunks)-1].Type)
+	assert.Equal(contents, Join(chunks))
+	_, err = png.Decode(bytes.NewReader(contents))
+	assert.Nil(err)
+
+	_, err = Split([]byte("not a png"))
+	assert.NotNil(err)
+	_, err = Split(contents[:len(contents)-4])
+	assert.NotNil(err)
+}
+
+func TestRewrite(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := testPNG(t)
+	rewritten, err := Rewrite(contents, jpegmeta.Filter{})
+	assert.Nil(err)
+	assert.Equal(contents, rewritten)
+
+	// exif that can't be decoded is dropped along with the text unless everything is kept.
+	rewritten, err = Rewrite(contents, jpegmeta.Filter{StripExtended: true})
+	assert.Nil(err)
+	chunks, err := Split(rewritten)
+	assert.Nil(err)
+	for _, chunk := range chunks {
+		assert.False(chunk.IsText())
+		assert.False(chunk.IsExif())
+	}
+	_, err = png.Decode(bytes.NewReader(rewritten))
+	assert.Nil(err)
+}
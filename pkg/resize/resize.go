@@ -3,6 +3,7 @@ package resize
 import (
 	"image"
 	"runtime"
+	"strings"
 	"sync"
 )
 
@@ -27,6 +28,32 @@ const (
 	Lanczos3
 )
 
+// interpolationFunctionNames are the names of the interpolation functions, as used in the config.
+var interpolationFunctionNames = map[InterpolationFunction]string{
+	NearestNeighbor:   "nearest",
+	Bilinear:          "bilinear",
+	Bicubic:           "bicubic",
+	MitchellNetravali: "mitchell-netravali",
+	Lanczos2:          "lanczos2",
+	Lanczos3:          "lanczos3",
+}
+
+// String returns the name of the interpolation function.
+func (i InterpolationFunction) String() string {
+	return interpolationFunctionNames[i]
+}
+
+// ParseInterpolationFunction returns the interpolation function for a name,
+// e.g. `lanczos3`. It returns false if the name is unknown.
+func ParseInterpolationFunction(name string) (InterpolationFunction, bool) {
+	for interp, interpName := range interpolationFunctionNames {
+		if strings.EqualFold(name, interpName) {
+			return interp, true
+		}
+	}
+	return NearestNeighbor, false
+}
+
 // kernal, returns an InterpolationFunctions taps and kernel.
 func (i InterpolationFunction) kernel() (int, func(float64) float64) {
 	switch i {
@@ -0,0 +1,90 @@
+package resize
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// UnsharpMask sharpens an image by adding the difference between it and a blurred copy of it,
+// which increases the contrast of edges; resized images look soft without it.
+//
+// The radius is the standard deviation of the gaussian blur in pixels, the amount is how much
+// of the difference is added (e.g. 0.5 for 50%), and differences below the threshold (0 to 255)
+// are ignored so flat areas and noise aren't sharpened.
+func UnsharpMask(img image.Image, radius, amount float64, threshold uint8) *image.RGBA {
+	bounds := img.Bounds()
+	output := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(output, output.Bounds(), img, bounds.Min, draw.Src)
+	if radius <= 0 || amount <= 0 {
+		return output
+	}
+
+	width, height := bounds.Dx(), bounds.Dy()
+	blurred := gaussianBlur(output, radius)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			offset := y*output.Stride + x*4
+			alpha := float64(output.Pix[offset+3])
+			for channel := 0; channel < 3; channel++ {
+				value := float64(output.Pix[offset+channel])
+				difference := value - blurred[(y*width+x)*3+channel]
+				if math.Abs(difference) < float64(threshold) {
+					continue
+				}
+				// the pixels are premultiplied, so the channels can't exceed the alpha.
+				output.Pix[offset+channel] = uint8(math.Round(math.Max(0, math.Min(alpha, value+amount*difference))))
+			}
+		}
+	}
+	return output
+}
+
+// gaussianBlur returns the red, green and blue channels of an image blurred with
+// a gaussian kernel with a given standard deviation, in two separable passes.
+func gaussianBlur(img *image.RGBA, sigma float64) []float64 {
+	kernel := gaussianKernel(sigma)
+	center := len(kernel) / 2
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+
+	horizontal := make([]float64, width*height*3)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for index, weight := range kernel {
+				offset := y*img.Stride + clampInt(x+index-center, 0, width-1)*4
+				for channel := 0; channel < 3; channel++ {
+					horizontal[(y*width+x)*3+channel] += weight * float64(img.Pix[offset+channel])
+				}
+			}
+		}
+	}
+
+	output := make([]float64, width*height*3)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for index, weight := range kernel {
+				offset := (clampInt(y+index-center, 0, height-1)*width + x) * 3
+				for channel := 0; channel < 3; channel++ {
+					output[(y*width+x)*3+channel] += weight * horizontal[offset+channel]
+				}
+			}
+		}
+	}
+	return output
+}
+
+// gaussianKernel returns the normalized weights of a gaussian kernel covering three standard deviations.
+func gaussianKernel(sigma float64) []float64 {
+	center := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*center+1)
+	var sum float64
+	for index := range kernel {
+		distance := float64(index - center)
+		kernel[index] = math.Exp(-(distance * distance) / (2 * sigma * sigma))
+		sum += kernel[index]
+	}
+	for index := range kernel {
+		kernel[index] /= sum
+	}
+	return kernel
+}
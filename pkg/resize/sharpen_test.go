@@ -0,0 +1,41 @@
+package resize
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestUnsharpMask(t *testing.T) {
+	assert := assert.New(t)
+
+	// a gray image, darker on the left half.
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	draw.Draw(img, image.Rect(0, 0, 10, 10), &image.Uniform{C: color.Gray{Y: 100}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(10, 0, 20, 10), &image.Uniform{C: color.Gray{Y: 150}}, image.Point{}, draw.Src)
+
+	sharpened := UnsharpMask(img, 1, 1, 0)
+	assert.Equal(img.Bounds(), sharpened.Bounds())
+	// the edge has more contrast, and flat areas are unchanged.
+	assert.True(sharpened.RGBAAt(9, 5).R < 100, sharpened.RGBAAt(9, 5))
+	assert.True(sharpened.RGBAAt(10, 5).R > 150, sharpened.RGBAAt(10, 5))
+	assert.Equal(img.RGBAAt(0, 5), sharpened.RGBAAt(0, 5))
+	assert.Equal(img.RGBAAt(19, 5), sharpened.RGBAAt(19, 5))
+
+	// differences below the threshold are ignored.
+	assert.Equal(img.Pix, UnsharpMask(img, 1, 1, 255).Pix)
+}
+
+func TestParseInterpolationFunction(t *testing.T) {
+	assert := assert.New(t)
+
+	interp, ok := ParseInterpolationFunction("Lanczos3")
+	assert.True(ok)
+	assert.Equal(Lanczos3, interp)
+	assert.Equal("mitchell-netravali", MitchellNetravali.String())
+	_, ok = ParseInterpolationFunction("sinc")
+	assert.False(ok)
+}
@@ -0,0 +1,143 @@
+package resize
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Smart crop scoring weights.
+const (
+	// SmartCropSaturationWeight is how much saturated pixels count relative to edges.
+	SmartCropSaturationWeight = 0.5
+	// SmartCropEntropyWeight is how much the entropy of a window counts relative to its edges and saturation.
+	SmartCropEntropyWeight = 0.25
+	// SmartCropMaxSteps is the maximum number of positions tried along each axis.
+	SmartCropMaxSteps = 32
+)
+
+// entropyBins is the number of luminance bins used for the entropy of a window.
+const entropyBins = 32
+
+// SmartCrop returns the region of an image with a given size that has the most interesting
+// content, for cropping an image without cutting off its subject.
+//
+// Each pixel is scored by its edges (the laplacian of its luminance) and its saturation, and
+// each candidate region by the average score of its pixels plus the entropy of its luminance.
+// If every region scores the same, e.g. for a flat image, the region is centered.
+//
+// The image is analyzed at every pixel, so it should be downscaled first.
+func SmartCrop(img image.Image, width, height int) image.Rectangle {
+	bounds := img.Bounds()
+	imageWidth, imageHeight := bounds.Dx(), bounds.Dy()
+	if width > imageWidth {
+		width = imageWidth
+	}
+	if height > imageHeight {
+		height = imageHeight
+	}
+	if width <= 0 || height <= 0 {
+		return image.Rectangle{Min: bounds.Min, Max: bounds.Min}
+	}
+
+	luminance := make([]float64, imageWidth*imageHeight)
+	saturation := make([]float64, imageWidth*imageHeight)
+	for y := 0; y < imageHeight; y++ {
+		for x := 0; x < imageWidth; x++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			luminance[y*imageWidth+x], saturation[y*imageWidth+x] = luminanceSaturation(c)
+		}
+	}
+	at := func(x, y int) float64 {
+		x = clampInt(x, 0, imageWidth-1)
+		y = clampInt(y, 0, imageHeight-1)
+		return luminance[y*imageWidth+x]
+	}
+
+	// integral is the summed area table of the pixel scores, so the score of
+	// any window can be computed from its corners.
+	integral := make([]float64, (imageWidth+1)*(imageHeight+1))
+	for y := 0; y < imageHeight; y++ {
+		var row float64
+		for x := 0; x < imageWidth; x++ {
+			edge := math.Abs(4*at(x, y) - at(x-1, y) - at(x+1, y) - at(x, y-1) - at(x, y+1))
+			row += math.Min(edge, 1) + SmartCropSaturationWeight*saturation[y*imageWidth+x]
+			integral[(y+1)*(imageWidth+1)+x+1] = integral[y*(imageWidth+1)+x+1] + row
+		}
+	}
+
+	windowScore := func(x, y int) float64 {
+		sum := integral[(y+height)*(imageWidth+1)+x+width] -
+			integral[y*(imageWidth+1)+x+width] -
+			integral[(y+height)*(imageWidth+1)+x] +
+			integral[y*(imageWidth+1)+x]
+		return sum/float64(width*height) + SmartCropEntropyWeight*windowEntropy(luminance, imageWidth, x, y, width, height)
+	}
+
+	bestX, bestY := (imageWidth-width)/2, (imageHeight-height)/2
+	bestScore := windowScore(bestX, bestY)
+	stepX := step(imageWidth - width)
+	stepY := step(imageHeight - height)
+	for y := 0; y <= imageHeight-height; y += stepY {
+		for x := 0; x <= imageWidth-width; x += stepX {
+			// a small margin keeps the centered window unless another is clearly better.
+			if score := windowScore(x, y); score > bestScore+1e-9 {
+				bestX, bestY, bestScore = x, y, score
+			}
+		}
+	}
+	return image.Rect(bounds.Min.X+bestX, bounds.Min.Y+bestY, bounds.Min.X+bestX+width, bounds.Min.Y+bestY+height)
+}
+
+// luminanceSaturation returns the luminance and the saturation of a color between 0 and 1.
+// Very dark and very light colors have no saturation.
+func luminanceSaturation(c color.NRGBA) (luminance, saturation float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	luminance = 0.299*r + 0.587*g + 0.114*b
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	if max == 0 || luminance < 0.05 || luminance > 0.95 {
+		return
+	}
+	saturation = (max - min) / max
+	return
+}
+
+// windowEntropy returns the entropy of the luminance of a window, between 0 and 1.
+func windowEntropy(luminance []float64, stride, x, y, width, height int) float64 {
+	var histogram [entropyBins]int
+	for row := y; row < y+height; row++ {
+		for column := x; column < x+width; column++ {
+			bin := int(luminance[row*stride+column] * entropyBins)
+			histogram[clampInt(bin, 0, entropyBins-1)]++
+		}
+	}
+	total := float64(width * height)
+	var entropy float64
+	for _, count := range histogram {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy / math.Log2(entropyBins)
+}
+
+// step returns the step between the positions tried along an axis with a given amount of free space.
+func step(free int) int {
+	if free <= SmartCropMaxSteps {
+		return 1
+	}
+	return int(math.Ceil(float64(free) / SmartCropMaxSteps))
+}
+
+func clampInt(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,39 @@
+package resize
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestSmartCrop(t *testing.T) {
+	assert := assert.New(t)
+
+	// a flat gray image with a saturated, detailed subject on the right.
+	img := image.NewRGBA(image.Rect(0, 0, 120, 60))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: 128, G: 128, B: 128, A: 255}}, image.Point{}, draw.Src)
+	for y := 20; y < 40; y++ {
+		for x := 90; x < 110; x++ {
+			if (x+y)%2 == 0 {
+				img.Set(x, y, color.RGBA{R: 255, G: 40, B: 40, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{R: 40, G: 40, B: 255, A: 255})
+			}
+		}
+	}
+
+	crop := SmartCrop(img, 60, 60)
+	assert.Equal(image.Pt(60, 60), crop.Size())
+	assert.True(crop.Min.X >= 50, crop)
+	assert.True(image.Rect(90, 20, 110, 40).In(crop), crop)
+
+	// flat images are cropped at the center.
+	flat := image.NewRGBA(image.Rect(0, 0, 120, 60))
+	assert.Equal(image.Rect(30, 0, 90, 60), SmartCrop(flat, 60, 60))
+
+	// crops are clamped to the image.
+	assert.Equal(flat.Bounds(), SmartCrop(flat, 200, 100))
+}
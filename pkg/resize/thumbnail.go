@@ -12,13 +12,21 @@ func Thumbnail(maxWidth, maxHeight uint, img image.Image, interp InterpolationFu
 	origBounds := img.Bounds()
 	origWidth := uint(origBounds.Dx())
 	origHeight := uint(origBounds.Dy())
-	newWidth, newHeight := origWidth, origHeight
 
 	// Return original image if it have same or smaller size as constraints
 	if maxWidth >= origWidth && maxHeight >= origHeight {
 		return img
 	}
 
+	newWidth, newHeight := ThumbnailDimensions(maxWidth, maxHeight, origWidth, origHeight)
+	return Resize(newWidth, newHeight, img, interp)
+}
+
+// ThumbnailDimensions returns the dimensions Thumbnail downscales an image
+// with the given dimensions to.
+func ThumbnailDimensions(maxWidth, maxHeight, origWidth, origHeight uint) (uint, uint) {
+	newWidth, newHeight := origWidth, origHeight
+
 	// Preserve aspect ratio
 	if origWidth > maxWidth {
 		newHeight = uint(origHeight * maxWidth / origWidth)
@@ -35,5 +43,5 @@ func Thumbnail(maxWidth, maxHeight uint, img image.Image, interp InterpolationFu
 		}
 		newHeight = maxHeight
 	}
-	return Resize(newWidth, newHeight, img, interp)
+	return newWidth, newHeight
 }
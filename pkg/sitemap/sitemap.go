@@ -0,0 +1,46 @@
+package sitemap
+
+import "encoding/xml"
+
+// Namespaces are the xml namespaces used by sitemaps.
+const (
+	Namespace      = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	ImageNamespace = "http://www.google.com/schemas/sitemap-image/1.1"
+)
+
+// URLSet is a sitemap, i.e. a list of urls.
+//
+// See: https://www.sitemaps.org/protocol.html
+type URLSet struct {
+	XMLName    xml.Name `xml:"urlset"`
+	XMLNS      string   `xml:"xmlns,attr"`
+	XMLNSImage string   `xml:"xmlns:image,attr,omitempty"`
+	URLs       []URL    `xml:"url"`
+}
+
+// URL is a single page in a sitemap.
+type URL struct {
+	Loc     string  `xml:"loc"`
+	LastMod string  `xml:"lastmod,omitempty"`
+	Images  []Image `xml:"image:image,omitempty"`
+}
+
+// Image is an image on a page, for google image sitemaps.
+//
+// See: https://developers.google.com/search/docs/advanced/sitemaps/image-sitemaps
+type Image struct {
+	Loc string `xml:"image:loc"`
+}
+
+// Index is a sitemap index, i.e. a list of sitemaps.
+type Index struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Sitemaps []Entry  `xml:"sitemap"`
+}
+
+// Entry is a single sitemap in a sitemap index.
+type Entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
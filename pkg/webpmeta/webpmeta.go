@@ -0,0 +1,162 @@
+package webpmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/blend/go-sdk/ex"
+
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+)
+
+// Chunks are the webp chunk types relevant to the metadata.
+const (
+	ChunkExtended = "VP8X"
+	ChunkExif     = "EXIF"
+	ChunkXMP      = "XMP "
+)
+
+// Flags are the flags of the extended chunk that say which metadata chunks a webp has.
+const (
+	FlagExif byte = 0x08
+	FlagXMP  byte = 0x04
+)
+
+// Errors
+const (
+	ErrNotWebP   ex.Class = "webpmeta; not a webp"
+	ErrTruncated ex.Class = "webpmeta; truncated chunk"
+)
+
+// Chunk is a chunk of a webp.
+type Chunk struct {
+	// FourCC is the four character chunk type, e.g. `VP8L`.
+	FourCC string
+	// Data is the chunk data, not including the type, the size or the padding.
+	Data []byte
+}
+
+// IsExif returns if the chunk holds exif data.
+func (c Chunk) IsExif() bool {
+	return c.FourCC == ChunkExif
+}
+
+// IsXMP returns if the chunk holds xmp data.
+func (c Chunk) IsXMP() bool {
+	return c.FourCC == ChunkXMP
+}
+
+// Split splits a webp into the chunks of its riff container.
+func Split(contents []byte) (chunks []Chunk, err error) {
+	if len(contents) < 12 || string(contents[:4]) != "RIFF" || string(contents[8:12]) != "WEBP" {
+		err = ex.New(ErrNotWebP)
+		return
+	}
+	// the riff size covers everything after it; anything past it isn't part of the webp.
+	end := 8 + int(binary.LittleEndian.Uint32(contents[4:]))
+	if end > len(contents) {
+		err = ex.New(ErrTruncated, ex.OptMessagef("riff size: %d", end-8))
+		return
+	}
+	offset := 12
+	for offset < end {
+		if offset+8 > end {
+			err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", offset))
+			return
+		}
+		size := int(binary.LittleEndian.Uint32(contents[offset+4:]))
+		if size < 0 || offset+8+size > end {
+			err = ex.New(ErrTruncated, ex.OptMessagef("offset: %d", offset))
+			return
+		}
+		chunks = append(chunks, Chunk{
+			FourCC: string(contents[offset : offset+4]),
+			Data:   contents[offset+8 : offset+8+size],
+		})
+		// chunks are padded to an even size.
+		offset += 8 + size + size&1
+	}
+	return
+}
+
+// Join joins chunks back into a webp.
+func Join(chunks []Chunk) []byte {
+	body := new(bytes.Buffer)
+	body.WriteString("WEBP")
+	for _, chunk := range chunks {
+		body.WriteString(chunk.FourCC)
+		binary.Write(body, binary.LittleEndian, uint32(len(chunk.Data)))
+		body.Write(chunk.Data)
+		if len(chunk.Data)&1 == 1 {
+			body.WriteByte(0)
+		}
+	}
+	output := new(bytes.Buffer)
+	output.WriteString("RIFF")
+	binary.Write(output, binary.LittleEndian, uint32(body.Len()))
+	output.Write(body.Bytes())
+	return output.Bytes()
+}
+
+// Exif returns the exif data of a webp from its chunks, i.e. a tiff structure, or nil if it has none.
+func Exif(chunks []Chunk) []byte {
+	for _, chunk := range chunks {
+		if chunk.IsExif() {
+			return bytes.TrimPrefix(chunk.Data, []byte(jpegmeta.HeaderExif))
+		}
+	}
+	return nil
+}
+
+// Rewrite rewrites the metadata of a webp per a given filter.
+//
+// The exif chunk is filtered like the exif segment of a jpeg, and the xmp chunk is removed
+// where the xmp segment of a jpeg would be; the flags of the extended chunk are updated to match.
+// Exif chunks that can't be decoded are dropped unless the filter keeps everything.
+func Rewrite(contents []byte, filter jpegmeta.Filter) ([]byte, error) {
+	chunks, err := Split(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var output []Chunk
+	var flags byte
+	for _, chunk := range chunks {
+		switch {
+		case chunk.IsExif():
+			// some encoders write the exif header of a jpeg segment before the tiff structure.
+			header := []byte(nil)
+			if bytes.HasPrefix(chunk.Data, []byte(jpegmeta.HeaderExif)) {
+				header = []byte(jpegmeta.HeaderExif)
+			}
+			rewritten, err := jpegmeta.RewriteTIFF(chunk.Data[len(header):], filter)
+			if err != nil {
+				if filter.IsKeepAll() {
+					output = append(output, chunk)
+					flags |= FlagExif
+				}
+				continue
+			}
+			if len(rewritten) == 0 {
+				continue
+			}
+			output = append(output, Chunk{FourCC: ChunkExif, Data: append(header, rewritten...)})
+			flags |= FlagExif
+		case chunk.IsXMP():
+			if !filter.StripExtended {
+				output = append(output, chunk)
+				flags |= FlagXMP
+			}
+		default:
+			output = append(output, chunk)
+		}
+	}
+	for index, chunk := range output {
+		if chunk.FourCC == ChunkExtended && len(chunk.Data) > 0 {
+			data := append([]byte(nil), chunk.Data...)
+			data[0] = data[0]&^(FlagExif|FlagXMP) | flags
+			output[index] = Chunk{FourCC: ChunkExtended, Data: data}
+		}
+	}
+	return Join(output), nil
+}
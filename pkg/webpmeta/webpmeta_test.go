@@ -0,0 +1,55 @@
+package webpmeta
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+
+	"github.com/wcharczuk/blogctl/pkg/jpegmeta"
+)
+
+// testChunks are the chunks of an extended webp with exif that can't be decoded and xmp;
+// the image data isn't valid, as only the container is read.
+func testChunks() []Chunk {
+	return []Chunk{
+		{FourCC: ChunkExtended, Data: []byte{FlagExif | FlagXMP, 0, 0, 0, 7, 0, 0, 7, 0, 0}},
+		{FourCC: "VP8L", Data: []byte("odd")},
+		{FourCC: ChunkExif, Data: []byte(jpegmeta.HeaderExif + "not exif")},
+		{FourCC: ChunkXMP, Data: []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`)},
+	}
+}
+
+func TestSplitJoin(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := Join(testChunks())
+	chunks, err := Split(contents)
+	assert.Nil(err)
+	assert.Equal(testChunks(), chunks)
+	// odd chunks are padded.
+	assert.Zero(len(contents) % 2)
+	assert.Equal([]byte("not exif"), Exif(chunks))
+
+	_, err = Split([]byte("not a webp"))
+	assert.NotNil(err)
+	_, err = Split(contents[:len(contents)-4])
+	assert.NotNil(err)
+}
+
+func TestRewrite(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := Join(testChunks())
+	rewritten, err := Rewrite(contents, jpegmeta.Filter{})
+	assert.Nil(err)
+	assert.Equal(contents, rewritten)
+
+	// exif that can't be decoded is dropped along with the xmp unless everything is kept, and the flags follow.
+	rewritten, err = Rewrite(contents, jpegmeta.Filter{StripExtended: true})
+	assert.Nil(err)
+	chunks, err := Split(rewritten)
+	assert.Nil(err)
+	assert.Len(chunks, 2)
+	assert.Zero(chunks[0].Data[0])
+	assert.Equal("VP8L", chunks[1].FourCC)
+}